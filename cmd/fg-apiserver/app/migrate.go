@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/MortalSC/FastGO/cmd/fg-apiserver/app/options"
+	"github.com/MortalSC/FastGO/internal/apiserver"
+	"github.com/MortalSC/FastGO/internal/commonpkg/migrate"
+	genericoptions "github.com/MortalSC/FastGO/pkg/options"
+	"github.com/gosuri/uitable"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// newMigrateCommand creates the `migrate` subcommand which manages the database schema
+// It reads the same configuration as the server to locate the database
+func newMigrateCommand(opts *options.ServerOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "migrate",
+		Short:        "Manage the versioned database schema migrations",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+	}
+
+	var upSteps int
+	upCmd := &cobra.Command{
+		Use:   "up",
+		Short: "Apply pending migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := newMigrator(opts)
+			if err != nil {
+				return err
+			}
+
+			applied, err := migrator.Up(context.Background(), upSteps)
+			for _, mig := range applied {
+				fmt.Fprintf(cmd.OutOrStdout(), "applied %04d_%s\n", mig.Version, mig.Name)
+			}
+			if err == nil && len(applied) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "no pending migrations")
+			}
+			return err
+		},
+	}
+	upCmd.Flags().IntVar(&upSteps, "steps", 0, "Number of migrations to apply, 0 applies all pending migrations")
+
+	var downSteps int
+	downCmd := &cobra.Command{
+		Use:   "down",
+		Short: "Revert applied migrations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := newMigrator(opts)
+			if err != nil {
+				return err
+			}
+
+			reverted, err := migrator.Down(context.Background(), downSteps)
+			for _, mig := range reverted {
+				fmt.Fprintf(cmd.OutOrStdout(), "reverted %04d_%s\n", mig.Version, mig.Name)
+			}
+			return err
+		},
+	}
+	downCmd.Flags().IntVar(&downSteps, "steps", 1, "Number of migrations to revert, 0 reverts all applied migrations")
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of every migration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			migrator, err := newMigrator(opts)
+			if err != nil {
+				return err
+			}
+
+			statuses, err := migrator.Status(context.Background())
+			if err != nil {
+				return err
+			}
+
+			table := uitable.New()
+			table.AddRow("VERSION", "NAME", "STATUS", "APPLIED AT")
+			for _, st := range statuses {
+				state, appliedAt := "pending", ""
+				if st.Applied {
+					state, appliedAt = "applied", st.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				if st.Modified {
+					state = "modified"
+				}
+				table.AddRow(fmt.Sprintf("%04d", st.Version), st.Name, state, appliedAt)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), table.String())
+			return nil
+		},
+	}
+
+	var dir string
+	createCmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create empty up/down migration files for every SQL dialect",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, dialect := range []string{genericoptions.DriverMySQL, genericoptions.DriverSQLite} {
+				files, err := migrate.Create(filepath.Join(dir, dialect), args[0])
+				if err != nil {
+					return err
+				}
+				for _, file := range files {
+					fmt.Fprintf(cmd.OutOrStdout(), "created %s\n", file)
+				}
+			}
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&dir, "dir", filepath.Join("configs", "migrations"), "Directory holding the migration files of every dialect")
+
+	cmd.AddCommand(upCmd, downCmd, statusCmd, createCmd)
+
+	return cmd
+}
+
+// newMigrator loads the configuration and connects to the configured database
+func newMigrator(opts *options.ServerOptions) (*migrate.Migrator, error) {
+	cfg, db, err := connect(opts, "migrated on startup")
+	if err != nil {
+		return nil, err
+	}
+	return cfg.NewMigrator(db)
+}
+
+// connect loads the configuration and connects to the configured database
+// The memory driver is refused since its database lives in the server only, reason tells what
+// the server does with it instead.
+func connect(opts *options.ServerOptions, reason string) (*apiserver.Config, *gorm.DB, error) {
+	if err := viper.Unmarshal(opts); err != nil {
+		return nil, nil, err
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	if opts.StorageOptions.Driver == genericoptions.DriverMemory {
+		return nil, nil, fmt.Errorf("the memory storage driver is %s and cannot be managed", reason)
+	}
+
+	cfg, err := opts.Config()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := cfg.NewDB()
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, db, nil
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"encoding/json"
+
+	"github.com/MortalSC/FastGO/internal/apiserver"
+	"github.com/spf13/cobra"
+)
+
+// newOpenAPICommand creates the `openapi` subcommand which prints the OpenAPI document
+// of the REST API, the document checked in as docs/openapi.json
+func newOpenAPICommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "openapi",
+		Short:        "Print the OpenAPI document of the REST API",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(apiserver.NewOpenAPI())
+		},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/MortalSC/FastGO/internal/apiserver"
+	"github.com/MortalSC/FastGO/internal/pkg/log"
 	genericoptions "github.com/MortalSC/FastGO/pkg/options"
 )
 
@@ -14,13 +15,44 @@ import (
 // Acts as a root configuration container that aggregates various subsystem configurations
 // Supports both JSON serialization and configuration file parsing via mapstructure
 type ServerOptions struct {
-	MySQLOptions *genericoptions.MySQLOptions `json:"mysql" mapstructure:"mysql"`
-	Addr         string                       `json:"addr" mapstructure:"addr"`
+	StorageOptions   *genericoptions.StorageOptions   `json:"storage" mapstructure:"storage"`
+	MySQLOptions     *genericoptions.MySQLOptions     `json:"mysql" mapstructure:"mysql"`
+	MigrationOptions *genericoptions.MigrationOptions `json:"migration" mapstructure:"migration"`
+	Addr             string                           `json:"addr" mapstructure:"addr"`
+	// GRPCAddr is the address of the gRPC server, empty disables it
+	GRPCAddr string `json:"grpc-addr" mapstructure:"grpc-addr"`
+	// TrustedProxies are the IPs and CIDRs of the proxies whose X-Forwarded-For header gives the
+	// client IP, the header is ignored if empty
+	TrustedProxies []string `json:"trusted-proxies" mapstructure:"trusted-proxies"`
+	// TLSOptions serves HTTPS, and gRPC over TLS, when a certificate is configured
+	TLSOptions *genericoptions.TLSOptions `json:"tls" mapstructure:"tls"`
+	// DocsOptions serves the API documentation page
+	DocsOptions *genericoptions.DocsOptions `json:"docs" mapstructure:"docs"`
+	// TracingOptions exports the OpenTelemetry spans of the requests
+	TracingOptions *genericoptions.TracingOptions `json:"tracing" mapstructure:"tracing"`
+	// HealthOptions configures the checks of the liveness and readiness probes
+	HealthOptions *genericoptions.HealthOptions `json:"health" mapstructure:"health"`
+	// MetricsOptions serves the Prometheus metrics on a listener apart from the API
+	MetricsOptions *genericoptions.MetricsOptions `json:"metrics" mapstructure:"metrics"`
+	// RateLimitOptions limits the rate of the requests of every route group
+	RateLimitOptions *genericoptions.RateLimitOptions `json:"rate-limit" mapstructure:"rate-limit"`
+	// LockoutOptions locks the accounts and the client IPs whose logins fail repeatedly
+	LockoutOptions *genericoptions.LockoutOptions `json:"lockout" mapstructure:"lockout"`
+	// PasswordOptions configures the hashing of the passwords and the passwords users may choose
+	PasswordOptions *genericoptions.PasswordOptions `json:"password" mapstructure:"password"`
+	// ShutdownOptions configures the pre-stop delay, the drain period and the timeout of the shutdown
+	ShutdownOptions *genericoptions.ShutdownOptions `json:"shutdown" mapstructure:"shutdown"`
+	// LogOptions configures the format, the level and the output of the logs
+	LogOptions *log.Options `json:"log" mapstructure:"log"`
 
 	// JWTKey is the key used to sign JWT tokens
 	JWTKey string `json:"jwt_key" mapstructure:"jwt_key"`
+	// JWTOptions configures asymmetric and rotated signing keys, which take precedence over JWTKey
+	JWTOptions *genericoptions.JWTOptions `json:"jwt" mapstructure:"jwt"`
 	// JWTExpire is the expiration time for JWT tokens
 	Expiration time.Duration `json:"expiration" mapstructure:"expiration"`
+	// RefreshExpiration is the expiration time for refresh tokens, which bounds how long a session may stay idle
+	RefreshExpiration time.Duration `json:"refresh-expiration" mapstructure:"refresh-expiration"`
 }
 
 // NewServerOptions creates a ServerOptions instance with default values
@@ -29,9 +61,24 @@ type ServerOptions struct {
 // these values through configuration files or environment variables
 func NewServerOptions() *ServerOptions {
 	return &ServerOptions{
-		MySQLOptions: genericoptions.NewMySQLOptions(),
-		Addr:         "0.0.0.0:6666",
-		Expiration:   2 * time.Hour,
+		StorageOptions:    genericoptions.NewStorageOptions(),
+		MySQLOptions:      genericoptions.NewMySQLOptions(),
+		MigrationOptions:  genericoptions.NewMigrationOptions(),
+		JWTOptions:        genericoptions.NewJWTOptions(),
+		TLSOptions:        genericoptions.NewTLSOptions(),
+		DocsOptions:       genericoptions.NewDocsOptions(),
+		TracingOptions:    genericoptions.NewTracingOptions(),
+		HealthOptions:     genericoptions.NewHealthOptions(),
+		MetricsOptions:    genericoptions.NewMetricsOptions(),
+		ShutdownOptions:   genericoptions.NewShutdownOptions(),
+		RateLimitOptions:  genericoptions.NewRateLimitOptions(),
+		LockoutOptions:    genericoptions.NewLockoutOptions(),
+		PasswordOptions:   genericoptions.NewPasswordOptions(),
+		LogOptions:        log.NewOptions(),
+		Addr:              "0.0.0.0:6666",
+		GRPCAddr:          "0.0.0.0:6667",
+		Expiration:        15 * time.Minute,
+		RefreshExpiration: 7 * 24 * time.Hour,
 	}
 }
 
@@ -40,7 +87,18 @@ func NewServerOptions() *ServerOptions {
 // Returns the first encountered error or nil if all configurations are valid
 // Ensures the service starts with a valid configuration state
 func (s *ServerOptions) Validate() error {
-	if err := s.MySQLOptions.Validate(); err != nil {
+	if err := s.StorageOptions.Validate(); err != nil {
+		return err
+	}
+
+	// MySQL settings are only required when data is stored in MySQL
+	if s.StorageOptions.Driver == genericoptions.DriverMySQL {
+		if err := s.MySQLOptions.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.MigrationOptions.Validate(); err != nil {
 		return err
 	}
 
@@ -48,11 +106,85 @@ func (s *ServerOptions) Validate() error {
 	if s.Addr == "" {
 		return fmt.Errorf("server address cannot be empty")
 	}
+	if err := validateAddr(s.Addr); err != nil {
+		return err
+	}
+	if s.GRPCAddr != "" {
+		if err := validateAddr(s.GRPCAddr); err != nil {
+			return err
+		}
+	}
 
-	// check the address format "host:port"
-	_, portStr, err := net.SplitHostPort(s.Addr)
+	for _, proxy := range s.TrustedProxies {
+		if err := validateProxy(proxy); err != nil {
+			return err
+		}
+	}
+
+	if err := s.TLSOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.DocsOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.TracingOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.HealthOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.MetricsOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.RateLimitOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.LockoutOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.PasswordOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.ShutdownOptions.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.LogOptions.Validate(); err != nil {
+		return err
+	}
+
+	if s.Expiration <= 0 || s.RefreshExpiration <= 0 {
+		return fmt.Errorf("token expiration and refresh-expiration must be positive")
+	}
+	if s.RefreshExpiration < s.Expiration {
+		return fmt.Errorf("refresh-expiration must not be shorter than expiration")
+	}
+
+	if err := s.JWTOptions.Validate(); err != nil {
+		return err
+	}
+
+	// Validate JWT key, which is only used when no signing keys are configured
+	if !s.JWTOptions.Enabled() && len(s.JWTKey) < 6 {
+		return fmt.Errorf("JWT key must be at least 6 characters long")
+	}
+
+	return nil
+}
+
+// validateAddr checks the "host:port" format of a listening address
+func validateAddr(addr string) error {
+	_, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
-		return fmt.Errorf("invalid server address format %s: %v", s.Addr, err)
+		return fmt.Errorf("invalid server address format %s: %v", addr, err)
 	}
 
 	// check if port is a valid number [1, 65535]
@@ -60,12 +192,17 @@ func (s *ServerOptions) Validate() error {
 	if err != nil || port < 1 || port > 65535 {
 		return fmt.Errorf("invalid port number %s: %v", portStr, err)
 	}
+	return nil
+}
 
-	// Validate JWT key
-	if len(s.JWTKey) < 6 {
-		return fmt.Errorf("JWT key must be at least 6 characters long")
+// validateProxy checks that a trusted proxy is an IP or a CIDR
+func validateProxy(proxy string) error {
+	if net.ParseIP(proxy) != nil {
+		return nil
+	}
+	if _, _, err := net.ParseCIDR(proxy); err != nil {
+		return fmt.Errorf("invalid trusted proxy '%s', must be an IP or a CIDR", proxy)
 	}
-
 	return nil
 }
 
@@ -75,9 +212,25 @@ func (s *ServerOptions) Validate() error {
 // should be made through ServerOptions before regeneration
 func (s *ServerOptions) Config() (*apiserver.Config, error) {
 	return &apiserver.Config{
-		MySQLOptions: s.MySQLOptions,
-		Addr:         s.Addr,
-		JWTKey:       s.JWTKey,
-		ExpiraTime:   s.Expiration,
+		StorageOptions:    s.StorageOptions,
+		MySQLOptions:      s.MySQLOptions,
+		MigrationOptions:  s.MigrationOptions,
+		Addr:              s.Addr,
+		GRPCAddr:          s.GRPCAddr,
+		TrustedProxies:    s.TrustedProxies,
+		TLSOptions:        s.TLSOptions,
+		DocsOptions:       s.DocsOptions,
+		TracingOptions:    s.TracingOptions,
+		HealthOptions:     s.HealthOptions,
+		MetricsOptions:    s.MetricsOptions,
+		ShutdownOptions:   s.ShutdownOptions,
+		RateLimitOptions:  s.RateLimitOptions,
+		LockoutOptions:    s.LockoutOptions,
+		PasswordOptions:   s.PasswordOptions,
+		LogOptions:        s.LogOptions,
+		JWTKey:            s.JWTKey,
+		JWTOptions:        s.JWTOptions,
+		ExpiraTime:        s.Expiration,
+		RefreshExpiraTime: s.RefreshExpiration,
 	}, nil
 }
@@ -1,11 +1,12 @@
 package app
 
 import (
-	"io"
-	"log/slog"
-	"os"
+	"context"
+	"os/signal"
+	"syscall"
 
 	"github.com/MortalSC/FastGO/cmd/fg-apiserver/app/options"
+	"github.com/MortalSC/FastGO/internal/pkg/log"
 	"github.com/MortalSC/FastGO/pkg/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -52,21 +53,28 @@ func NewFastG0Command() *cobra.Command {
 	// Add --version flag to display version information
 	version.AddFlags(cmd.PersistentFlags())
 
+	// Add the subcommand managing the database schema
+	cmd.AddCommand(newMigrateCommand(opts))
+
+	// Add the subcommand creating the users, such as the first administrator
+	cmd.AddCommand(newUserCommand(opts))
+
+	// Add the subcommand printing the OpenAPI document
+	cmd.AddCommand(newOpenAPICommand())
+
 	return cmd
 }
 
 // run executes the main application workflow:
 // Config unmarshalling
 // Config validation
+// Logger initialization
 // Server construction
-// Server execution
+// Server execution until SIGINT or SIGTERM
 func run(opts *options.ServerOptions) error {
 	// if has --version flag, print version and exit
 	version.PrintAndExitIfRequested()
 
-	//
-	initLog()
-
 	if err := viper.Unmarshal(opts); err != nil {
 		return err
 	}
@@ -75,73 +83,25 @@ func run(opts *options.ServerOptions) error {
 		return err
 	}
 
-	cfg, err := opts.Config()
-	if err != nil {
+	if err := log.Init(opts.LogOptions); err != nil {
 		return err
 	}
 
-	server, err := cfg.NewServer()
+	cfg, err := opts.Config()
 	if err != nil {
 		return err
 	}
 
-	return server.Run()
-}
-
-// initLog initializes the logging system
-func initLog() {
-	// Get the log configuration from viper
-	format := viper.GetString("log.format")
-	level := viper.GetString("log.level")
-	output := viper.GetString("log.output")
-
-	// Conversion the log
-	var slevel slog.Level
-	switch level {
-	case "debug":
-		slevel = slog.LevelDebug
-	case "info":
-		slevel = slog.LevelInfo
-	case "warn":
-		slevel = slog.LevelWarn
-	case "error":
-		slevel = slog.LevelError
-	default:
-		slevel = slog.LevelInfo
-	}
-
-	opts := &slog.HandlerOptions{
-		Level: slevel,
-	}
-
-	var w io.Writer
-	var err error
-	// Conversion the log output path
-	switch output {
-	case "", "stdout":
-		w = os.Stdout
-	default:
-		w, err = os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-		if err != nil {
-			panic(err)
-		}
-	}
-
-	// Conversion the log format
+	server, err := cfg.NewServer()
 	if err != nil {
-		return
+		return err
 	}
 
-	var handler slog.Handler
-	switch format {
-	case "json":
-		handler = slog.NewJSONHandler(w, opts)
-	case "text":
-		handler = slog.NewTextHandler(w, opts)
-	default:
-		handler = slog.NewTextHandler(w, opts)
-	}
+	// SIGTERM is sent by `kill` and by orchestrators, SIGINT by CTRL+C. SIGKILL cannot be caught.
+	// Once the shutdown started, a second signal terminates the process immediately.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	context.AfterFunc(ctx, stop)
 
-	// Set the global log instance as a custom log instance
-	slog.SetDefault(slog.New(handler))
+	return server.Run(ctx)
 }
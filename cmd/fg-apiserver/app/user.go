@@ -0,0 +1,72 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MortalSC/FastGO/cmd/fg-apiserver/app/options"
+	"github.com/MortalSC/FastGO/internal/pkg/known"
+	apiv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/spf13/cobra"
+)
+
+// newUserCommand creates the `user` subcommand which manages the users in the database
+// It reads the same configuration as the server to locate the database
+func newUserCommand(opts *options.ServerOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "user",
+		Short:        "Manage the users in the database",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+	}
+
+	var (
+		req      apiv1.CreateUserRequest
+		nickname string
+		role     string
+	)
+	createCmd := &cobra.Command{
+		Use:   "create USERNAME",
+		Short: "Create a user, such as the first administrator",
+		Long: "Create a user with the given role, such as the first administrator, since the sign up only creates users.\n" +
+			"The password is read from the standard input when --password is not set.",
+		Example: "  fg-apiserver user create root --role admin --email root@example.com --phone 18800000000 < password.txt",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Username = args[0]
+			if cmd.Flags().Changed("nickname") {
+				req.Nickname = &nickname
+			}
+			if req.Password == "" {
+				line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("failed to read the password from the standard input: %w", err)
+				}
+				req.Password = strings.TrimRight(line, "\r\n")
+			}
+
+			cfg, db, err := connect(opts, "empty on startup")
+			if err != nil {
+				return err
+			}
+
+			userID, err := cfg.CreateUser(context.Background(), db, &req, role)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "created %s user %s (%s)\n", role, req.Username, userID)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&req.Password, "password", "", "Password of the user, read from the standard input if empty")
+	createCmd.Flags().StringVar(&req.Email, "email", "", "Email of the user")
+	createCmd.Flags().StringVar(&req.Phone, "phone", "", "Phone number of the user")
+	createCmd.Flags().StringVar(&role, "role", known.RoleUser, "Role of the user: admin or user")
+	createCmd.Flags().StringVar(&nickname, "nickname", "", "Nickname of the user")
+
+	cmd.AddCommand(createCmd)
+
+	return cmd
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultServer is the address of fg-apiserver used when neither the flag nor the config file sets one
+const defaultServer = "http://127.0.0.1:6666"
+
+// Config is the local config file of fgctl, it keeps the server and the session of the last login
+type Config struct {
+	Server          string    `yaml:"server"`
+	Username        string    `yaml:"username,omitempty"`
+	Token           string    `yaml:"token,omitempty"`
+	ExpireAt        time.Time `yaml:"expire_at,omitempty"`
+	RefreshToken    string    `yaml:"refresh_token,omitempty"`
+	RefreshExpireAt time.Time `yaml:"refresh_expire_at,omitempty"`
+}
+
+// defaultConfigFile returns $HOME/.fgctl/config.yaml
+func defaultConfigFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".fgctl", "config.yaml")
+	}
+	return filepath.Join(home, ".fgctl", "config.yaml")
+}
+
+// loadConfig reads the config file at path, a missing file yields an empty config
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// save writes the config file at path, it is only readable by its owner since it holds tokens
+func (cfg *Config) save(path string) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// clearSession forgets the tokens of the last login
+func (cfg *Config) clearSession() {
+	cfg.Username, cfg.Token, cfg.RefreshToken = "", "", ""
+	cfg.ExpireAt, cfg.RefreshExpireAt = time.Time{}, time.Time{}
+}
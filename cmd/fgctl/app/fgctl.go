@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/MortalSC/FastGO/pkg/client"
+	"github.com/MortalSC/FastGO/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+// options are the global flags shared by all fgctl commands
+type options struct {
+	configFile string
+	server     string
+	output     string
+	caFile     string
+	insecure   bool
+}
+
+// NewFgctlCommand creates the root command of fgctl, the command-line client of fg-apiserver
+func NewFgctlCommand() *cobra.Command {
+	opts := &options{}
+
+	cmd := &cobra.Command{
+		Use:   "fgctl",
+		Short: "fgctl controls the fg-apiserver",
+		Long:  "fgctl is the command-line client of fg-apiserver, it logs in once and keeps the session in a local config file.",
+
+		SilenceUsage: true,
+
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			version.PrintAndExitIfRequested()
+
+			switch opts.output {
+			case OutputTable, OutputJSON, OutputYAML:
+				return nil
+			default:
+				return fmt.Errorf("unknown output format %q, must be one of table, json or yaml", opts.output)
+			}
+		},
+
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.configFile, "config", defaultConfigFile(), "Path to the fgctl config file holding the server and the session")
+	flags.StringVarP(&opts.server, "server", "s", "", "Address of fg-apiserver, defaults to the server of the last login or "+defaultServer)
+	flags.StringVarP(&opts.output, "output", "o", OutputTable, "Output format, one of table, json or yaml")
+	flags.StringVar(&opts.caFile, "certificate-authority", "", "Path to the CA certificate verifying the server")
+	flags.BoolVar(&opts.insecure, "insecure-skip-tls-verify", false, "Skip the verification of the server certificate, insecure")
+	version.AddFlags(flags)
+
+	cmd.AddCommand(
+		newLoginCommand(opts),
+		newLogoutCommand(opts),
+		newUserCommand(opts),
+		newPostCommand(opts),
+		newPasswordCommand(opts),
+	)
+
+	return cmd
+}
+
+// session is the client of a command with the config it was created from
+type session struct {
+	*client.Client
+	cfg *Config
+	// dirty is set by commands which changed cfg, so that it is written back
+	dirty bool
+}
+
+// run runs fn with a client of the configured server and session, tokens refreshed by the
+// client on the way are written back to the config file
+func (o *options) run(cmd *cobra.Command, fn func(ctx context.Context, s *session) error) error {
+	cfg, err := loadConfig(o.configFile)
+	if err != nil {
+		return err
+	}
+	if o.server != "" {
+		cfg.Server = o.server
+	}
+	if cfg.Server == "" {
+		cfg.Server = defaultServer
+	}
+
+	hc, err := o.httpClient()
+	if err != nil {
+		return err
+	}
+
+	s := &session{
+		Client: client.New(cfg.Server, client.WithHTTPClient(hc), client.WithTokens(cfg.Token, cfg.ExpireAt, cfg.RefreshToken)),
+		cfg:    cfg,
+	}
+	loadedToken, loadedRefreshToken := cfg.Token, cfg.RefreshToken
+	runErr := fn(cmd.Context(), s)
+
+	if token, expireAt, refreshToken := s.Token(); token != loadedToken || refreshToken != loadedRefreshToken {
+		cfg.Token, cfg.ExpireAt, cfg.RefreshToken = token, expireAt, refreshToken
+		s.dirty = true
+	}
+	if s.dirty {
+		if err := cfg.save(o.configFile); err != nil && runErr == nil {
+			return err
+		}
+	}
+	return runErr
+}
+
+// httpClient creates the HTTP client honouring the TLS flags
+func (o *options) httpClient() (*http.Client, error) {
+	if o.caFile == "" && !o.insecure {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: o.insecure} //nolint:gosec // explicitly requested by the user
+	if o.caFile != "" {
+		pem, err := os.ReadFile(o.caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", o.caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// print prints a response in the format of the --output flag
+func (o *options) print(cmd *cobra.Command, v any, tbl *table) error {
+	return printObject(cmd.OutOrStdout(), o.output, v, tbl)
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newLoginCommand creates the `login` command which logs in and stores the session in the config file
+func newLoginCommand(opts *options) *cobra.Command {
+	var password string
+	var passwordStdin bool
+
+	cmd := &cobra.Command{
+		Use:   "login USERNAME",
+		Short: "Log in to fg-apiserver and store the session in the config file",
+		Example: `  # Log in, the password is prompted for
+  fgctl login --server https://fastgo.example.com root
+
+  # Log in from a script
+  echo "$PASSWORD" | fgctl login root --password-stdin`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if password == "" {
+				if !passwordStdin {
+					fmt.Fprint(cmd.ErrOrStderr(), "Password: ")
+				}
+				var err error
+				if password, err = readLine(cmd.InOrStdin()); err != nil {
+					return err
+				}
+			}
+
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				resp, err := s.Login(ctx, args[0], password)
+				if err != nil {
+					return err
+				}
+
+				s.cfg.Username, s.cfg.RefreshExpireAt = args[0], resp.RefreshExpireAt
+				s.dirty = true
+				fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %s as %s, the session expires at %s\n",
+					s.cfg.Server, args[0], formatTime(resp.RefreshExpireAt))
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&password, "password", "p", "", "Password of the user, prefer --password-stdin since flags show up in the process list")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the password from stdin")
+
+	return cmd
+}
+
+// newLogoutCommand creates the `logout` command which revokes the session and removes it from the config file
+func newLogoutCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Log out of fg-apiserver and remove the session from the config file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				if err := s.Logout(ctx); err != nil {
+					return err
+				}
+
+				s.cfg.clearSession()
+				fmt.Fprintln(cmd.OutOrStdout(), "Logged out")
+				return nil
+			})
+		},
+	}
+}
+
+// readLine reads a single line, without the line break
+func readLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("read password: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
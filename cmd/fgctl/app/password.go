@@ -0,0 +1,84 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/spf13/cobra"
+)
+
+// newPasswordCommand creates the `password` command managing passwords
+func newPasswordCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "password",
+		Short: "Manage passwords",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(newPasswordChangeCommand(opts))
+
+	return cmd
+}
+
+func newPasswordChangeCommand(opts *options) *cobra.Command {
+	var oldPassword, newPassword string
+
+	cmd := &cobra.Command{
+		Use:   "change [USER]",
+		Short: "Change the password of a user, the logged in user by default",
+		Long: `Change the password of a user, the logged in user by default.
+
+Changing a password revokes all sessions of the user, including the session of fgctl
+when it is the logged in user, so log in again afterwards. The passwords which are not
+given by flags are read from stdin, one per line.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := bufio.NewReader(cmd.InOrStdin())
+			for _, p := range []struct {
+				value  *string
+				prompt string
+			}{{&oldPassword, "Old password: "}, {&newPassword, "New password: "}} {
+				if *p.value != "" {
+					continue
+				}
+				fmt.Fprint(cmd.ErrOrStderr(), p.prompt)
+				line, err := in.ReadString('\n')
+				if line = strings.TrimRight(line, "\r\n"); line == "" {
+					return fmt.Errorf("read password: %w", err)
+				}
+				*p.value = line
+			}
+
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				userID := s.cfg.Username
+				if len(args) > 0 {
+					userID = args[0]
+				}
+				if userID == "" {
+					return fmt.Errorf("not logged in, log in first or name the user")
+				}
+
+				req := &v1.ChangePasswordRequest{UserID: userID, OldPassword: oldPassword, NewPassword: newPassword}
+				if _, err := s.Users().ChangePassword(ctx, req); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(cmd.OutOrStdout(), "Password of %s changed\n", userID)
+				if userID == s.cfg.Username {
+					s.cfg.clearSession()
+					s.dirty = true
+					fmt.Fprintln(cmd.OutOrStdout(), "The session was revoked, log in again")
+				}
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&oldPassword, "old-password", "", "Current password of the user")
+	cmd.Flags().StringVar(&newPassword, "new-password", "", "New password of the user")
+
+	return cmd
+}
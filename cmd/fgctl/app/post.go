@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/spf13/cobra"
+)
+
+// newPostCommand creates the `post` command managing the posts of the logged in user
+func newPostCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "post",
+		Short: "Manage the posts of the logged in user",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(
+		newPostListCommand(opts),
+		newPostGetCommand(opts),
+		newPostCreateCommand(opts),
+		newPostUpdateCommand(opts),
+		newPostDeleteCommand(opts),
+	)
+
+	return cmd
+}
+
+// postTable is the table view of posts, the content is left to the json and yaml formats
+func postTable(posts ...*v1.Post) *table {
+	tbl := &table{header: []any{"POST ID", "USER ID", "TITLE", "CREATED AT", "UPDATED AT"}}
+	for _, p := range posts {
+		tbl.addRow(p.PostID, p.UserID, p.Title, formatTime(p.CreateAt), formatTime(p.UpdateAt))
+	}
+	return tbl
+}
+
+func newPostListCommand(opts *options) *cobra.Command {
+	req := &v1.ListPostRequest{}
+	var title string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the posts of the logged in user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Title = changed(cmd, "title", title)
+
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				resp, err := s.Posts().List(ctx, req)
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd, resp, postTable(resp.Posts...).withFooter(pageFooter(resp.Total, resp.NextCursor)))
+			})
+		},
+	}
+
+	addPageFlags(cmd, &req.Limit, &req.Offset, &req.Cursor, &req.SkipCount, &req.Filter, &req.Sort)
+	cmd.Flags().StringVar(&title, "title", "", "Only list the posts whose title contains the given text")
+
+	return cmd
+}
+
+func newPostGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get POST_ID",
+		Short: "Get a post",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				resp, err := s.Posts().Get(ctx, &v1.GetPostRequest{PostID: args[0]})
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd, resp.Post, postTable(resp.Post))
+			})
+		},
+	}
+}
+
+func newPostCreateCommand(opts *options) *cobra.Command {
+	req := &v1.CreatePostRequest{}
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a post",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				resp, err := s.Posts().Create(ctx, req)
+				if err != nil {
+					return err
+				}
+
+				tbl := &table{header: []any{"POST ID", "TITLE"}}
+				tbl.addRow(resp.PostID, req.Title)
+				return opts.print(cmd, resp, tbl)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&req.Title, "title", "", "Title of the post")
+	cmd.Flags().StringVar(&req.Content, "content", "", "Content of the post")
+	_ = cmd.MarkFlagRequired("title")
+	_ = cmd.MarkFlagRequired("content")
+
+	return cmd
+}
+
+func newPostUpdateCommand(opts *options) *cobra.Command {
+	var title, content string
+
+	cmd := &cobra.Command{
+		Use:   "update POST_ID",
+		Short: "Update a post, only the given flags are changed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &v1.UpdatePostRequest{
+				PostID:  args[0],
+				Title:   changed(cmd, "title", title),
+				Content: changed(cmd, "content", content),
+			}
+
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				if _, err := s.Posts().Update(ctx, req); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Post %s updated\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&title, "title", "", "New title of the post")
+	cmd.Flags().StringVar(&content, "content", "", "New content of the post")
+
+	return cmd
+}
+
+func newPostDeleteCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete POST_ID...",
+		Short: "Delete posts",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				if _, err := s.Posts().Delete(ctx, &v1.DeletePostRequest{PostID: args}); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "%d post(s) deleted\n", len(args))
+				return nil
+			})
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gosuri/uitable"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the --output flag
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// table is the tabular view of a response, printed by the table output format
+type table struct {
+	header []any
+	rows   [][]any
+	// footer is printed below the rows, e.g. the cursor of the next page
+	footer string
+}
+
+// addRow appends a row to the table
+func (t *table) addRow(cells ...any) {
+	t.rows = append(t.rows, cells)
+}
+
+// withFooter sets the footer of the table
+func (t *table) withFooter(footer string) *table {
+	t.footer = footer
+	return t
+}
+
+// printObject prints v in the given format, the table format prints tbl
+func printObject(w io.Writer, format string, v any, tbl *table) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		// Go through JSON so that the YAML keys are the json tags of the API types
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var obj any
+		if err := yaml.Unmarshal(data, &obj); err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(obj)
+	case OutputTable:
+		ut := uitable.New()
+		ut.MaxColWidth = 60
+		ut.AddRow(tbl.header...)
+		for _, row := range tbl.rows {
+			ut.AddRow(row...)
+		}
+		if _, err := fmt.Fprintln(w, ut.String()); err != nil {
+			return err
+		}
+		if tbl.footer != "" {
+			_, err := fmt.Fprintln(w, "\n"+tbl.footer)
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q, must be one of table, json or yaml", format)
+	}
+}
+
+// formatTime formats the timestamps of the table output
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/spf13/cobra"
+)
+
+// newUserCommand creates the `user` command managing users
+func newUserCommand(opts *options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage users",
+		Args:  cobra.NoArgs,
+	}
+
+	cmd.AddCommand(
+		newUserListCommand(opts),
+		newUserGetCommand(opts),
+		newUserCreateCommand(opts),
+		newUserUpdateCommand(opts),
+		newUserDeleteCommand(opts),
+	)
+
+	return cmd
+}
+
+// userTable is the table view of users
+func userTable(users ...*v1.User) *table {
+	tbl := &table{header: []any{"USER ID", "USERNAME", "NICKNAME", "EMAIL", "PHONE", "ROLE", "POSTS", "CREATED AT"}}
+	for _, u := range users {
+		tbl.addRow(u.UserID, u.Username, u.Nickname, u.Email, u.Phone, u.Role, u.PostCount, formatTime(u.CreateAt))
+	}
+	return tbl
+}
+
+func newUserListCommand(opts *options) *cobra.Command {
+	req := &v1.ListUserRequest{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List users, which is reserved for administrators",
+		Example: `  # List the users created this year, the newest first
+  fgctl user list --filter created_at>=2025-01-01 --sort -created_at`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				resp, err := s.Users().List(ctx, req)
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd, resp, userTable(resp.Users...).withFooter(pageFooter(resp.Total, resp.NextCursor)))
+			})
+		},
+	}
+
+	addPageFlags(cmd, &req.Limit, &req.Offset, &req.Cursor, &req.SkipCount, &req.Filter, &req.Sort)
+
+	return cmd
+}
+
+func newUserGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get USER",
+		Short: "Get a user by user ID or username",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				resp, err := s.Users().Get(ctx, &v1.GetUserRequest{UserID: args[0]})
+				if err != nil {
+					return err
+				}
+				return opts.print(cmd, resp.User, userTable(resp.User))
+			})
+		},
+	}
+}
+
+func newUserCreateCommand(opts *options) *cobra.Command {
+	req := &v1.CreateUserRequest{}
+	var nickname string
+
+	cmd := &cobra.Command{
+		Use:     "create",
+		Short:   "Create a user, which needs no login",
+		Example: `  fgctl user create --username colin --password fastgo1234 --email colin@example.com --phone 18888888888`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req.Nickname = changed(cmd, "nickname", nickname)
+
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				resp, err := s.Users().Create(ctx, req)
+				if err != nil {
+					return err
+				}
+
+				tbl := &table{header: []any{"USER ID", "USERNAME"}}
+				tbl.addRow(resp.UserID, req.Username)
+				return opts.print(cmd, resp, tbl)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&req.Username, "username", "", "Username of the user")
+	cmd.Flags().StringVar(&req.Password, "password", "", "Password of the user")
+	cmd.Flags().StringVar(&nickname, "nickname", "", "Nickname of the user")
+	cmd.Flags().StringVar(&req.Email, "email", "", "Email of the user")
+	cmd.Flags().StringVar(&req.Phone, "phone", "", "Phone number of the user")
+	for _, name := range []string{"username", "password", "email", "phone"} {
+		_ = cmd.MarkFlagRequired(name)
+	}
+
+	return cmd
+}
+
+func newUserUpdateCommand(opts *options) *cobra.Command {
+	var username, nickname, email, phone, role string
+
+	cmd := &cobra.Command{
+		Use:     "update USER",
+		Short:   "Update a user by user ID or username, only the given flags are changed",
+		Example: `  fgctl user update colin --nickname Colin --email colin@example.org`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &v1.UpdateUserRequest{
+				UserID:   args[0],
+				Username: changed(cmd, "username", username),
+				Nickname: changed(cmd, "nickname", nickname),
+				Email:    changed(cmd, "email", email),
+				Phone:    changed(cmd, "phone", phone),
+				Role:     changed(cmd, "role", role),
+			}
+
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				if _, err := s.Users().Update(ctx, req); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "User %s updated\n", args[0])
+				return nil
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&username, "username", "", "New username of the user")
+	cmd.Flags().StringVar(&nickname, "nickname", "", "New nickname of the user")
+	cmd.Flags().StringVar(&email, "email", "", "New email of the user")
+	cmd.Flags().StringVar(&phone, "phone", "", "New phone number of the user")
+	cmd.Flags().StringVar(&role, "role", "", "New role of the user, which only administrators can change")
+
+	return cmd
+}
+
+func newUserDeleteCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete USER...",
+		Short: "Delete users by user ID or username",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return opts.run(cmd, func(ctx context.Context, s *session) error {
+				for _, userID := range args {
+					if _, err := s.Users().Delete(ctx, &v1.DeleteUserRequest{UserID: userID}); err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.OutOrStdout(), "User %s deleted\n", userID)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// addPageFlags adds the paging, filter and sort flags of list commands
+func addPageFlags(cmd *cobra.Command, limit, offset *int64, cursor *string, skipCount *bool, filter, sort *string) {
+	cmd.Flags().Int64Var(limit, "limit", 0, "Maximum number of items to return, 0 uses the server default")
+	cmd.Flags().Int64Var(offset, "offset", 0, "Number of items to skip, cannot be combined with --cursor")
+	cmd.Flags().StringVar(cursor, "cursor", "", "Cursor of the next page printed by the previous list")
+	cmd.Flags().BoolVar(skipCount, "skip-count", false, "Omit the total number of items")
+	cmd.Flags().StringVar(filter, "filter", "", "Comma separated conditions such as created_at>=2025-01-01,username~go")
+	cmd.Flags().StringVar(sort, "sort", "", "Comma separated fields, prefixed with - for the descending order")
+}
+
+// changed returns a pointer to value if the flag name is set, so that optional fields of
+// update requests are left out unless given
+func changed(cmd *cobra.Command, name, value string) *string {
+	if !cmd.Flags().Changed(name) {
+		return nil
+	}
+	return &value
+}
+
+// pageFooter summarizes the total and the next cursor of a list below its table
+func pageFooter(total *int64, nextCursor string) string {
+	var footer string
+	if total != nil {
+		footer = fmt.Sprintf("Total: %d", *total)
+	}
+	if nextCursor != "" {
+		footer += fmt.Sprintf("\nNext page: --cursor %s", nextCursor)
+	}
+	return strings.TrimPrefix(footer, "\n")
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os"
+
+	"github.com/MortalSC/FastGO/cmd/fgctl/app"
+)
+
+func main() {
+	// 创建 fgctl 命令
+	command := app.NewFgctlCommand()
+
+	// 执行命令，出错时返回非零退出码，便于脚本判断执行结果
+	if err := command.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
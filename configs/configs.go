@@ -0,0 +1,18 @@
+// Package configs embeds the configuration assets shipped with fastgo,
+// so that binaries and tests can use them without depending on the working directory.
+package configs
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// migrationsFS holds the schema migrations of every supported SQL dialect
+//
+//go:embed migrations
+var migrationsFS embed.FS
+
+// Migrations returns the schema migration files of the given SQL dialect (mysql or sqlite)
+func Migrations(dialect string) (fs.FS, error) {
+	return fs.Sub(migrationsFS, "migrations/"+dialect)
+}
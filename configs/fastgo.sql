@@ -1,57 +0,0 @@
-/*M!999999\- enable the sandbox mode */ 
-
-/*!40101 SET @OLD_CHARACTER_SET_CLIENT=@@CHARACTER_SET_CLIENT */;
-/*!40101 SET @OLD_CHARACTER_SET_RESULTS=@@CHARACTER_SET_RESULTS */;
-/*!40101 SET @OLD_COLLATION_CONNECTION=@@COLLATION_CONNECTION */;
-/*!40101 SET NAMES utf8mb4 */;
-/*!40103 SET @OLD_TIME_ZONE=@@TIME_ZONE */;
-/*!40103 SET TIME_ZONE='+00:00' */;
-/*!40014 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0 */;
-/*!40014 SET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0 */;
-/*!40101 SET @OLD_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_VALUE_ON_ZERO' */;
-/*!40111 SET @OLD_SQL_NOTES=@@SQL_NOTES, SQL_NOTES=0 */;
-DROP TABLE IF EXISTS `post`;
-/*!40101 SET @saved_cs_client     = @@character_set_client */;
-/*!40101 SET character_set_client = utf8mb4 */;
-CREATE TABLE `post` (
-  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
-  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
-  `postID` varchar(35) NOT NULL DEFAULT '' COMMENT '博文唯一 ID',
-  `title` varchar(256) NOT NULL DEFAULT '' COMMENT '博文标题',
-  `content` longtext NOT NULL DEFAULT '' COMMENT '博文内容',
-  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '博文创建时间',
-  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '博文最后修改时间',
-  PRIMARY KEY (`id`),
-  UNIQUE KEY `post.postID` (`postID`),
-  KEY `idx.post.userID` (`userID`)
-) ENGINE=InnoDB AUTO_INCREMENT=2 DEFAULT CHARSET=utf8mb3 COLLATE=utf8mb3_general_ci COMMENT='博文表';
-/*!40101 SET character_set_client = @saved_cs_client */;
-DROP TABLE IF EXISTS `user`;
-/*!40101 SET @saved_cs_client     = @@character_set_client */;
-/*!40101 SET character_set_client = utf8mb4 */;
-CREATE TABLE `user` (
-  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
-  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
-  `username` varchar(255) NOT NULL DEFAULT '' COMMENT '用户名（唯一）',
-  `password` varchar(255) NOT NULL DEFAULT '' COMMENT '用户密码（加密后）',
-  `nickname` varchar(30) NOT NULL DEFAULT '' COMMENT '用户昵称',
-  `email` varchar(256) NOT NULL DEFAULT '' COMMENT '用户电子邮箱地址',
-  `phone` varchar(16) NOT NULL DEFAULT '' COMMENT '用户手机号',
-  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '用户创建时间',
-  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '用户最后修改时间',
-  PRIMARY KEY (`id`),
-  UNIQUE KEY `user.userID` (`userID`),
-  UNIQUE KEY `user.username` (`username`),
-  UNIQUE KEY `user.phone` (`phone`)
-) ENGINE=MyISAM AUTO_INCREMENT=99 DEFAULT CHARSET=utf8mb3 COLLATE=utf8mb3_general_ci COMMENT='用户表';
-/*!40101 SET character_set_client = @saved_cs_client */;
-/*!40103 SET TIME_ZONE=@OLD_TIME_ZONE */;
-
-/*!40101 SET SQL_MODE=@OLD_SQL_MODE */;
-/*!40014 SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS */;
-/*!40014 SET UNIQUE_CHECKS=@OLD_UNIQUE_CHECKS */;
-/*!40101 SET CHARACTER_SET_CLIENT=@OLD_CHARACTER_SET_CLIENT */;
-/*!40101 SET CHARACTER_SET_RESULTS=@OLD_CHARACTER_SET_RESULTS */;
-/*!40101 SET COLLATION_CONNECTION=@OLD_COLLATION_CONNECTION */;
-/*!40111 SET SQL_NOTES=@OLD_SQL_NOTES */;
-
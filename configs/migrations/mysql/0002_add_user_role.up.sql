@@ -0,0 +1,3 @@
+-- Roles used by the authorization policies, either 'admin' or 'user'.
+-- Promote the first administrator manually, e.g. UPDATE `user` SET `role` = 'admin' WHERE `username` = 'root';
+ALTER TABLE `user` ADD COLUMN `role` varchar(32) NOT NULL DEFAULT 'user' COMMENT '用户角色' AFTER `phone`;
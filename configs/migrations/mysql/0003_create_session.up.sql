@@ -0,0 +1,17 @@
+-- Login sessions, every session holds the current refresh token of a user and can be revoked.
+CREATE TABLE IF NOT EXISTS `session` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `sessionID` varchar(36) NOT NULL DEFAULT '' COMMENT '会话唯一 ID',
+  `userID` varchar(36) NOT NULL DEFAULT '' COMMENT '用户唯一 ID',
+  `refreshTokenHash` varchar(64) NOT NULL DEFAULT '' COMMENT '当前刷新令牌的 SHA-256',
+  `previousTokenHash` varchar(64) NOT NULL DEFAULT '' COMMENT '上一个刷新令牌的 SHA-256',
+  `expiresAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '刷新令牌过期时间',
+  `revokedAt` datetime DEFAULT NULL COMMENT '会话吊销时间',
+  `createdAt` datetime NOT NULL DEFAULT current_timestamp() COMMENT '会话创建时间',
+  `updatedAt` datetime NOT NULL DEFAULT current_timestamp() ON UPDATE current_timestamp() COMMENT '会话最后修改时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `session.sessionID` (`sessionID`),
+  UNIQUE KEY `session.refreshTokenHash` (`refreshTokenHash`),
+  KEY `idx.session.previousTokenHash` (`previousTokenHash`),
+  KEY `idx.session.userID` (`userID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3 COLLATE=utf8mb3_general_ci COMMENT='会话表';
@@ -0,0 +1,27 @@
+-- Initial fastgo schema, mirrors configs/fastgo.sql.
+CREATE TABLE IF NOT EXISTS `post` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `userID` varchar(36) NOT NULL DEFAULT '',
+  `postID` varchar(35) NOT NULL DEFAULT '',
+  `title` varchar(256) NOT NULL DEFAULT '',
+  `content` longtext NOT NULL DEFAULT '',
+  `createdAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `post.postID` ON `post` (`postID`);
+CREATE INDEX IF NOT EXISTS `idx.post.userID` ON `post` (`userID`);
+
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `userID` varchar(36) NOT NULL DEFAULT '',
+  `username` varchar(255) NOT NULL DEFAULT '',
+  `password` varchar(255) NOT NULL DEFAULT '',
+  `nickname` varchar(30) NOT NULL DEFAULT '',
+  `email` varchar(256) NOT NULL DEFAULT '',
+  `phone` varchar(16) NOT NULL DEFAULT '',
+  `createdAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `user.userID` ON `user` (`userID`);
+CREATE UNIQUE INDEX IF NOT EXISTS `user.username` ON `user` (`username`);
+CREATE UNIQUE INDEX IF NOT EXISTS `user.phone` ON `user` (`phone`);
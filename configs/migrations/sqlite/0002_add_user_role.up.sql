@@ -0,0 +1,2 @@
+-- Roles used by the authorization policies, either 'admin' or 'user'.
+ALTER TABLE `user` ADD COLUMN `role` varchar(32) NOT NULL DEFAULT 'user';
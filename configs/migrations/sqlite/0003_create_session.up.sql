@@ -0,0 +1,16 @@
+-- Login sessions, every session holds the current refresh token of a user and can be revoked.
+CREATE TABLE IF NOT EXISTS `session` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `sessionID` varchar(36) NOT NULL DEFAULT '',
+  `userID` varchar(36) NOT NULL DEFAULT '',
+  `refreshTokenHash` varchar(64) NOT NULL DEFAULT '',
+  `previousTokenHash` varchar(64) NOT NULL DEFAULT '',
+  `expiresAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `revokedAt` datetime DEFAULT NULL,
+  `createdAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updatedAt` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `session.sessionID` ON `session` (`sessionID`);
+CREATE UNIQUE INDEX IF NOT EXISTS `session.refreshTokenHash` ON `session` (`refreshTokenHash`);
+CREATE INDEX IF NOT EXISTS `idx.session.previousTokenHash` ON `session` (`previousTokenHash`);
+CREATE INDEX IF NOT EXISTS `idx.session.userID` ON `session` (`userID`);
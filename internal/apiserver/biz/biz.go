@@ -4,6 +4,9 @@ import (
 	postv1 "github.com/MortalSC/FastGO/internal/apiserver/biz/v1/post"
 	userv1 "github.com/MortalSC/FastGO/internal/apiserver/biz/v1/user"
 	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/authn"
+	fgauthn "github.com/MortalSC/FastGO/internal/pkg/authn"
+	"github.com/MortalSC/FastGO/pkg/auth"
 )
 
 type IBiz interface {
@@ -16,18 +19,25 @@ type IBiz interface {
 
 type biz struct {
 	store store.IStore
+	authn authn.AuthenTicator
+	guard *fgauthn.LoginGuard
+	// hasher hashes and verifies the passwords
+	hasher *auth.Hasher
 }
 
 var _ IBiz = (*biz)(nil)
 
-func NewBiz(store store.IStore) *biz {
+func NewBiz(store store.IStore, authn authn.AuthenTicator, guard *fgauthn.LoginGuard, hasher *auth.Hasher) *biz {
 	return &biz{
-		store: store,
+		store:  store,
+		authn:  authn,
+		guard:  guard,
+		hasher: hasher,
 	}
 }
 
 func (b *biz) UserV1() userv1.UserBiz {
-	return userv1.New(b.store)
+	return userv1.New(b.store, b.authn, b.guard, b.hasher)
 }
 
 func (b *biz) PostV1() postv1.PostBiz {
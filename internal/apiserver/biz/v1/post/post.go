@@ -6,8 +6,11 @@ import (
 	"github.com/MortalSC/FastGO/internal/apiserver/model"
 	"github.com/MortalSC/FastGO/internal/apiserver/store"
 	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	"github.com/MortalSC/FastGO/internal/pkg/authz"
 	"github.com/MortalSC/FastGO/internal/pkg/contextx"
 	"github.com/MortalSC/FastGO/internal/pkg/conversion"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/tracing"
 	apiv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
 	"github.com/jinzhu/copier"
 )
@@ -37,7 +40,27 @@ func New(store store.IStore) *postBiz {
 	}
 }
 
+// postFields are the fields clients may filter and sort posts on
+var postFields = where.Schema{
+	"post_id":    {Column: "postID", Operators: where.EqualityOps},
+	"title":      {Column: "title", Operators: where.TextOps, Sortable: true},
+	"content":    {Column: "content", Operators: []where.Operator{where.OpLike}},
+	"created_at": {Column: "createdAt", Operators: where.RangeOps, Value: where.Time, Sortable: true},
+	"updated_at": {Column: "updatedAt", Operators: where.RangeOps, Value: where.Time, Sortable: true},
+}
+
+// ownedBy limits the query to the posts of the caller, administrators may access every post
+func ownedBy(ctx context.Context) *where.Options {
+	if authz.IsAdmin(ctx) {
+		return where.NewWhere()
+	}
+	return where.F("userID", contextx.UserID(ctx))
+}
+
 func (b *postBiz) Create(ctx context.Context, req *apiv1.CreatePostRequest) (*apiv1.CreatePostResponse, error) {
+	ctx, span := tracing.Start(ctx, "postBiz.Create")
+	defer span.End()
+
 	var postM model.Post
 	_ = copier.Copy(&postM, req)
 	postM.UserID = contextx.UserID(ctx)
@@ -52,7 +75,10 @@ func (b *postBiz) Create(ctx context.Context, req *apiv1.CreatePostRequest) (*ap
 }
 
 func (b *postBiz) Update(ctx context.Context, req *apiv1.UpdatePostRequest) (*apiv1.UpdatePostResponse, error) {
-	whr := where.F("userID", contextx.UserID(ctx), "postID", req.PostID)
+	ctx, span := tracing.Start(ctx, "postBiz.Update")
+	defer span.End()
+
+	whr := ownedBy(ctx).F("postID", req.PostID)
 	postM, err := b.store.Post().Get(ctx, whr)
 	if err != nil {
 		return nil, err
@@ -74,7 +100,10 @@ func (b *postBiz) Update(ctx context.Context, req *apiv1.UpdatePostRequest) (*ap
 }
 
 func (b *postBiz) Delete(ctx context.Context, req *apiv1.DeletePostRequest) (*apiv1.DeletePostResponse, error) {
-	whr := where.F("userID", contextx.UserID(ctx), "postID", req.PostID)
+	ctx, span := tracing.Start(ctx, "postBiz.Delete")
+	defer span.End()
+
+	whr := ownedBy(ctx).F("postID", req.PostID)
 	if err := b.store.Post().Delete(ctx, whr); err != nil {
 		return nil, err
 	}
@@ -83,7 +112,10 @@ func (b *postBiz) Delete(ctx context.Context, req *apiv1.DeletePostRequest) (*ap
 }
 
 func (b *postBiz) Get(ctx context.Context, req *apiv1.GetPostRequest) (*apiv1.GetPostResponse, error) {
-	whr := where.F("userID", contextx.UserID(ctx), "postID", req.PostID)
+	ctx, span := tracing.Start(ctx, "postBiz.Get")
+	defer span.End()
+
+	whr := ownedBy(ctx).F("postID", req.PostID)
 	postM, err := b.store.Post().Get(ctx, whr)
 	if err != nil {
 		return nil, err
@@ -95,10 +127,24 @@ func (b *postBiz) Get(ctx context.Context, req *apiv1.GetPostRequest) (*apiv1.Ge
 }
 
 func (b *postBiz) List(ctx context.Context, req *apiv1.ListPostRequest) (*apiv1.ListPostResponse, error) {
-	whr := where.F("userID", contextx.UserID(ctx)).P(int(req.Offset), int(req.Limit))
+	ctx, span := tracing.Start(ctx, "postBiz.List")
+	defer span.End()
+
+	cursor, err := where.ParseCursor(req.Cursor)
+	if err != nil {
+		return nil, errorx.ErrInvalidArgument.WithMessage("%v", err)
+	}
+
+	whr := where.F("userID", contextx.UserID(ctx)).O(int(req.Offset)).L(int(req.Limit)).After(cursor)
+	if req.SkipCount {
+		whr = whr.NoCount()
+	}
 	if req.Title != nil {
 		whr = whr.Q("title like ?", "%"+*req.Title+"%")
 	}
+	if err := postFields.Apply(whr, req.Filter, req.Sort); err != nil {
+		return nil, errorx.ErrInvalidArgument.WithMessage("%v", err)
+	}
 
 	count, postList, err := b.store.Post().List(ctx, whr)
 	if err != nil {
@@ -110,8 +156,13 @@ func (b *postBiz) List(ctx context.Context, req *apiv1.ListPostRequest) (*apiv1.
 		posts = append(posts, conversion.PostModelToPostV1(post))
 	}
 
-	return &apiv1.ListPostResponse{
-		Total: count,
-		Posts: posts,
-	}, nil
+	resp := &apiv1.ListPostResponse{Posts: posts}
+	if !req.SkipCount {
+		resp.Total = &count
+	}
+	if len(postList) > 0 {
+		resp.NextCursor = whr.Next(len(postList), postList[len(postList)-1].ID).String()
+	}
+
+	return resp, nil
 }
@@ -2,20 +2,28 @@ package user
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/MortalSC/FastGO/internal/apiserver/model"
 	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/authn"
 	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	fgauthn "github.com/MortalSC/FastGO/internal/pkg/authn"
+	"github.com/MortalSC/FastGO/internal/pkg/authz"
 	"github.com/MortalSC/FastGO/internal/pkg/contextx"
 	"github.com/MortalSC/FastGO/internal/pkg/conversion"
 	"github.com/MortalSC/FastGO/internal/pkg/errorx"
 	"github.com/MortalSC/FastGO/internal/pkg/known"
+	"github.com/MortalSC/FastGO/internal/pkg/metrics"
+	"github.com/MortalSC/FastGO/internal/pkg/tracing"
 	apiv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
 	"github.com/MortalSC/FastGO/pkg/auth"
-	"github.com/MortalSC/FastGO/pkg/token"
 	"github.com/jinzhu/copier"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -33,24 +41,44 @@ type UserBiz interface {
 type UserExpansion interface {
 	Login(ctx context.Context, req *apiv1.LoginRequest) (*apiv1.LoginResponse, error)
 	RefreshToken(ctx context.Context, req *apiv1.RefreshTokenRequest) (*apiv1.RefreshTokenResponse, error)
+	Logout(ctx context.Context, req *apiv1.LogoutRequest) (*apiv1.LogoutResponse, error)
 	ChangePassword(ctx context.Context, req *apiv1.ChangePasswordRequest) (*apiv1.ChangePasswordResponse, error)
+	GetLock(ctx context.Context, req *apiv1.GetUserLockRequest) (*apiv1.GetUserLockResponse, error)
+	Unlock(ctx context.Context, req *apiv1.UnlockUserRequest) (*apiv1.UnlockUserResponse, error)
 }
 
 type userBiz struct {
 	store store.IStore
+	authn authn.AuthenTicator
+	guard *fgauthn.LoginGuard
+	// hasher hashes and verifies the passwords
+	hasher *auth.Hasher
 }
 
 var _ UserBiz = (*userBiz)(nil)
 
-func New(store store.IStore) *userBiz {
+func New(store store.IStore, authn authn.AuthenTicator, guard *fgauthn.LoginGuard, hasher *auth.Hasher) *userBiz {
 	return &userBiz{
-		store: store,
+		store:  store,
+		authn:  authn,
+		guard:  guard,
+		hasher: hasher,
 	}
 }
 
 func (b *userBiz) Create(ctx context.Context, req *apiv1.CreateUserRequest) (*apiv1.CreateUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "userBiz.Create")
+	defer span.End()
+
 	var userM model.User
 	_ = copier.Copy(&userM, req)
+	userM.Role = known.RoleUser
+
+	password, err := b.hasher.Hash(req.Password)
+	if err != nil {
+		return nil, errorx.ErrInternal.WithMessage("hash the password: %v", err)
+	}
+	userM.Password = password
 
 	if err := b.store.User().Create(ctx, &userM); err != nil {
 		return nil, err
@@ -61,8 +89,32 @@ func (b *userBiz) Create(ctx context.Context, req *apiv1.CreateUserRequest) (*ap
 	}, nil
 }
 
+// userFields are the fields clients may filter and sort users on
+var userFields = where.Schema{
+	"user_id":    {Column: "userID", Operators: where.EqualityOps},
+	"username":   {Column: "username", Operators: where.TextOps, Sortable: true},
+	"nickname":   {Column: "nickname", Operators: where.TextOps, Sortable: true},
+	"email":      {Column: "email", Operators: where.TextOps},
+	"phone":      {Column: "phone", Operators: where.TextOps},
+	"role":       {Column: "role", Operators: where.EqualityOps},
+	"created_at": {Column: "createdAt", Operators: where.RangeOps, Value: where.Time, Sortable: true},
+	"updated_at": {Column: "updatedAt", Operators: where.RangeOps, Value: where.Time, Sortable: true},
+}
+
+// targetUser returns the user addressed by idOrName, which is a userID or a username
+// The caller itself is returned when idOrName is empty
+func (b *userBiz) targetUser(ctx context.Context, idOrName string) (*model.User, error) {
+	if idOrName == "" {
+		idOrName = contextx.UserID(ctx)
+	}
+	return b.store.User().GetByIDOrName(ctx, idOrName)
+}
+
 func (b *userBiz) Update(ctx context.Context, req *apiv1.UpdateUserRequest) (*apiv1.UpdateUserResponse, error) {
-	userM, err := b.store.User().Get(ctx, where.F("userID", contextx.UserID(ctx)))
+	ctx, span := tracing.Start(ctx, "userBiz.Update")
+	defer span.End()
+
+	userM, err := b.targetUser(ctx, req.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -79,6 +131,15 @@ func (b *userBiz) Update(ctx context.Context, req *apiv1.UpdateUserRequest) (*ap
 	if req.Phone != nil {
 		userM.Phone = *req.Phone
 	}
+	if req.Role != nil {
+		if !authz.IsAdmin(ctx) {
+			return nil, errorx.ErrPermissionDenied
+		}
+		if *req.Role != known.RoleAdmin && *req.Role != known.RoleUser {
+			return nil, errorx.ErrInvalidArgument.WithMessage("role must be one of: %s, %s", known.RoleAdmin, known.RoleUser)
+		}
+		userM.Role = *req.Role
+	}
 
 	if err := b.store.User().Update(ctx, userM); err != nil {
 		return nil, err
@@ -88,14 +149,30 @@ func (b *userBiz) Update(ctx context.Context, req *apiv1.UpdateUserRequest) (*ap
 }
 
 func (b *userBiz) Delete(ctx context.Context, req *apiv1.DeleteUserRequest) (*apiv1.DeleteUserResponse, error) {
-	if err := b.store.User().Delete(ctx, where.F("userID", contextx.UserID(ctx))); err != nil {
+	ctx, span := tracing.Start(ctx, "userBiz.Delete")
+	defer span.End()
+
+	userM, err := b.targetUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.store.User().Delete(ctx, where.F("userID", userM.UserID)); err != nil {
+		return nil, err
+	}
+
+	// Sign out every session of the user, whose tokens must not outlive the account
+	if err := b.authn.DestroyAll(ctx, userM.UserID); err != nil {
 		return nil, err
 	}
 	return &apiv1.DeleteUserResponse{}, nil
 }
 
 func (b *userBiz) Get(ctx context.Context, req *apiv1.GetUserRequest) (*apiv1.GetUserResponse, error) {
-	userM, err := b.store.User().Get(ctx, where.F("userID", contextx.UserID(ctx)))
+	ctx, span := tracing.Start(ctx, "userBiz.Get")
+	defer span.End()
+
+	userM, err := b.targetUser(ctx, req.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +183,21 @@ func (b *userBiz) Get(ctx context.Context, req *apiv1.GetUserRequest) (*apiv1.Ge
 }
 
 func (b *userBiz) List(ctx context.Context, req *apiv1.ListUserRequest) (*apiv1.ListUserResponse, error) {
-	whr := where.P(int(req.Offset), int(req.Limit))
+	ctx, span := tracing.Start(ctx, "userBiz.List")
+	defer span.End()
+
+	cursor, err := where.ParseCursor(req.Cursor)
+	if err != nil {
+		return nil, errorx.ErrInvalidArgument.WithMessage("%v", err)
+	}
+
+	whr := where.O(int(req.Offset)).L(int(req.Limit)).After(cursor)
+	if req.SkipCount {
+		whr = whr.NoCount()
+	}
+	if err := userFields.Apply(whr, req.Filter, req.Sort); err != nil {
+		return nil, errorx.ErrInvalidArgument.WithMessage("%v", err)
+	}
 	count, userList, err := b.store.User().List(ctx, whr)
 	if err != nil {
 		return nil, err
@@ -119,11 +210,14 @@ func (b *userBiz) List(ctx context.Context, req *apiv1.ListUserRequest) (*apiv1.
 
 	for _, user := range userList {
 		eg.Go(func() error {
+			ctx, span := tracing.Start(ctx, "userBiz.countPosts", attribute.String("user.id", user.UserID))
+			defer span.End()
+
 			select {
 			case <-ctx.Done():
 				return nil
 			default:
-				count, _, err := b.store.Post().List(ctx, where.F("userID", contextx.UserID(ctx)))
+				count, _, err := b.store.Post().List(ctx, where.F("userID", user.UserID))
 				if err != nil {
 					return err
 				}
@@ -150,62 +244,197 @@ func (b *userBiz) List(ctx context.Context, req *apiv1.ListUserRequest) (*apiv1.
 
 	slog.DebugContext(ctx, "Get users from backend storage", "count", len(users))
 
-	return &apiv1.ListUserResponse{
-		Total: count,
-		Users: users,
-	}, nil
+	resp := &apiv1.ListUserResponse{Users: users}
+	if !req.SkipCount {
+		resp.Total = &count
+	}
+	if len(userList) > 0 {
+		resp.NextCursor = whr.Next(len(userList), userList[len(userList)-1].ID).String()
+	}
+
+	return resp, nil
 }
 
+// Login signs in a user with its password
+// Unknown users and wrong passwords get the same errorx.ErrInvalidCredentials, and the
+// accounts and client IPs failing repeatedly are locked for a while.
 func (b *userBiz) Login(ctx context.Context, req *apiv1.LoginRequest) (*apiv1.LoginResponse, error) {
-	whr := where.F("username", req.Username)
-	userM, err := b.store.User().Get(ctx, whr)
-	if err != nil {
-		return nil, errorx.ErrUserNotFound
+	ctx, span := tracing.Start(ctx, "userBiz.Login")
+	defer span.End()
+
+	clientIP := contextx.ClientIP(ctx)
+	if err := b.guard.Attempt(ctx, req.Username, clientIP); err != nil {
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure).Inc()
+		return nil, err
 	}
 
-	if err := auth.Compare(userM.Password, req.Password); err != nil {
-		return nil, errorx.ErrInvalidPassword
+	userM, err := b.store.User().Get(ctx, where.F("username", req.Username))
+	if err != nil && !errors.Is(err, errorx.ErrUserNotFound) {
+		b.guard.Abort(ctx, req.Username, clientIP)
+		return nil, err
+	}
+	if userM != nil && !strings.EqualFold(userM.Username, req.Username) {
+		// The collation of the database also matches the usernames differing by accents or
+		// trailing spaces, which must not get a failure budget of their own
+		userM = nil
+	}
+
+	if userM == nil {
+		// Take as long as the logins of existing users, which do not reveal which usernames exist
+		b.hasher.CompareDummy(req.Password)
+	}
+	if userM == nil || b.hasher.Compare(userM.Password, req.Password) != nil {
+		metrics.LoginAttempts.WithLabelValues(metrics.LoginFailure).Inc()
+		b.guard.Fail(ctx, req.Username, clientIP)
+		return nil, errorx.ErrInvalidCredentials
 	}
+	b.guard.Succeed(ctx, req.Username, clientIP)
+	b.rehash(ctx, userM, req.Password)
 
-	tokenStr, expireAt, err := token.Sign(userM.UserID)
+	tokens, err := b.authn.Sign(ctx, userM.UserID)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to sign token", "err", err)
-		return nil, errorx.ErrSignToken
+		return nil, err
 	}
+	metrics.LoginAttempts.WithLabelValues(metrics.LoginSuccess).Inc()
 
 	return &apiv1.LoginResponse{
-		Token:    tokenStr,
-		ExpireAt: expireAt,
+		Token:           tokens.GetToken(),
+		ExpireAt:        time.Unix(tokens.GetExpireAt(), 0),
+		RefreshToken:    tokens.GetRefreshToken(),
+		RefreshExpireAt: time.Unix(tokens.GetRefreshExpireAt(), 0),
 	}, nil
 }
 
-// RefreshToken refreshes or create the token for the user
+// rehash hashes the verified password of the user again when its hash uses an outdated
+// algorithm or outdated parameters, failures are logged since the login succeeded anyway
+func (b *userBiz) rehash(ctx context.Context, userM *model.User, password string) {
+	if !b.hasher.NeedsRehash(userM.Password) {
+		return
+	}
+
+	hash, err := b.hasher.Hash(password)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to rehash the password", "userID", userM.UserID, "err", err)
+		return
+	}
+	userM.Password = hash
+	if err := b.store.User().Update(ctx, userM); err != nil {
+		slog.ErrorContext(ctx, "Failed to save the rehashed password", "userID", userM.UserID, "err", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Rehashed the password of the user", "userID", userM.UserID)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair, the refresh token is rotated
 func (b *userBiz) RefreshToken(ctx context.Context, req *apiv1.RefreshTokenRequest) (*apiv1.RefreshTokenResponse, error) {
-	tokenStr, expireAt, err := token.Sign(contextx.UserID(ctx))
+	ctx, span := tracing.Start(ctx, "userBiz.RefreshToken")
+	defer span.End()
+
+	tokens, err := b.authn.Refresh(ctx, req.RefreshToken)
 	if err != nil {
-		return nil, errorx.ErrSignToken.WithMessage(err.Error())
+		return nil, err
 	}
+
 	return &apiv1.RefreshTokenResponse{
-		Token:    tokenStr,
-		ExpireAt: expireAt,
+		Token:           tokens.GetToken(),
+		ExpireAt:        time.Unix(tokens.GetExpireAt(), 0),
+		RefreshToken:    tokens.GetRefreshToken(),
+		RefreshExpireAt: time.Unix(tokens.GetRefreshExpireAt(), 0),
 	}, nil
 }
 
+// Logout revokes the session of the access token used by the request
+func (b *userBiz) Logout(ctx context.Context, req *apiv1.LogoutRequest) (*apiv1.LogoutResponse, error) {
+	ctx, span := tracing.Start(ctx, "userBiz.Logout")
+	defer span.End()
+
+	if err := b.authn.Destroy(ctx, contextx.AccessToken(ctx)); err != nil {
+		return nil, err
+	}
+
+	return &apiv1.LogoutResponse{}, nil
+}
+
 func (b *userBiz) ChangePassword(ctx context.Context, req *apiv1.ChangePasswordRequest) (*apiv1.ChangePasswordResponse, error) {
-	userM, err := b.store.User().Get(ctx, where.F("userID", contextx.UserID(ctx)))
+	ctx, span := tracing.Start(ctx, "userBiz.ChangePassword")
+	defer span.End()
+
+	userM, err := b.targetUser(ctx, req.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := auth.Compare(userM.Password, req.OldPassword); err != nil {
-		slog.ErrorContext(ctx, "Failed to compare password", "err", err)
-		return nil, errorx.ErrInvalidPassword
+	// Administrators may reset the password of other users without knowing the old one
+	if userM.UserID == contextx.UserID(ctx) || !authz.IsAdmin(ctx) {
+		if err := b.hasher.Compare(userM.Password, req.OldPassword); err != nil {
+			slog.ErrorContext(ctx, "Failed to compare password", "err", err)
+			return nil, errorx.ErrInvalidPassword
+		}
 	}
 
-	userM.Password, _ = auth.Encrypt(req.NewPassword)
+	password, err := b.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return nil, errorx.ErrInternal.WithMessage("hash the password: %v", err)
+	}
+	userM.Password = password
 	if err := b.store.User().Update(ctx, userM); err != nil {
 		return nil, err
 	}
 
+	// Sign out every session of the user, tokens issued with the old password are no longer trusted
+	if err := b.authn.DestroyAll(ctx, userM.UserID); err != nil {
+		return nil, err
+	}
+
 	return &apiv1.ChangePasswordResponse{}, nil
 }
+
+// GetLock returns the failed logins of a user and whether they locked its account
+func (b *userBiz) GetLock(ctx context.Context, req *apiv1.GetUserLockRequest) (*apiv1.GetUserLockResponse, error) {
+	ctx, span := tracing.Start(ctx, "userBiz.GetLock")
+	defer span.End()
+
+	userM, err := b.targetUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := b.guard.State(ctx, userM.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &apiv1.GetUserLockResponse{
+		Locked:       state.Locked(time.Now()),
+		FailedLogins: int64(state.Failures),
+	}
+	if !state.LastFailure.IsZero() {
+		resp.LastFailedLoginAt = &state.LastFailure
+	}
+	if resp.Locked {
+		resp.LockedUntil = &state.LockedUntil
+	}
+	return resp, nil
+}
+
+// Unlock forgets the failed logins of a user, which unlocks its account
+// The lockouts of the client IPs are kept.
+func (b *userBiz) Unlock(ctx context.Context, req *apiv1.UnlockUserRequest) (*apiv1.UnlockUserResponse, error) {
+	ctx, span := tracing.Start(ctx, "userBiz.Unlock")
+	defer span.End()
+
+	userM, err := b.targetUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.guard.Unlock(ctx, userM.Username); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Unlocked the logins of the user", "userID", userM.UserID)
+
+	return &apiv1.UnlockUserResponse{}, nil
+}
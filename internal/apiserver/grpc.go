@@ -0,0 +1,91 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/biz"
+	"github.com/MortalSC/FastGO/internal/apiserver/rpc"
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/authn"
+	fgauthn "github.com/MortalSC/FastGO/internal/pkg/authn"
+	"github.com/MortalSC/FastGO/internal/pkg/authz"
+	"github.com/MortalSC/FastGO/internal/pkg/interceptor"
+	"github.com/MortalSC/FastGO/internal/pkg/validation"
+	rpcv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/rpc/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// NewGRPCServer creates the gRPC server of the user and post services
+// The interceptors authenticate, rate limit and authorize the calls like the middlewares of
+// the REST API. The server uses TLS when tlsConfig is not nil
+func (cfg *Config) NewGRPCServer(store store.IStore, authenticator authn.AuthenTicator, guard *fgauthn.LoginGuard, passwords passwords, limits rateLimits, tlsConfig *tls.Config) *grpc.Server {
+	// Public methods, like their REST routes, need no access token
+	public := []string{
+		rpcv1.UserService_Login_FullMethodName,
+		rpcv1.UserService_RefreshToken_FullMethodName,
+		rpcv1.UserService_CreateUser_FullMethodName,
+	}
+
+	userOwner := func(ctx context.Context, req any) (string, error) {
+		return userOwnerID(ctx, store, req.(interface{ GetUserId() string }).GetUserId())
+	}
+	postOwner := func(ctx context.Context, req any) (string, error) {
+		return postOwnerID(ctx, store, req.(interface{ GetPostId() string }).GetPostId())
+	}
+	postsOwner := func(ctx context.Context, req any) (string, error) {
+		return postOwnerID(ctx, store, req.(*rpcv1.DeletePostRequest).GetPostId()...)
+	}
+	// Methods without a rule are denied
+	rules := map[string]interceptor.Rule{
+		rpcv1.UserService_Logout_FullMethodName:         {Resource: authz.ResourceSession, Action: authz.ActionDelete},
+		rpcv1.UserService_UpdateUser_FullMethodName:     {Resource: authz.ResourceUser, Action: authz.ActionUpdate, Owner: userOwner},
+		rpcv1.UserService_DeleteUser_FullMethodName:     {Resource: authz.ResourceUser, Action: authz.ActionDelete, Owner: userOwner},
+		rpcv1.UserService_GetUser_FullMethodName:        {Resource: authz.ResourceUser, Action: authz.ActionGet, Owner: userOwner},
+		rpcv1.UserService_ListUsers_FullMethodName:      {Resource: authz.ResourceUser, Action: authz.ActionList},
+		rpcv1.UserService_ChangePassword_FullMethodName: {Resource: authz.ResourceUser, Action: authz.ActionUpdate, Owner: userOwner},
+		rpcv1.PostService_CreatePost_FullMethodName:     {Resource: authz.ResourcePost, Action: authz.ActionCreate},
+		rpcv1.PostService_UpdatePost_FullMethodName:     {Resource: authz.ResourcePost, Action: authz.ActionUpdate, Owner: postOwner},
+		rpcv1.PostService_DeletePost_FullMethodName:     {Resource: authz.ResourcePost, Action: authz.ActionDelete, Owner: postsOwner},
+		rpcv1.PostService_GetPost_FullMethodName:        {Resource: authz.ResourcePost, Action: authz.ActionGet, Owner: postOwner},
+		rpcv1.PostService_ListPosts_FullMethodName:      {Resource: authz.ResourcePost, Action: authz.ActionList},
+	}
+	for _, method := range public {
+		rules[method] = interceptor.Rule{Public: true}
+	}
+
+	// The methods are limited like their REST routes
+	methodLimits := map[string]rateLimit{
+		rpcv1.UserService_Login_FullMethodName:        limits.auth,
+		rpcv1.UserService_RefreshToken_FullMethodName: limits.auth,
+		rpcv1.UserService_CreateUser_FullMethodName:   limits.signUp,
+	}
+	for method := range rules {
+		if _, ok := methodLimits[method]; !ok {
+			methodLimits[method] = limits.api
+		}
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			interceptor.Recovery(),
+			interceptor.RequestID(),
+			interceptor.ClientIP(),
+			interceptor.Tracing(),
+			interceptor.Authn(authenticator, public...),
+			limits.interceptor(methodLimits),
+			interceptor.Authz(authz.NewAuthorizer(), rules),
+		),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	srv := grpc.NewServer(opts...)
+
+	handler := rpc.NewHandler(biz.NewBiz(store, authenticator, guard, passwords.hasher), validation.NewValidation(store, passwords.policy))
+	rpcv1.RegisterUserServiceServer(srv, handler)
+	rpcv1.RegisterPostServiceServer(srv, handler)
+
+	return srv
+}
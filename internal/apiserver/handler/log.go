@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/core"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/log"
+	apiv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) GetLogLevel(c *gin.Context) {
+	core.HandleQueryRequest(c, func(ctx context.Context, _ *apiv1.GetLogLevelRequest) (*apiv1.GetLogLevelResponse, error) {
+		return &apiv1.GetLogLevelResponse{Level: log.Level()}, nil
+	})
+}
+
+// UpdateLogLevel changes the log level of the running server, e.g. to debug an incident
+// The level is not persisted, a restart falls back to the configured level
+func (h *Handler) UpdateLogLevel(c *gin.Context) {
+	core.HandleJSONRequest(c, func(ctx context.Context, req *apiv1.UpdateLogLevelRequest) (*apiv1.UpdateLogLevelResponse, error) {
+		if err := log.SetLevel(req.Level); err != nil {
+			return nil, errorx.ErrInvalidArgument.WithMessage("%v", err)
+		}
+		return &apiv1.UpdateLogLevelResponse{Level: log.Level()}, nil
+	})
+}
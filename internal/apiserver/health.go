@@ -0,0 +1,57 @@
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/health"
+	genericoptions "github.com/MortalSC/FastGO/pkg/options"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// NewHealth creates the probes of the server
+// The server is ready when the database is reachable, its schema is up to date and the disk
+// holding the log file has enough free space. Liveness has no dependency, so that an outage of
+// the database does not get every replica restarted.
+func (cfg *Config) NewHealth(db *gorm.DB, store store.IStore) (*health.Health, error) {
+	opts := cfg.HealthOptions
+	if opts == nil {
+		opts = genericoptions.NewHealthOptions()
+	}
+
+	migrator, err := cfg.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	h := health.New(opts.Timeout)
+	h.AddReadinessChecks(
+		health.NewChecker("database", store.Ping),
+		health.NewChecker("migrations", migrator.EnsureUpToDate),
+	)
+	if file := cfg.LogOptions.File(); file != "" {
+		h.AddReadinessChecks(health.DiskSpace("disk", filepath.Dir(file), opts.MinFreeDiskMB<<20))
+	}
+	return h, nil
+}
+
+// installHealth registers the probes, which respond 503 Service Unavailable when a check fails
+// /healthz is kept for the existing deployments and reports the readiness
+func installHealth(engine *gin.Engine, h *health.Health) {
+	probe := func(check func(ctx context.Context) *health.Result) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			result := check(c.Request.Context())
+			status := http.StatusOK
+			if !result.OK() {
+				status = http.StatusServiceUnavailable
+			}
+			c.JSON(status, result)
+		}
+	}
+	engine.GET("/livez", probe(h.Live))
+	engine.GET("/readyz", probe(h.Ready))
+	engine.GET("/healthz", probe(h.Ready))
+}
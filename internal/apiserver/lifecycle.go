@@ -0,0 +1,158 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/lifecycle"
+	genericoptions "github.com/MortalSC/FastGO/pkg/options"
+	"google.golang.org/grpc"
+)
+
+// shutdownOptions returns the configured ShutdownOptions, or the defaults
+func (cfg *Config) shutdownOptions() *genericoptions.ShutdownOptions {
+	if cfg.ShutdownOptions == nil {
+		return genericoptions.NewShutdownOptions()
+	}
+	return cfg.ShutdownOptions
+}
+
+// newLifecycle orders the components of the server: they are started from the database
+// to the listeners, and stopped from the listeners to the database, so that the in-flight
+// requests complete before the resources they use are released
+func (s *Server) newLifecycle() *lifecycle.Lifecycle {
+	lc := lifecycle.New()
+	drain := s.cfg.shutdownOptions().DrainPeriod
+
+	lc.Append(lifecycle.Hook{
+		Name: "database",
+		OnStop: func(context.Context) error {
+			sqlDB, err := s.db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.Close()
+		},
+	})
+
+	if s.tracerProvider != nil {
+		// Stopped after the listeners, so that the spans of the last requests are exported
+		lc.Append(lifecycle.Hook{Name: "tracing", OnStop: s.tracerProvider.Shutdown})
+	}
+
+	// The authenticator purges the expired sessions in the background
+	lc.Append(lifecycle.Hook{
+		Name:   "authenticator",
+		OnStop: func(context.Context) error { return s.authn.Release() },
+	})
+
+	// The listeners are drained concurrently under one deadline, so that stopping them takes
+	// the drain period once whatever their number
+	var listeners []lifecycle.Hook
+	if s.grpcSrv != nil {
+		listeners = append(listeners, lifecycle.Hook{
+			Name: "grpc server",
+			OnStart: func(context.Context) error {
+				lis, err := net.Listen("tcp", s.cfg.GRPCAddr)
+				if err != nil {
+					return err
+				}
+
+				slog.Info("Start to listening the incoming requests on grpc address", "addr", lis.Addr().String())
+
+				lc.Go("grpc server", func() error { return s.grpcSrv.Serve(lis) })
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return stopGRPCServer(ctx, s.grpcSrv)
+			},
+		})
+	}
+
+	if s.redirectSrv != nil {
+		listeners = append(listeners, httpServerHook("redirect server", lc, s.redirectSrv, func(lis net.Listener) error {
+			slog.Info("Redirecting the plain http requests to https", "addr", lis.Addr().String())
+			return s.redirectSrv.Serve(lis)
+		}))
+	}
+
+	if s.metricsSrv != nil {
+		listeners = append(listeners, httpServerHook("metrics server", lc, s.metricsSrv, func(lis net.Listener) error {
+			slog.Info("Serving the metrics", "addr", lis.Addr().String())
+			return s.metricsSrv.Serve(lis)
+		}))
+	}
+
+	listeners = append(listeners, httpServerHook("http server", lc, s.srv, func(lis net.Listener) error {
+		if s.srv.TLSConfig != nil {
+			slog.Info("Start to listening the incoming requests on https address", "addr", lis.Addr().String())
+			// The certificate is served by the TLS configuration
+			return s.srv.ServeTLS(lis, "", "")
+		}
+		slog.Info("Start to listening the incoming requests on http address", "addr", lis.Addr().String())
+		return s.srv.Serve(lis)
+	}))
+
+	group := lifecycle.Group("listeners", listeners...)
+	lc.Append(lifecycle.Hook{
+		Name:    group.Name,
+		OnStart: group.OnStart,
+		OnStop: func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, drain)
+			defer cancel()
+			return group.OnStop(ctx)
+		},
+	})
+
+	return lc
+}
+
+// httpServerHook listens on the address of srv when started, so that a busy address fails the
+// start, and serves it with serve. Stopping it waits for the in-flight requests until ctx is
+// done, then closes their connections.
+func httpServerHook(name string, lc *lifecycle.Lifecycle, srv *http.Server, serve func(net.Listener) error) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			lis, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+
+			lc.Go(name, func() error {
+				if err := serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					return err
+				}
+				return nil
+			})
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := srv.Shutdown(ctx); err != nil {
+				// Close the connections of the requests which did not complete in time
+				return errors.Join(err, srv.Close())
+			}
+			return nil
+		},
+	}
+}
+
+// stopGRPCServer waits for the pending calls to complete until ctx is done, then cancels them
+func stopGRPCServer(ctx context.Context, srv *grpc.Server) error {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		srv.Stop()
+		return ctx.Err()
+	}
+}
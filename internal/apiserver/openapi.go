@@ -0,0 +1,198 @@
+package apiserver
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/core"
+	"github.com/MortalSC/FastGO/internal/commonpkg/health"
+	"github.com/MortalSC/FastGO/internal/commonpkg/openapi"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/MortalSC/FastGO/pkg/token"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+// docsPage renders the OpenAPI document with Swagger UI, whose assets are loaded from AssetsURL
+// or from the assets embedded by swaggerFiles
+//
+//go:embed docs.html
+var docsPage string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// docsAssets are the embedded swagger-ui-dist files loaded by docsPage
+var docsAssets = []string{"swagger-ui-bundle.js", "swagger-ui.css"}
+
+// The errors shared by many operations
+var (
+	authErrors  = []*errorx.ErrorX{errorx.ErrTokenInvalid, errorx.ErrTokenExpired, errorx.ErrTokenRevoked}
+	inputErrors = []*errorx.ErrorX{errorx.ErrBind, errorx.ErrInvalidArgument}
+	// rateErrors are returned once the rate limit of the route group is exceeded
+	rateErrors = []*errorx.ErrorX{errorx.ErrTooManyRequests}
+)
+
+// errs concatenates lists of errors
+func errs(lists ...[]*errorx.ErrorX) []*errorx.ErrorX {
+	var all []*errorx.ErrorX
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// restOperations documents the routes of InstallRESTAPI
+// Keep it in sync with the routes, TestOpenAPIDocument fails on undocumented routes
+var restOperations = []openapi.Operation{
+	{
+		ID: "Healthz", Method: http.MethodGet, Path: "/healthz", Summary: "Check the readiness of the server, kept for compatibility", Tags: []string{"system"},
+		Response: health.Result{},
+	},
+	{
+		ID: "Livez", Method: http.MethodGet, Path: "/livez", Summary: "Check the liveness of the server, 503 when a check fails", Tags: []string{"system"},
+		Response: health.Result{},
+	},
+	{
+		ID: "Readyz", Method: http.MethodGet, Path: "/readyz", Summary: "Check the readiness of the server and its dependencies, 503 when a check fails", Tags: []string{"system"},
+		Response: health.Result{},
+	},
+	{
+		ID: "GetJWKS", Method: http.MethodGet, Path: "/.well-known/jwks.json", Summary: "Get the public keys verifying the access tokens", Tags: []string{"system"},
+		Response: token.JWKS{},
+	},
+	{
+		Method: http.MethodPost, Path: "/login", Summary: "Log in with a username and a password", Tags: []string{"auth"},
+		Request: v1.LoginRequest{}, Response: v1.LoginResponse{},
+		Errors: errs(inputErrors, []*errorx.ErrorX{errorx.ErrInvalidCredentials, errorx.ErrLoginLocked, errorx.ErrSignToken, errorx.ErrDBRead}, rateErrors),
+	},
+	{
+		Method: http.MethodPost, Path: "/refresh-token", Summary: "Exchange a refresh token for new tokens", Tags: []string{"auth"},
+		Request: v1.RefreshTokenRequest{}, Response: v1.RefreshTokenResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrSignToken, errorx.ErrDBRead, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodPost, Path: "/logout", Summary: "Revoke the session of the access token", Tags: []string{"auth"}, Auth: true,
+		Request: v1.LogoutRequest{}, Response: v1.LogoutResponse{},
+		Errors: errs(authErrors, []*errorx.ErrorX{errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/user", Summary: "Create a user", Tags: []string{"user"},
+		Request: v1.CreateUserRequest{}, Response: v1.CreateUserResponse{},
+		Errors: errs(inputErrors, []*errorx.ErrorX{errorx.ErrUserNameInvalid, errorx.ErrPasswordInvalid, errorx.ErrUserAlreadyExists, errorx.ErrDBRead, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/user/:user_id", Summary: "Update a user", Tags: []string{"user"}, Auth: true,
+		Request: v1.UpdateUserRequest{}, Response: v1.UpdateUserResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrUserNameInvalid, errorx.ErrUserAlreadyExists, errorx.ErrPermissionDenied, errorx.ErrUserNotFound, errorx.ErrDBRead, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/user/:user_id", Summary: "Delete a user", Tags: []string{"user"}, Auth: true,
+		Request: v1.DeleteUserRequest{}, Response: v1.DeleteUserResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrUserNotFound, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/user/:user_id", Summary: "Get a user", Tags: []string{"user"}, Auth: true,
+		Request: v1.GetUserRequest{}, Response: v1.GetUserResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrUserNotFound, errorx.ErrDBRead}, rateErrors),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/user", Summary: "List users", Tags: []string{"user"}, Auth: true,
+		Request: v1.ListUserRequest{}, Response: v1.ListUserResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrDBRead}, rateErrors),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/user/:user_id/change-password", Summary: "Change the password of a user", Tags: []string{"user"}, Auth: true,
+		Request: v1.ChangePasswordRequest{}, Response: v1.ChangePasswordResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPasswordInvalid, errorx.ErrPermissionDenied, errorx.ErrUserNotFound, errorx.ErrInvalidPassword, errorx.ErrDBRead, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/user/:user_id/lock", Summary: "Get the failed logins of a user and whether they locked its account", Tags: []string{"user"}, Auth: true,
+		Request: v1.GetUserLockRequest{}, Response: v1.GetUserLockResponse{},
+		Errors: errs(authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrUserNotFound, errorx.ErrDBRead}, rateErrors),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/user/:user_id/lock", Summary: "Unlock the account of a user", Tags: []string{"user"}, Auth: true,
+		Request: v1.UnlockUserRequest{}, Response: v1.UnlockUserResponse{},
+		Errors: errs(authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrUserNotFound, errorx.ErrDBRead}, rateErrors),
+	},
+	{
+		Method: http.MethodPost, Path: "/api/v1/post", Summary: "Create a post", Tags: []string{"post"}, Auth: true,
+		Request: v1.CreatePostRequest{}, Response: v1.CreatePostResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodPut, Path: "/api/v1/post/:post_id", Summary: "Update a post", Tags: []string{"post"}, Auth: true,
+		Request: v1.UpdatePostRequest{}, Response: v1.UpdatePostResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrPostNotFound, errorx.ErrDBRead, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodDelete, Path: "/api/v1/post/:post_id", Summary: "Delete a post", Tags: []string{"post"}, Auth: true,
+		Request: v1.DeletePostRequest{}, Response: v1.DeletePostResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrPostNotFound, errorx.ErrDBWrite}, rateErrors),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/post/:post_id", Summary: "Get a post", Tags: []string{"post"}, Auth: true,
+		Request: v1.GetPostRequest{}, Response: v1.GetPostResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrPostNotFound, errorx.ErrDBRead}, rateErrors),
+	},
+	{
+		Method: http.MethodGet, Path: "/api/v1/post", Summary: "List the posts of the caller", Tags: []string{"post"}, Auth: true,
+		Request: v1.ListPostRequest{}, Response: v1.ListPostResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied, errorx.ErrDBRead}, rateErrors),
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/log-level", Summary: "Get the log level of the server", Tags: []string{"admin"}, Auth: true,
+		Request: v1.GetLogLevelRequest{}, Response: v1.GetLogLevelResponse{},
+		Errors: errs(authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied}, rateErrors),
+	},
+	{
+		Method: http.MethodPut, Path: "/admin/log-level", Summary: "Change the log level of the running server, until it restarts", Tags: []string{"admin"}, Auth: true,
+		Request: v1.UpdateLogLevelRequest{}, Response: v1.UpdateLogLevelResponse{},
+		Errors: errs(inputErrors, authErrors, []*errorx.ErrorX{errorx.ErrPermissionDenied}, rateErrors),
+	},
+}
+
+// NewOpenAPI builds the OpenAPI document of the REST API
+func NewOpenAPI() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "fg-apiserver",
+		Description: "REST API of FastGO. Errors carry a stable reason, listed per status code in x-error-reasons.",
+		Version:     "v1",
+	}, core.ErrorResponse{})
+
+	for _, op := range restOperations {
+		doc.Add(op)
+	}
+	return doc
+}
+
+// installDocs serves the OpenAPI document, and Swagger UI when enabled
+func (cfg *Config) installDocs(engine *gin.Engine) {
+	doc := NewOpenAPI()
+	engine.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	})
+
+	if cfg.DocsOptions == nil || !cfg.DocsOptions.UI {
+		return
+	}
+
+	assetsURL := cfg.DocsOptions.AssetsURL
+	if assetsURL == "" {
+		assetsURL = "/docs/assets"
+		for _, name := range docsAssets {
+			engine.GET("/docs/assets/"+name, func(c *gin.Context) {
+				c.FileFromFS(name, http.FS(swaggerFiles.FS))
+			})
+		}
+	}
+	engine.GET("/docs", func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		_ = docsTemplate.Execute(c.Writer, map[string]string{
+			"AssetsURL": assetsURL,
+			"SpecURL":   "/openapi.json",
+		})
+	})
+}
@@ -0,0 +1,28 @@
+package apiserver
+
+import (
+	"github.com/MortalSC/FastGO/pkg/auth"
+	genericoptions "github.com/MortalSC/FastGO/pkg/options"
+)
+
+// passwords holds the hashing of the passwords and the policy of the new passwords
+type passwords struct {
+	// hasher hashes the new passwords and verifies the hashes of every supported algorithm
+	hasher *auth.Hasher
+	// policy rejects the weak and the leaked passwords
+	policy auth.Policy
+}
+
+// newPasswords creates the hasher and the policy of PasswordOptions, or of its defaults if not set
+func (cfg *Config) newPasswords() (passwords, error) {
+	opts := cfg.PasswordOptions
+	if opts == nil {
+		opts = genericoptions.NewPasswordOptions()
+	}
+
+	policy, err := opts.NewPolicy()
+	if err != nil {
+		return passwords{}, err
+	}
+	return passwords{hasher: opts.NewHasher(), policy: policy}, nil
+}
@@ -0,0 +1,94 @@
+package apiserver
+
+import (
+	"context"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/ratelimit"
+	"github.com/MortalSC/FastGO/internal/pkg/interceptor"
+	middleware "github.com/MortalSC/FastGO/internal/pkg/middleware"
+	genericoptions "github.com/MortalSC/FastGO/pkg/options"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// rateLimitKeys maps the keys of the configuration to the keys of the buckets
+var rateLimitKeys = map[string]middleware.KeyFunc{
+	genericoptions.RateLimitKeyIP:    middleware.ByClientIP,
+	genericoptions.RateLimitKeyUser:  middleware.ByUser,
+	genericoptions.RateLimitKeyRoute: middleware.ByRoute,
+}
+
+// rateLimitMethodKeys maps the keys of the configuration to the keys of the buckets of the gRPC calls
+var rateLimitMethodKeys = map[string]interceptor.KeyFunc{
+	genericoptions.RateLimitKeyIP:    interceptor.ByClientIP,
+	genericoptions.RateLimitKeyUser:  interceptor.ByUser,
+	genericoptions.RateLimitKeyRoute: interceptor.ByMethod,
+}
+
+// rateLimit is the limit of a group of routes and methods
+type rateLimit struct {
+	name  string
+	limit genericoptions.RouteRateLimit
+}
+
+// rateLimits holds the rate limits of every group of routes and methods
+// The REST API and the gRPC server share the buckets of the store, so that the clients do not
+// get twice the requests by calling both.
+type rateLimits struct {
+	// store keeps the buckets, the limits are disabled if nil
+	store ratelimit.Store
+	// auth limits the logins and the token refreshes
+	auth rateLimit
+	// signUp limits the creation of accounts
+	signUp rateLimit
+	// api limits the authenticated routes, it must run after Authn
+	api rateLimit
+}
+
+// newRateLimits creates the rate limits of RateLimitOptions, whose buckets are kept by
+// RateLimitStore, or in memory if not set
+func (cfg *Config) newRateLimits() rateLimits {
+	opts := cfg.RateLimitOptions
+	if opts == nil || !opts.Enabled {
+		return rateLimits{}
+	}
+
+	store := cfg.RateLimitStore
+	if store == nil {
+		store = ratelimit.NewMemoryStore()
+	}
+	return rateLimits{
+		store:  store,
+		auth:   rateLimit{name: "auth", limit: opts.Auth},
+		signUp: rateLimit{name: "signup", limit: opts.SignUp},
+		api:    rateLimit{name: "api", limit: opts.API},
+	}
+}
+
+// handler returns the middleware enforcing l on the routes
+func (limits rateLimits) handler(l rateLimit) gin.HandlerFunc {
+	if limits.store == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.RateLimit(limits.store, l.name, toLimit(l.limit), rateLimitKeys[l.limit.Key])
+}
+
+// interceptor returns the interceptor enforcing the limits of methods, keyed by full method
+// name, it must run after Authn
+func (limits rateLimits) interceptor(methods map[string]rateLimit) grpc.UnaryServerInterceptor {
+	if limits.store == nil {
+		return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			return handler(ctx, req)
+		}
+	}
+
+	limitsByMethod := make(map[string]interceptor.Limit, len(methods))
+	for method, l := range methods {
+		limitsByMethod[method] = interceptor.Limit{Name: l.name, Limit: toLimit(l.limit), Key: rateLimitMethodKeys[l.limit.Key]}
+	}
+	return interceptor.RateLimit(limits.store, limitsByMethod)
+}
+
+func toLimit(l genericoptions.RouteRateLimit) ratelimit.Limit {
+	return ratelimit.Limit{Requests: l.Requests, Period: l.Period, Burst: l.Burst}
+}
@@ -0,0 +1,61 @@
+// Package rpc implements the gRPC services of pkg/api/apiserver/rpc/v1 on top of the
+// business layer, sharing the request types and the validation of the REST handlers.
+package rpc
+
+import (
+	"context"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/biz"
+	"github.com/MortalSC/FastGO/internal/commonpkg/core"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/validation"
+	rpcv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/rpc/v1"
+)
+
+// Handler implements the user and post services
+type Handler struct {
+	rpcv1.UnimplementedUserServiceServer
+	rpcv1.UnimplementedPostServiceServer
+
+	biz biz.IBiz
+	val *validation.Validator
+}
+
+// NewHandler creates a new Handler instance
+func NewHandler(biz biz.IBiz, val *validation.Validator) *Handler {
+	return &Handler{
+		biz: biz,
+		val: val,
+	}
+}
+
+// handle is the gRPC counterpart of core.HandleRequest: it converts the protobuf request in
+// to the request type T of the business layer, validates it, calls handler and converts its
+// response to the protobuf response O
+func handle[O any, T any, R any](ctx context.Context, in any, handler core.Handler[T, R], validators ...core.Validator[T]) (*O, error) {
+	var req T
+	if err := core.CopyWithConverters(&req, in); err != nil {
+		return nil, errorx.ErrBind.WithMessage("%v", err)
+	}
+
+	if defaulter, ok := any(&req).(interface{ Default() }); ok {
+		defaulter.Default()
+	}
+
+	for _, validate := range validators {
+		if err := validate(ctx, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := handler(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	var out O
+	if err := core.CopyWithConverters(&out, resp); err != nil {
+		return nil, errorx.ErrInternal.WithMessage("%v", err)
+	}
+	return &out, nil
+}
@@ -0,0 +1,27 @@
+package rpc
+
+import (
+	"context"
+
+	rpcv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/rpc/v1"
+)
+
+func (h *Handler) CreatePost(ctx context.Context, req *rpcv1.CreatePostRequest) (*rpcv1.CreatePostResponse, error) {
+	return handle[rpcv1.CreatePostResponse](ctx, req, h.biz.PostV1().Create, h.val.ValidateCreatePostRequest)
+}
+
+func (h *Handler) UpdatePost(ctx context.Context, req *rpcv1.UpdatePostRequest) (*rpcv1.UpdatePostResponse, error) {
+	return handle[rpcv1.UpdatePostResponse](ctx, req, h.biz.PostV1().Update, h.val.ValidateUpdatePostRequest)
+}
+
+func (h *Handler) DeletePost(ctx context.Context, req *rpcv1.DeletePostRequest) (*rpcv1.DeletePostResponse, error) {
+	return handle[rpcv1.DeletePostResponse](ctx, req, h.biz.PostV1().Delete, h.val.ValidateDeletePostRequest)
+}
+
+func (h *Handler) GetPost(ctx context.Context, req *rpcv1.GetPostRequest) (*rpcv1.GetPostResponse, error) {
+	return handle[rpcv1.GetPostResponse](ctx, req, h.biz.PostV1().Get, h.val.ValidateGetPostRequest)
+}
+
+func (h *Handler) ListPosts(ctx context.Context, req *rpcv1.ListPostRequest) (*rpcv1.ListPostResponse, error) {
+	return handle[rpcv1.ListPostResponse](ctx, req, h.biz.PostV1().List, h.val.ValidateListPostRequest)
+}
@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+
+	rpcv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/rpc/v1"
+)
+
+func (h *Handler) Login(ctx context.Context, req *rpcv1.LoginRequest) (*rpcv1.LoginResponse, error) {
+	return handle[rpcv1.LoginResponse](ctx, req, h.biz.UserV1().Login, h.val.ValidateLoginRequest)
+}
+
+func (h *Handler) RefreshToken(ctx context.Context, req *rpcv1.RefreshTokenRequest) (*rpcv1.RefreshTokenResponse, error) {
+	return handle[rpcv1.RefreshTokenResponse](ctx, req, h.biz.UserV1().RefreshToken, h.val.ValidateRefreshTokenRequest)
+}
+
+func (h *Handler) Logout(ctx context.Context, req *rpcv1.LogoutRequest) (*rpcv1.LogoutResponse, error) {
+	return handle[rpcv1.LogoutResponse](ctx, req, h.biz.UserV1().Logout, h.val.ValidateLogoutRequest)
+}
+
+func (h *Handler) ChangePassword(ctx context.Context, req *rpcv1.ChangePasswordRequest) (*rpcv1.ChangePasswordResponse, error) {
+	return handle[rpcv1.ChangePasswordResponse](ctx, req, h.biz.UserV1().ChangePassword, h.val.ValidateChangePasswordRequest)
+}
+
+func (h *Handler) CreateUser(ctx context.Context, req *rpcv1.CreateUserRequest) (*rpcv1.CreateUserResponse, error) {
+	return handle[rpcv1.CreateUserResponse](ctx, req, h.biz.UserV1().Create, h.val.ValidateCreateUserRequest)
+}
+
+func (h *Handler) UpdateUser(ctx context.Context, req *rpcv1.UpdateUserRequest) (*rpcv1.UpdateUserResponse, error) {
+	return handle[rpcv1.UpdateUserResponse](ctx, req, h.biz.UserV1().Update, h.val.ValidateUpdateUserRequest)
+}
+
+func (h *Handler) DeleteUser(ctx context.Context, req *rpcv1.DeleteUserRequest) (*rpcv1.DeleteUserResponse, error) {
+	return handle[rpcv1.DeleteUserResponse](ctx, req, h.biz.UserV1().Delete, h.val.ValidateDeleteUserRequest)
+}
+
+func (h *Handler) GetUser(ctx context.Context, req *rpcv1.GetUserRequest) (*rpcv1.GetUserResponse, error) {
+	return handle[rpcv1.GetUserResponse](ctx, req, h.biz.UserV1().Get, h.val.ValidateGetUserRequest)
+}
+
+func (h *Handler) ListUsers(ctx context.Context, req *rpcv1.ListUserRequest) (*rpcv1.ListUserResponse, error) {
+	return handle[rpcv1.ListUserResponse](ctx, req, h.biz.UserV1().List, h.val.ValidateListUserRequest)
+}
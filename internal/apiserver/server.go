@@ -5,134 +5,466 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
+	"github.com/MortalSC/FastGO/configs"
 	"github.com/MortalSC/FastGO/internal/apiserver/biz"
 	"github.com/MortalSC/FastGO/internal/apiserver/handler"
 	"github.com/MortalSC/FastGO/internal/apiserver/store"
-	"github.com/MortalSC/FastGO/internal/pkg/core"
+	"github.com/MortalSC/FastGO/internal/commonpkg/authn"
+	"github.com/MortalSC/FastGO/internal/commonpkg/core"
+	"github.com/MortalSC/FastGO/internal/commonpkg/health"
+	"github.com/MortalSC/FastGO/internal/commonpkg/lifecycle"
+	"github.com/MortalSC/FastGO/internal/commonpkg/lockout"
+	"github.com/MortalSC/FastGO/internal/commonpkg/migrate"
+	"github.com/MortalSC/FastGO/internal/commonpkg/ratelimit"
+	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	fgauthn "github.com/MortalSC/FastGO/internal/pkg/authn"
+	"github.com/MortalSC/FastGO/internal/pkg/authz"
 	"github.com/MortalSC/FastGO/internal/pkg/errorx"
-	"github.com/MortalSC/FastGO/internal/pkg/known"
+	"github.com/MortalSC/FastGO/internal/pkg/log"
+	"github.com/MortalSC/FastGO/internal/pkg/metrics"
 	middleware "github.com/MortalSC/FastGO/internal/pkg/middleware"
+	"github.com/MortalSC/FastGO/internal/pkg/tracing"
 	"github.com/MortalSC/FastGO/internal/pkg/validation"
 	genericoptions "github.com/MortalSC/FastGO/pkg/options"
 	"github.com/MortalSC/FastGO/pkg/token"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
 )
 
 type Config struct {
-	MySQLOptions *genericoptions.MySQLOptions
-	Addr         string
-	JWTKey       string
-	ExpiraTime   time.Duration
+	StorageOptions   *genericoptions.StorageOptions
+	MySQLOptions     *genericoptions.MySQLOptions
+	MigrationOptions *genericoptions.MigrationOptions
+	Addr             string
+	// GRPCAddr is the address of the gRPC server, which is disabled if empty
+	GRPCAddr string
+	// TrustedProxies are the IPs and CIDRs of the proxies whose X-Forwarded-For header gives the
+	// client IP, no proxy is trusted if empty
+	TrustedProxies []string
+	// TLSOptions serves HTTPS and gRPC over TLS when a certificate is configured
+	TLSOptions *genericoptions.TLSOptions
+	// DocsOptions serves the API documentation page
+	DocsOptions *genericoptions.DocsOptions
+	// TracingOptions exports the spans of the requests
+	TracingOptions *genericoptions.TracingOptions
+	// HealthOptions configures the checks of the liveness and readiness probes
+	HealthOptions *genericoptions.HealthOptions
+	// MetricsOptions serves the Prometheus metrics on a listener apart from the API, they are not served if nil
+	MetricsOptions *genericoptions.MetricsOptions
+	// RateLimitOptions limits the rate of the requests of every route group, they are unlimited if nil
+	RateLimitOptions *genericoptions.RateLimitOptions
+	// RateLimitStore keeps the buckets of the rate limits, in memory if nil
+	// A store shared by the replicas enforces the limits across all of them.
+	RateLimitStore ratelimit.Store
+	// LockoutOptions locks the accounts and the client IPs whose logins fail repeatedly, nothing is locked if nil
+	LockoutOptions *genericoptions.LockoutOptions
+	// LockoutStore keeps the failed logins, in memory if nil
+	// A store shared by the replicas enforces the lockouts across all of them.
+	LockoutStore lockout.Store
+	// PasswordOptions configures the hashing of the passwords and the passwords users may choose,
+	// argon2id and the default policy if nil
+	PasswordOptions *genericoptions.PasswordOptions
+	// ShutdownOptions configures the pre-stop delay, the drain period and the timeout of the shutdown
+	ShutdownOptions *genericoptions.ShutdownOptions
+	// LogOptions is the configuration of the default logger, the disk holding its file is checked
+	LogOptions *log.Options
+	JWTKey     string
+	JWTOptions *genericoptions.JWTOptions
+	ExpiraTime time.Duration
+	// RefreshExpiraTime is the lifetime of refresh tokens
+	RefreshExpiraTime time.Duration
 }
 
 type Server struct {
-	cfg *Config
-	srv *http.Server
+	cfg     *Config
+	srv     *http.Server
+	grpcSrv *grpc.Server
+	// redirectSrv redirects plain HTTP requests to HTTPS, it is nil unless configured
+	redirectSrv *http.Server
+	// metricsSrv serves the Prometheus metrics, it is nil unless configured
+	metricsSrv *http.Server
+	authn      authn.AuthenTicator
+	// tracerProvider flushes the pending spans on shutdown, it is nil unless tracing is enabled
+	tracerProvider *sdktrace.TracerProvider
+	// health fails the readiness once the server starts shutting down
+	health *health.Health
+	// db is closed once the listeners are stopped
+	db *gorm.DB
+	// lifecycle starts and stops the listeners and the resources they use
+	lifecycle *lifecycle.Lifecycle
 }
 
 func (cfg *Config) NewServer() (*Server, error) {
-	token.Init(cfg.JWTKey, known.XUserID, cfg.ExpiraTime)
+	if err := cfg.initToken(); err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := cfg.NewTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider, err := cfg.initTracing()
+	if err != nil {
+		return nil, err
+	}
 
 	// Create gin engine
 	engine := gin.New()
+	// The client IP keys the rate limits, the lockouts and the access logs, the forwarding
+	// headers are only trusted from the configured proxies
+	if err := engine.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
 
 	middlewares := []gin.HandlerFunc{
 		gin.Recovery(),
 		middleware.NoCache,
 		middleware.Cors,
 		middleware.RequestID(),
+		middleware.ClientIP(),
+		middleware.Tracing(),
+		middleware.AccessLog(),
+		middleware.Metrics(),
 	}
 	engine.Use(middlewares...)
 
 	// Initialize database connection
-	db, err := cfg.MySQLOptions.NewDB()
+	db, err := cfg.NewDB()
 	if err != nil {
 		return nil, err
 	}
+	if err := cfg.prepareSchema(db); err != nil {
+		return nil, err
+	}
+	if err := cfg.instrumentDB(db); err != nil {
+		return nil, err
+	}
 	store := store.NewStore(db)
+	authenticator := fgauthn.New(store, cfg.ExpiraTime, cfg.RefreshExpiraTime)
+	guard := cfg.newLoginGuard()
+	passwords, err := cfg.newPasswords()
+	if err != nil {
+		return nil, err
+	}
+
+	probes, err := cfg.NewHealth(db, store)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rate limits protect the logins from brute force and the sign up from mass account creation
+	limits := cfg.newRateLimits()
 
-	cfg.InstallRESTAPI(engine, store)
+	cfg.InstallRESTAPI(engine, store, authenticator, guard, passwords, limits, probes)
 
 	// create HTTP server instance
 	httpSrv := &http.Server{
-		Addr:    cfg.Addr,
-		Handler: engine,
+		Addr:      cfg.Addr,
+		Handler:   engine,
+		TLSConfig: tlsConfig,
+	}
+
+	var grpcSrv *grpc.Server
+	if cfg.GRPCAddr != "" {
+		grpcSrv = cfg.NewGRPCServer(store, authenticator, guard, passwords, limits, tlsConfig)
+	}
+
+	var redirectSrv *http.Server
+	if tlsConfig != nil && cfg.TLSOptions.RedirectAddr != "" {
+		redirectSrv = cfg.newRedirectServer()
+	}
+
+	var metricsSrv *http.Server
+	if cfg.MetricsOptions != nil && cfg.MetricsOptions.Addr != "" {
+		metricsSrv = cfg.NewMetricsServer()
+	}
+
+	s := &Server{
+		cfg:            cfg,
+		srv:            httpSrv,
+		grpcSrv:        grpcSrv,
+		redirectSrv:    redirectSrv,
+		metricsSrv:     metricsSrv,
+		authn:          authenticator,
+		tracerProvider: tracerProvider,
+		health:         probes,
+		db:             db,
+	}
+	s.lifecycle = s.newLifecycle()
+
+	return s, nil
+}
+
+// newLoginGuard creates the lockout of the failed logins configured by LockoutOptions, whose
+// failures are kept by LockoutStore, or in memory if not set
+func (cfg *Config) newLoginGuard() *fgauthn.LoginGuard {
+	store := cfg.LockoutStore
+	if store == nil {
+		store = lockout.NewMemoryStore()
+	}
+
+	opts := cfg.LockoutOptions
+	if opts == nil || !opts.Enabled {
+		// Policies without MaxFailures never lock
+		return fgauthn.NewLoginGuard(store, lockout.Policy{}, lockout.Policy{})
+	}
+
+	policy := lockout.Policy{Duration: opts.Duration, MaxDuration: opts.MaxDuration, Window: opts.Window}
+	account, client := policy, policy
+	account.MaxFailures = opts.MaxFailures
+	client.MaxFailures = opts.IPMaxFailures
+	return fgauthn.NewLoginGuard(store, account, client)
+}
+
+// initToken configures the keys signing the tokens and the validation of their claims
+// The signing keys of JWTOptions take precedence over the shared JWTKey
+func (cfg *Config) initToken() error {
+	opts := cfg.JWTOptions.TokenOptions()
+	if !cfg.JWTOptions.Enabled() {
+		token.Init(cfg.JWTKey, cfg.ExpiraTime, opts...)
+		return nil
 	}
 
-	return &Server{
-		cfg: cfg,
-		srv: httpSrv,
-	}, nil
+	keys, err := cfg.JWTOptions.LoadKeys()
+	if err != nil {
+		return err
+	}
+	return token.InitWithKeys(cfg.JWTOptions.ActiveKeyID, keys, cfg.ExpiraTime, opts...)
 }
 
-func (s *Server) Run() error {
+// initTracing installs the W3C trace context propagation and, when tracing is enabled, the
+// tracer provider exporting the spans
+func (cfg *Config) initTracing() (*sdktrace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	slog.Info("Start to listening the incoming requests on http address", "addr", s.cfg.Addr)
+	if !cfg.TracingOptions.Enabled() {
+		return nil, nil
+	}
 
-	go func() {
-		if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			slog.Error(err.Error())
-			os.Exit(1)
+	tp, err := cfg.TracingOptions.NewTracerProvider(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+
+	slog.Info("Exporting the request traces", "exporter", cfg.TracingOptions.Exporter)
+
+	return tp, nil
+}
+
+// NewDB creates the database instance of the configured storage driver
+func (cfg *Config) NewDB() (*gorm.DB, error) {
+	if cfg.StorageOptions == nil || cfg.StorageOptions.Driver == genericoptions.DriverMySQL {
+		return cfg.MySQLOptions.NewDB()
+	}
+
+	slog.Info("Using sqlite backed storage", "driver", cfg.StorageOptions.Driver)
+
+	return cfg.StorageOptions.NewDB()
+}
+
+// instrumentDB records the statements of db as metrics and spans, and the statistics of its connection pool
+func (cfg *Config) instrumentDB(db *gorm.DB) error {
+	for _, plugin := range []gorm.Plugin{metrics.NewGormPlugin(), tracing.NewGormPlugin()} {
+		if err := db.Use(plugin); err != nil && !errors.Is(err, gorm.ErrRegistered) {
+			return err
 		}
-	}()
+	}
 
-	// create a channel of type os.Signal for receiving system signals
-	quit := make(chan os.Signal, 1)
-	// When the kill command is executed (without parameters), the syscall.SIGTERM signal is sent by default
-	// Using the kill-2 command will send the syscall.SIGINT signal (for example, triggered by pressing CTRL+C)
-	// Using the kill-9 command will send the syscall.SIGKILL signal, but the SIGKILL signal cannot be captured, so there is no need to listen for and handle it
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	// Block the program and wait to receive the signal from the quit channel
-	<-quit
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
 
-	slog.Info("Shutting down server...")
+	dbName := genericoptions.DriverMySQL
+	if cfg.StorageOptions != nil && cfg.StorageOptions.Driver != genericoptions.DriverMySQL {
+		dbName = cfg.StorageOptions.Driver
+	} else if cfg.MySQLOptions != nil && cfg.MySQLOptions.Database != "" {
+		dbName = cfg.MySQLOptions.Database
+	}
+	metrics.RegisterDBStats(sqlDB, dbName)
 
-	// Gracefully close the service
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	return nil
+}
+
+// NewMetricsServer creates the server of the Prometheus metrics at /metrics, which listens
+// apart from the API so that the metrics are reachable by the scrapers only
+func (cfg *Config) NewMetricsServer() *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	return &http.Server{
+		Addr:    cfg.MetricsOptions.Addr,
+		Handler: mux,
+	}
+}
 
-	// First, close the dependent services, and then close the dependent services
-	// Gracefully close the service within 10 seconds (process the unprocessed requests and then close the service). If it exceeds 10 seconds, it will time out and exit
-	if err := s.srv.Shutdown(ctx); err != nil {
-		slog.Error("Insecure Server forced to shutdown:", "err", err)
+// NewMigrator creates a migrator with the embedded schema migrations matching the storage driver
+func (cfg *Config) NewMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	dialect := genericoptions.DriverMySQL
+	if cfg.StorageOptions != nil {
+		dialect = cfg.StorageOptions.Dialect()
+	}
+
+	fsys, err := configs.Migrations(dialect)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New(db, fsys)
+}
+
+// prepareSchema applies or checks the schema migrations before the server starts
+// The sqlite and memory drivers are always migrated, MySQL follows MigrationOptions
+func (cfg *Config) prepareSchema(db *gorm.DB) error {
+	migrator, err := cfg.NewMigrator(db)
+	if err != nil {
 		return err
 	}
 
-	slog.Info("Server exited")
+	ctx := context.Background()
+	autoMigrate := cfg.StorageOptions != nil && cfg.StorageOptions.Driver != genericoptions.DriverMySQL
+	if cfg.MigrationOptions != nil && cfg.MigrationOptions.AutoMigrate {
+		autoMigrate = true
+	}
+
+	if autoMigrate {
+		applied, err := migrator.Up(ctx, 0)
+		if err != nil {
+			return err
+		}
+		for _, mig := range applied {
+			slog.Info("Applied schema migration", "version", mig.Version, "name", mig.Name)
+		}
+		return nil
+	}
 
+	if cfg.MigrationOptions != nil && cfg.MigrationOptions.RequireUpToDate {
+		return migrator.EnsureUpToDate(ctx)
+	}
 	return nil
 }
 
-func (cfg *Config) InstallRESTAPI(engine *gin.Engine, store store.IStore) {
+// Run starts the server and blocks until ctx is done or a listener fails, then shuts it down
+// gracefully: the readiness fails, the server keeps serving for the pre-stop delay so that the
+// load balancers deregister it, and its components are stopped within the shutdown timeout.
+// The error of the failed listener, or of the shutdown, is returned.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.lifecycle.Start(context.Background()); err != nil {
+		return err
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down server...")
+	case runErr = <-s.lifecycle.Failed():
+		slog.Error("Server failed, shutting down", "err", runErr)
+	}
+
+	// Fail the readiness first, so that the load balancers stop routing new requests
+	s.health.ShutDown()
+
+	opts := s.cfg.shutdownOptions()
+	if runErr == nil && opts.PreStopDelay > 0 {
+		slog.Info("Waiting for the load balancers to deregister the server", "delay", opts.PreStopDelay)
+		time.Sleep(opts.PreStopDelay)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	if err := s.lifecycle.Stop(stopCtx); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+
+	slog.Info("Server exited")
+
+	return runErr
+}
+
+// userOwnerID returns the user ID of the user addressed by userID or username
+func userOwnerID(ctx context.Context, store store.IStore, id string) (string, error) {
+	user, err := store.User().GetByIDOrName(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return user.UserID, nil
+}
+
+// postOwnerID returns the user ID owning the posts, or an empty string if they have several owners
+func postOwnerID(ctx context.Context, store store.IStore, postIDs ...string) (string, error) {
+	var ownerID string
+	for i, postID := range postIDs {
+		post, err := store.Post().Get(ctx, where.F("postID", postID))
+		if err != nil {
+			return "", err
+		}
+		if i > 0 && post.UserID != ownerID {
+			return "", nil
+		}
+		ownerID = post.UserID
+	}
+	return ownerID, nil
+}
+
+func (cfg *Config) InstallRESTAPI(engine *gin.Engine, store store.IStore, authenticator authn.AuthenTicator, guard *fgauthn.LoginGuard, passwords passwords, limits rateLimits, probes *health.Health) {
 
 	// ====== test api start ======
 
 	// register 404 handler
 	engine.NoRoute(func(c *gin.Context) {
-		core.WriteResponse(c, errorx.ErrNotFound.WithMessage("Page not found"), nil)
+		core.WriteResponse(c, nil, errorx.ErrNotFound.WithMessage("Page not found"))
 	})
 
-	// register /healthz handler
-	engine.GET("/healthz", func(c *gin.Context) {
-		core.WriteResponse(c, map[string]string{
-			"status": "ok",
-		}, nil)
+	// ====== test api end ======
+
+	// register the liveness and readiness probes
+	installHealth(engine, probes)
+
+	// register the OpenAPI document and its documentation page
+	cfg.installDocs(engine)
+
+	// register the public keys verifying the issued tokens
+	engine.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		core.WriteResponse(c, token.JWKSet(), nil)
 	})
 
-	// ====== test api end ======
+	handler := handler.NewHandler(biz.NewBiz(store, authenticator, guard, passwords.hasher), validation.NewValidation(store, passwords.policy))
 
-	handler := handler.NewHandler(biz.NewBiz(store), validation.NewValidation(store))
+	authLimit, signUpLimit, apiLimit := limits.handler(limits.auth), limits.handler(limits.signUp), limits.handler(limits.api)
 
-	engine.POST("/login", handler.Login)
-	engine.POST("/refresh-token", middleware.Authn(), handler.RefreshToken)
+	engine.POST("/login", authLimit, handler.Login)
+	engine.POST("/refresh-token", authLimit, handler.RefreshToken)
+	engine.POST("/logout", middleware.Authn(authenticator), apiLimit, handler.Logout)
 
 	authMiddleware := []gin.HandlerFunc{
-		middleware.Authn(),
+		middleware.Authn(authenticator),
+		apiLimit,
+	}
+
+	// Authorization policies decide who may read or modify which user and post
+	az := authz.NewAuthorizer()
+	userOwner := func(c *gin.Context) (string, error) {
+		// The path addresses a user by userID or by username
+		return userOwnerID(c.Request.Context(), store, c.Param("user_id"))
+	}
+	postOwner := func(c *gin.Context) (string, error) {
+		return postOwnerID(c.Request.Context(), store, c.Param("post_id"))
+	}
+	userAuthz := func(action authz.Action, owner middleware.OwnerFunc) gin.HandlerFunc {
+		return middleware.Authz(az, authz.ResourceUser, action, owner)
+	}
+	postAuthz := func(action authz.Action, owner middleware.OwnerFunc) gin.HandlerFunc {
+		return middleware.Authz(az, authz.ResourcePost, action, owner)
+	}
+	lockAuthz := func(action authz.Action) gin.HandlerFunc {
+		return middleware.Authz(az, authz.ResourceUserLock, action, nil)
 	}
 
 	// Register the V1 API routes
@@ -140,22 +472,31 @@ func (cfg *Config) InstallRESTAPI(engine *gin.Engine, store store.IStore) {
 	{
 		userv1 := v1.Group("/user")
 		{
-			userv1.POST("", handler.CreateUser)
+			userv1.POST("", signUpLimit, handler.CreateUser)
 			userv1.Use(authMiddleware...)
-			userv1.PUT(":user_id", handler.UpdateUser)
-			userv1.DELETE(":user_id", handler.DeleteUser)
-			userv1.GET(":user_id", handler.GetUser)
-			userv1.GET("", handler.ListUsers)
-			userv1.PUT(":user_id/change-password", handler.ChangePassword)
+			userv1.PUT(":user_id", userAuthz(authz.ActionUpdate, userOwner), handler.UpdateUser)
+			userv1.DELETE(":user_id", userAuthz(authz.ActionDelete, userOwner), handler.DeleteUser)
+			userv1.GET(":user_id", userAuthz(authz.ActionGet, userOwner), handler.GetUser)
+			userv1.GET("", userAuthz(authz.ActionList, nil), handler.ListUsers)
+			userv1.PUT(":user_id/change-password", userAuthz(authz.ActionUpdate, userOwner), handler.ChangePassword)
+			userv1.GET(":user_id/lock", lockAuthz(authz.ActionGet), handler.GetUserLock)
+			userv1.DELETE(":user_id/lock", lockAuthz(authz.ActionDelete), handler.UnlockUser)
 		}
 
 		postv1 := v1.Group("/post", authMiddleware...)
 		{
-			postv1.POST("", handler.CreatePost)
-			postv1.PUT(":post_id", handler.UpdatePost)
-			postv1.DELETE(":post_id", handler.DeletePost)
-			postv1.GET(":post_id", handler.GetPost)
-			postv1.GET("", handler.ListPosts)
+			postv1.POST("", postAuthz(authz.ActionCreate, nil), handler.CreatePost)
+			postv1.PUT(":post_id", postAuthz(authz.ActionUpdate, postOwner), handler.UpdatePost)
+			postv1.DELETE(":post_id", postAuthz(authz.ActionDelete, postOwner), handler.DeletePost)
+			postv1.GET(":post_id", postAuthz(authz.ActionGet, postOwner), handler.GetPost)
+			postv1.GET("", postAuthz(authz.ActionList, nil), handler.ListPosts)
 		}
 	}
+
+	// Register the administration routes
+	admin := engine.Group("/admin", authMiddleware...)
+	{
+		admin.GET("/log-level", middleware.Authz(az, authz.ResourceLogLevel, authz.ActionGet, nil), handler.GetLogLevel)
+		admin.PUT("/log-level", middleware.Authz(az, authz.ResourceLogLevel, authz.ActionUpdate, nil), handler.UpdateLogLevel)
+	}
 }
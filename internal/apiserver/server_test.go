@@ -1,5 +1,70 @@
 package apiserver
 
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	fgctlapp "github.com/MortalSC/FastGO/cmd/fgctl/app"
+	"github.com/MortalSC/FastGO/internal/apiserver/model"
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/core"
+	"github.com/MortalSC/FastGO/internal/commonpkg/health"
+	"github.com/MortalSC/FastGO/internal/commonpkg/openapi"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/known"
+	"github.com/MortalSC/FastGO/internal/pkg/log"
+	"github.com/MortalSC/FastGO/internal/pkg/tracing"
+	rpcv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/rpc/v1"
+	apiv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/MortalSC/FastGO/pkg/client"
+	genericoptions "github.com/MortalSC/FastGO/pkg/options"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
 // ============================================================================
 /*
 
@@ -20,3 +85,1457 @@ curl -XPOST -H'Content-Type: application/json' -H"Authorization: Bearer ${token}
 
 */
 // ============================================================================
+
+// newTestServer creates a server backed by the in-memory storage driver
+func newTestServer(t *testing.T, opts ...func(*Config)) http.Handler {
+	t.Helper()
+	return newServer(t, opts...).srv.Handler
+}
+
+// newServer creates a server backed by the memory driver, opts adjust its configuration
+func newServer(t *testing.T, opts ...func(*Config)) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	cfg := &Config{
+		StorageOptions: &genericoptions.StorageOptions{Driver: genericoptions.DriverMemory},
+		Addr:           "127.0.0.1:0",
+		JWTKey:         "fastgo-test-key",
+		ExpiraTime:     time.Hour,
+
+		RefreshExpiraTime: 24 * time.Hour,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	srv, err := cfg.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = srv.authn.Release() })
+
+	return srv
+}
+
+// doRequest sends a JSON request to the handler and decodes the JSON response into out
+func doRequest(t *testing.T, h http.Handler, method, path, token, body string, out any) int {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if out != nil {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), out), w.Body.String())
+	}
+	return w.Code
+}
+
+// createUser creates a user through the API and returns its user ID and a login token
+func createUser(t *testing.T, h http.Handler, username, phone string) (string, string) {
+	t.Helper()
+
+	var created struct {
+		UserID string `json:"user_id"`
+	}
+	body := fmt.Sprintf(`{"username":%q,"password":"fastgo1234","email":"%s@example.com","phone":%q}`, username, username, phone)
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/api/v1/user", "", body, &created))
+
+	return created.UserID, login(t, h, username)
+}
+
+// login logs in a user created by createUser and returns the token
+func login(t *testing.T, h http.Handler, username string) string {
+	t.Helper()
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	body := fmt.Sprintf(`{"username":%q,"password":"fastgo1234"}`, username)
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/login", "", body, &resp))
+	require.NotEmpty(t, resp.Token)
+
+	return resp.Token
+}
+
+func TestServerWithMemoryStorage(t *testing.T) {
+	h := newTestServer(t)
+
+	var created struct {
+		UserID string `json:"user_id"`
+	}
+	code := doRequest(t, h, http.MethodPost, "/api/v1/user", "",
+		`{"username":"fastgo","password":"fastgo1234","email":"fastgo@example.com","phone":"18800000000"}`, &created)
+	require.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, created.UserID)
+
+	var login struct {
+		Token string `json:"token"`
+	}
+	code = doRequest(t, h, http.MethodPost, "/login", "", `{"username":"fastgo","password":"fastgo1234"}`, &login)
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, login.Token)
+
+	var got struct {
+		User struct {
+			UserID   string `json:"user_id"`
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	code = doRequest(t, h, http.MethodGet, "/api/v1/user/"+created.UserID, login.Token, "", &got)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, created.UserID, got.User.UserID)
+	assert.Equal(t, "fastgo", got.User.Username)
+}
+
+func TestServerAuthorization(t *testing.T) {
+	h := newTestServer(t)
+
+	aliceID, aliceToken := createUser(t, h, "alice", "18800000001")
+	bobID, bobToken := createUser(t, h, "bob", "18800000002")
+
+	var errResp struct {
+		Reason string `json:"reason"`
+	}
+	code := doRequest(t, h, http.MethodGet, "/api/v1/user/"+bobID, aliceToken, "", &errResp)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "PermissionDenied", errResp.Reason)
+
+	code = doRequest(t, h, http.MethodGet, "/api/v1/user", aliceToken, "", nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code = doRequest(t, h, http.MethodPut, "/api/v1/user/"+bobID, bobToken, `{"role":"admin"}`, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// Missing users are denied like existing ones, which does not reveal which users exist
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		code = doRequest(t, h, method, "/api/v1/user/nobody", aliceToken, `{"nickname":"nobody"}`, nil)
+		assert.Equal(t, http.StatusForbidden, code, method)
+	}
+
+	// Promote alice, the new role is carried by the next token
+	require.NoError(t, store.Store.DB(context.Background()).Model(&model.User{}).
+		Where("userID = ?", aliceID).Update("role", known.RoleAdmin).Error)
+	aliceToken = login(t, h, "alice")
+
+	code = doRequest(t, h, http.MethodGet, "/api/v1/user/"+bobID, aliceToken, "", nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	code = doRequest(t, h, http.MethodGet, "/api/v1/user", aliceToken, "", nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	code = doRequest(t, h, http.MethodGet, "/api/v1/user/nobody", aliceToken, "", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestUserPathParameter(t *testing.T) {
+	h := newTestServer(t)
+
+	carolID, carolToken := createUser(t, h, "carol", "18800000003")
+	daveID, daveToken := createUser(t, h, "dave", "18800000004")
+
+	// A user may be addressed by username as well as by userID
+	var got struct {
+		User struct {
+			UserID string `json:"user_id"`
+		} `json:"user"`
+	}
+	code := doRequest(t, h, http.MethodGet, "/api/v1/user/carol", carolToken, "", &got)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, carolID, got.User.UserID)
+
+	code = doRequest(t, h, http.MethodPut, "/api/v1/user/carol", carolToken, `{"nickname":"carol(modified)"}`, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	// Deleting someone else must not delete the caller
+	code = doRequest(t, h, http.MethodDelete, "/api/v1/user/"+daveID, carolToken, "", nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code = doRequest(t, h, http.MethodGet, "/api/v1/user/"+carolID, carolToken, "", nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	code = doRequest(t, h, http.MethodDelete, "/api/v1/user/dave", daveToken, "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	code = doRequest(t, h, http.MethodGet, "/api/v1/user/"+daveID, carolToken, "", nil)
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestRefreshTokenAndRevocation(t *testing.T) {
+	h := newTestServer(t)
+	userID, _ := createUser(t, h, "erin", "18800000005")
+
+	type tokens struct {
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	var first tokens
+	body := `{"username":"erin","password":"fastgo1234"}`
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/login", "", body, &first))
+	require.NotEmpty(t, first.RefreshToken)
+
+	// Refreshing rotates the refresh token and keeps the session alive
+	var second tokens
+	body = fmt.Sprintf(`{"refresh_token":%q}`, first.RefreshToken)
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/refresh-token", "", body, &second))
+	require.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, second.Token, "", nil))
+
+	// Replaying the rotated refresh token revokes the whole session
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodPost, "/refresh-token", "", body, nil))
+	body = fmt.Sprintf(`{"refresh_token":%q}`, second.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodPost, "/refresh-token", "", body, nil))
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, second.Token, "", nil))
+
+	// Logout revokes the access token immediately
+	token := login(t, h, "erin")
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/logout", token, "", nil))
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, token, "", nil))
+
+	// Changing the password signs out every session of the user
+	token, other := login(t, h, "erin"), login(t, h, "erin")
+	body = `{"old_password":"fastgo1234","new_password":"fastgo5678"}`
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPut, "/api/v1/user/"+userID+"/change-password", token, body, nil))
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, token, "", nil))
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, other, "", nil))
+
+	// Deleting the user signs out every session of the user
+	var session tokens
+	body = `{"username":"erin","password":"fastgo5678"}`
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/login", "", body, &session))
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/login", "", body, &first))
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodDelete, "/api/v1/user/"+userID, first.Token, "", nil))
+	body = fmt.Sprintf(`{"refresh_token":%q}`, session.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodPost, "/refresh-token", "", body, nil))
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, session.Token, "", nil))
+}
+
+// writeKey writes the PKCS #8 private key and the PKIX public key of key as PEM files
+func writeKey(t *testing.T, name string, key crypto.Signer) (string, string) {
+	t.Helper()
+
+	priv, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+
+	privFile := filepath.Join(t.TempDir(), name+".key")
+	pubFile := filepath.Join(t.TempDir(), name+".pub")
+	require.NoError(t, os.WriteFile(privFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: priv}), 0o600))
+	require.NoError(t, os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0o600))
+
+	return privFile, pubFile
+}
+
+func TestSigningKeyRotation(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecPriv, ecPub := writeKey(t, "ec", ecKey)
+	edPriv, _ := writeKey(t, "ed", edKey)
+
+	h := newTestServer(t, func(cfg *Config) {
+		cfg.JWTOptions = &genericoptions.JWTOptions{
+			ActiveKeyID: "ec-1",
+			Keys:        []genericoptions.JWTKeyOptions{{ID: "ec-1", Algorithm: "ES256", PrivateKeyFile: ecPriv}},
+		}
+	})
+	userID, oldToken := createUser(t, h, "frank", "18800000006")
+
+	// Rotate to an EdDSA key, the retired key only verifies the tokens it signed
+	h = newTestServer(t, func(cfg *Config) {
+		cfg.JWTOptions = &genericoptions.JWTOptions{
+			ActiveKeyID: "ed-1",
+			Keys: []genericoptions.JWTKeyOptions{
+				{ID: "ed-1", Algorithm: "EdDSA", PrivateKeyFile: edPriv},
+				{ID: "ec-1", Algorithm: "ES256", PublicKeyFile: ecPub},
+			},
+		}
+	})
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, oldToken, "", nil))
+
+	newToken := login(t, h, "frank")
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, newToken, "", nil))
+	header, err := base64.RawURLEncoding.DecodeString(strings.Split(newToken, ".")[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(header), `"kid":"ed-1"`)
+	assert.Contains(t, string(header), `"alg":"EdDSA"`)
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			Alg string `json:"alg"`
+			Crv string `json:"crv"`
+		} `json:"keys"`
+	}
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/.well-known/jwks.json", "", "", &jwks))
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "ec-1", jwks.Keys[0].Kid)
+	assert.Equal(t, "EC", jwks.Keys[0].Kty)
+	assert.Equal(t, "P-256", jwks.Keys[0].Crv)
+	assert.Equal(t, "ed-1", jwks.Keys[1].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+
+	// Tokens signed with a key which is no longer configured are rejected
+	h = newTestServer(t)
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, newToken, "", nil))
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/.well-known/jwks.json", "", "", &jwks))
+	assert.Empty(t, jwks.Keys)
+}
+
+func TestCreateUser(t *testing.T) {
+	srv := newServer(t)
+	h := srv.srv.Handler
+	ctx := context.Background()
+
+	// The first administrator is created without the API
+	req := &apiv1.CreateUserRequest{Username: "zoe", Password: "fastgo1234", Email: "zoe@example.com", Phone: "18800000028"}
+	_, err := srv.cfg.CreateUser(ctx, srv.db, req, "root")
+	require.Error(t, err)
+	userID, err := srv.cfg.CreateUser(ctx, srv.db, req, known.RoleAdmin)
+	require.NoError(t, err)
+
+	token := login(t, h, "zoe")
+	var got apiv1.GetUserResponse
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, token, "", &got))
+	assert.Equal(t, known.RoleAdmin, got.User.Role)
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user", token, "", nil))
+
+	// The users are validated like those of the sign up
+	_, err = srv.cfg.CreateUser(ctx, srv.db, req, known.RoleAdmin)
+	assert.ErrorIs(t, err, errorx.ErrUserAlreadyExists)
+
+	// The unique indexes are reported like the validation, e.g. when two sign ups race
+	userM := &model.User{Username: "zoe", Password: "hash", Email: "zoe@example.com", Phone: "18800000029", Role: known.RoleUser}
+	assert.ErrorIs(t, store.NewStore(srv.db).User().Create(ctx, userM), errorx.ErrUserAlreadyExists)
+}
+
+func TestTokenClaimsValidation(t *testing.T) {
+	withAudience := func(audience ...string) func(*Config) {
+		return func(cfg *Config) {
+			cfg.JWTOptions = genericoptions.NewJWTOptions()
+			cfg.JWTOptions.Audience = audience
+		}
+	}
+
+	h := newTestServer(t, withAudience("fastgo"))
+	userID, token := createUser(t, h, "grace", "18800000007")
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[1])
+	require.NoError(t, err)
+	var claims struct {
+		Subject  string   `json:"sub"`
+		ID       string   `json:"jti"`
+		Issuer   string   `json:"iss"`
+		Audience []string `json:"aud"`
+		Username string   `json:"username"`
+		Roles    []string `json:"roles"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &claims))
+	assert.Equal(t, userID, claims.Subject)
+	assert.NotEmpty(t, claims.ID)
+	assert.Equal(t, "fg-apiserver", claims.Issuer)
+	assert.Equal(t, []string{"fastgo"}, claims.Audience)
+	assert.Equal(t, "grace", claims.Username)
+	assert.Equal(t, []string{known.RoleUser}, claims.Roles)
+
+	// Tokens issued for another audience are rejected
+	h = newTestServer(t, withAudience("other"))
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, token, "", nil))
+	h = newTestServer(t, withAudience("other", "fastgo"))
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user/"+userID, token, "", nil))
+}
+
+func TestCursorPagination(t *testing.T) {
+	h := newTestServer(t)
+	_, token := createUser(t, h, "heidi", "18800000008")
+
+	var created []string
+	for i := range 5 {
+		var resp struct {
+			PostID string `json:"post_id"`
+		}
+		body := fmt.Sprintf(`{"title":"post %d","content":"content"}`, i)
+		require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/api/v1/post", token, body, &resp))
+		created = append([]string{resp.PostID}, created...)
+	}
+
+	type page struct {
+		Total *int64 `json:"total"`
+		Posts []struct {
+			PostID string `json:"post_id"`
+		} `json:"posts"`
+		NextCursor string `json:"next_cursor"`
+	}
+
+	// Follow the cursors until the last page, the posts come newest first
+	var (
+		listed []string
+		cursor string
+	)
+	for range 10 {
+		var resp page
+		path := "/api/v1/post?limit=2&skip_count=true&cursor=" + cursor
+		require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, path, token, "", &resp))
+		assert.Nil(t, resp.Total)
+		for _, post := range resp.Posts {
+			listed = append(listed, post.PostID)
+		}
+		if cursor = resp.NextCursor; cursor == "" {
+			break
+		}
+	}
+	assert.Equal(t, created, listed)
+
+	// The total counts every post, not only the requested page
+	var resp page
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/post?limit=2&offset=2", token, "", &resp))
+	require.NotNil(t, resp.Total)
+	assert.EqualValues(t, 5, *resp.Total)
+	assert.Len(t, resp.Posts, 2)
+
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodGet, "/api/v1/post?offset=2&cursor="+resp.NextCursor, token, "", nil))
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodGet, "/api/v1/post?cursor=garbage", token, "", nil))
+}
+
+func TestListFilterAndSort(t *testing.T) {
+	h := newTestServer(t)
+	_, token := createUser(t, h, "ivan", "18800000009")
+
+	for _, title := range []string{"go basics", "rust intro", "go at 100%"} {
+		body := fmt.Sprintf(`{"title":%q,"content":"content"}`, title)
+		require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/api/v1/post", token, body, nil))
+	}
+
+	titles := func(query string) []string {
+		t.Helper()
+
+		var resp struct {
+			Posts []struct {
+				Title string `json:"title"`
+			} `json:"posts"`
+		}
+		require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/post?"+query, token, "", &resp))
+
+		var titles []string
+		for _, post := range resp.Posts {
+			titles = append(titles, post.Title)
+		}
+		return titles
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1).Format(time.DateOnly)
+	assert.Equal(t, []string{"go at 100%", "go basics"}, titles("filter=title~go&sort=title"))
+	assert.Equal(t, []string{"go at 100%"}, titles("filter="+url.QueryEscape("title~100%")))
+	assert.Equal(t, []string{"rust intro", "go basics"}, titles("filter="+url.QueryEscape("title!=go at 100%")+"&sort=-title"))
+	assert.Len(t, titles("filter=created_at>=2025-01-01,created_at<"+tomorrow), 3)
+	assert.Empty(t, titles("filter=created_at<2025-01-01"))
+
+	// Sorted lists are paged with offsets, they return no cursor
+	var page struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/post?sort=title&limit=2", token, "", &page))
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, []string{"go at 100%", "go basics"}, titles("sort=title&limit=2"))
+	assert.Equal(t, []string{"rust intro"}, titles("sort=title&limit=2&offset=2"))
+
+	for _, query := range []string{
+		"filter=userID=someone",
+		"filter=content=content",
+		"filter=created_at>yesterday",
+		"sort=content",
+		"sort=title&cursor=eyJpZCI6MX0",
+	} {
+		assert.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodGet, "/api/v1/post?"+query, token, "", nil), query)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	h := newTestServer(t)
+	_, token := createUser(t, h, "judy", "18800000010")
+
+	type failure struct {
+		Reason   string            `json:"reason"`
+		Metadata map[string]string `json:"metadata"`
+	}
+
+	var resp failure
+	body := `{"username":" ","password":"short","email":"judy","phone":"call me"}`
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodPost, "/api/v1/user", "", body, &resp))
+	assert.Equal(t, "InvalidArgument.UserNameInvalid", resp.Reason)
+	delete(resp.Metadata, "X-Request-ID")
+	assert.Equal(t, []string{"email", "password", "phone", "username"}, slices.Sorted(maps.Keys(resp.Metadata)))
+
+	// Usernames and phone numbers are unique
+	resp = failure{}
+	body = `{"username":"judy","password":"fastgo1234","email":"judy@example.com","phone":"18800000010"}`
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodPost, "/api/v1/user", "", body, &resp))
+	assert.Equal(t, "AlreadyExist.UserAlreadyExists", resp.Reason)
+	delete(resp.Metadata, "X-Request-ID")
+	assert.Equal(t, map[string]string{"username": "is already taken", "phone": "is already taken"}, resp.Metadata)
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPut, "/api/v1/user/judy", token, `{"phone":"18800000010"}`, nil))
+
+	resp = failure{}
+	body = fmt.Sprintf(`{"title":%q,"content":"content"}`, strings.Repeat("t", 257))
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodPost, "/api/v1/post", token, body, &resp))
+	assert.Contains(t, resp.Metadata, "title")
+}
+
+func TestErrorResponse(t *testing.T) {
+	h := newTestServer(t)
+	_, token := createUser(t, h, "kate", "18800000011")
+
+	// Errors of the middlewares, the request binding and the biz layer share the same envelope
+	for _, tc := range []struct {
+		method, path, token, body string
+		code                      int
+		reason                    string
+	}{
+		{http.MethodGet, "/api/v1/post", "", "", http.StatusUnauthorized, "Unauthenticated.TokenInvalid"},
+		{http.MethodGet, "/api/v1/user", token, "", http.StatusForbidden, "PermissionDenied"},
+		{http.MethodPost, "/api/v1/post", token, "{", http.StatusBadRequest, "BindError"},
+		{http.MethodGet, "/api/v1/post/post-missing", token, "", http.StatusForbidden, "PermissionDenied"},
+		{http.MethodPut, "/api/v1/user/kate/change-password", token, `{"old_password":"wrong1234","new_password":"fastgo5678"}`,
+			http.StatusUnauthorized, "Unauthenticated.InvalidPassword"},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		var resp struct {
+			Code     int               `json:"code"`
+			Reason   string            `json:"reason"`
+			Message  string            `json:"message"`
+			Metadata map[string]string `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		assert.Equal(t, tc.code, w.Code, tc.path)
+		assert.Equal(t, tc.code, resp.Code, tc.path)
+		assert.Equal(t, tc.reason, resp.Reason, tc.path)
+		assert.NotEmpty(t, resp.Message, tc.path)
+		assert.Equal(t, w.Header().Get("X-Request-ID"), resp.Metadata["X-Request-ID"], tc.path)
+		assert.NotEmpty(t, resp.Metadata["X-Request-ID"], tc.path)
+	}
+}
+
+func TestPostByPath(t *testing.T) {
+	h := newTestServer(t)
+	_, token := createUser(t, h, "leo", "18800000012")
+
+	var created struct {
+		PostID string `json:"post_id"`
+	}
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPost, "/api/v1/post", token, `{"title":"draft","content":"content"}`, &created))
+	path := "/api/v1/post/" + created.PostID
+
+	// The post ID of the path is bound along with the JSON body
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPut, path, token, `{"title":"final"}`, nil))
+
+	var got struct {
+		Post struct {
+			PostID string `json:"post_id"`
+			Title  string `json:"title"`
+		} `json:"post"`
+	}
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, path, token, "", &got))
+	assert.Equal(t, created.PostID, got.Post.PostID)
+	assert.Equal(t, "final", got.Post.Title)
+
+	var bindErr struct {
+		Reason  string `json:"reason"`
+		Message string `json:"message"`
+	}
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodPut, path, token, `{"title":1}`, &bindErr))
+	assert.Equal(t, "BindError", bindErr.Reason)
+	assert.Contains(t, bindErr.Message, "title")
+
+	// Deleted posts are denied like the posts of other users
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodDelete, path, token, "", nil))
+	assert.Equal(t, http.StatusForbidden, doRequest(t, h, http.MethodGet, path, token, "", nil))
+}
+
+// dialGRPC serves the gRPC server of cfg in memory and connects to it
+func dialGRPC(t *testing.T, cfg *Config) *grpc.ClientConn {
+	t.Helper()
+
+	srv, err := cfg.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = srv.authn.Release() })
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.grpcSrv.Serve(lis) }()
+	t.Cleanup(srv.grpcSrv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestGRPCServer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &Config{
+		StorageOptions: &genericoptions.StorageOptions{Driver: genericoptions.DriverMemory},
+		Addr:           "127.0.0.1:0",
+		GRPCAddr:       "127.0.0.1:0",
+		JWTKey:         "fastgo-test-key",
+		ExpiraTime:     time.Hour,
+
+		RefreshExpiraTime: 24 * time.Hour,
+	}
+	conn := dialGRPC(t, cfg)
+	users, posts := rpcv1.NewUserServiceClient(conn), rpcv1.NewPostServiceClient(conn)
+
+	ctx := context.Background()
+	created, err := users.CreateUser(ctx, &rpcv1.CreateUserRequest{
+		Username: "mallory", Password: "fastgo1234", Email: "mallory@example.com", Phone: "18800000013",
+	})
+	require.NoError(t, err)
+	login, err := users.Login(ctx, &rpcv1.LoginRequest{Username: "mallory", Password: "fastgo1234"})
+	require.NoError(t, err)
+	assert.True(t, login.GetExpireAt().AsTime().After(time.Now()))
+
+	// Calls without an access token are rejected with the reason and the request ID of the error
+	var header metadata.MD
+	_, err = users.GetUser(ctx, &rpcv1.GetUserRequest{UserId: created.GetUserId()}, grpc.Header(&header))
+	st := status.Convert(err)
+	assert.Equal(t, codes.Unauthenticated, st.Code())
+	require.Len(t, st.Details(), 1)
+	info := st.Details()[0].(*errdetails.ErrorInfo)
+	assert.Equal(t, "Unauthenticated.TokenInvalid", info.GetReason())
+	assert.Equal(t, header.Get(known.XRequestID), []string{info.GetMetadata()["X-Request-ID"]})
+
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+login.GetToken())
+	got, err := users.GetUser(authed, &rpcv1.GetUserRequest{UserId: "mallory"})
+	require.NoError(t, err)
+	assert.Equal(t, created.GetUserId(), got.GetUser().GetUserId())
+	assert.False(t, got.GetUser().GetCreateAt().AsTime().IsZero())
+
+	_, err = users.ListUsers(authed, &rpcv1.ListUserRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = users.UpdateUser(authed, &rpcv1.UpdateUserRequest{UserId: "mallory", Email: proto.String("mallory")})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	post, err := posts.CreatePost(authed, &rpcv1.CreatePostRequest{Title: "over grpc", Content: "content"})
+	require.NoError(t, err)
+	_, err = posts.UpdatePost(authed, &rpcv1.UpdatePostRequest{PostId: post.GetPostId(), Title: proto.String("updated")})
+	require.NoError(t, err)
+	list, err := posts.ListPosts(authed, &rpcv1.ListPostRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.GetPosts(), 1)
+	assert.Equal(t, "updated", list.GetPosts()[0].GetTitle())
+	assert.EqualValues(t, 1, list.GetTotal())
+
+	_, err = posts.DeletePost(authed, &rpcv1.DeletePostRequest{PostId: []string{post.GetPostId()}})
+	require.NoError(t, err)
+	_, err = posts.GetPost(authed, &rpcv1.GetPostRequest{PostId: post.GetPostId()})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// writeCert issues a certificate signed by parent, or a self-signed CA if parent is nil, and
+// writes it to dir as name.crt and name.key
+func writeCert(t *testing.T, dir, name string, serial int64, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid, tmpl.KeyUsage = true, true, x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func TestTLSServing(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", 1, nil, nil)
+	writeCert(t, dir, "server", 2, ca, caKey)
+	writeCert(t, dir, "client", 3, ca, caKey)
+
+	gin.SetMode(gin.TestMode)
+	cfg := &Config{
+		StorageOptions: &genericoptions.StorageOptions{Driver: genericoptions.DriverMemory},
+		Addr:           "127.0.0.1:8443",
+		TLSOptions: &genericoptions.TLSOptions{
+			CertFile:     filepath.Join(dir, "server.crt"),
+			KeyFile:      filepath.Join(dir, "server.key"),
+			MinVersion:   "1.2",
+			ClientCAFile: filepath.Join(dir, "ca.crt"),
+			ClientAuth:   genericoptions.ClientAuthRequire,
+			RedirectAddr: "127.0.0.1:8080",
+		},
+		JWTKey:     "fastgo-test-key",
+		ExpiraTime: time.Hour,
+
+		RefreshExpiraTime: 24 * time.Hour,
+	}
+	srv, err := cfg.NewServer()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = srv.authn.Release() })
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.srv.ServeTLS(lis, "", "") }()
+	t.Cleanup(func() { _ = srv.srv.Close() })
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key"))
+	require.NoError(t, err)
+
+	// get returns the serial number of the server certificate
+	get := func(certs ...tls.Certificate) (int64, error) {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+		defer client.CloseIdleConnections()
+
+		resp, err := client.Get("https://" + lis.Addr().String() + "/healthz")
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return 0, fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return resp.TLS.PeerCertificates[0].SerialNumber.Int64(), nil
+	}
+
+	serial, err := get(clientCert)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, serial)
+
+	// Clients without a certificate signed by the client CA are rejected
+	_, err = get()
+	assert.Error(t, err)
+
+	// Renewed certificates are served without a restart
+	writeCert(t, dir, "server", 4, ca, caKey)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "server.crt"), future, future))
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "server.key"), future, future))
+	assert.Eventually(t, func() bool {
+		serial, err := get(clientCert)
+		return err == nil && serial == 4
+	}, 5*time.Second, 200*time.Millisecond)
+
+	// Plain HTTP requests are redirected to the HTTPS address
+	require.NotNil(t, srv.redirectSrv)
+	w := httptest.NewRecorder()
+	srv.redirectSrv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://fastgo.example:8080/api/v1/post?limit=1", nil))
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "https://fastgo.example:8443/api/v1/post?limit=1", w.Header().Get("Location"))
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	h := newTestServer(t, func(cfg *Config) {
+		cfg.DocsOptions = &genericoptions.DocsOptions{UI: true}
+	})
+	engine := h.(*gin.Engine)
+
+	var doc struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/openapi.json", "", "", &doc))
+
+	// Every route is documented
+	for _, route := range engine.Routes() {
+		if route.Path == "/openapi.json" || route.Path == "/docs" || strings.HasPrefix(route.Path, "/docs/") {
+			continue
+		}
+		ops, ok := doc.Paths[openapi.OpenAPIPath(route.Path)]
+		require.True(t, ok, "route %s %s is not documented", route.Method, route.Path)
+		assert.Contains(t, ops, strings.ToLower(route.Method), "route %s %s is not documented", route.Method, route.Path)
+	}
+
+	// The checked in document is up to date
+	want, err := json.MarshalIndent(NewOpenAPI(), "", "  ")
+	require.NoError(t, err)
+	got, err := os.ReadFile("../../docs/openapi.json")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got), "docs/openapi.json is outdated, run: go run ./cmd/fg-apiserver openapi > docs/openapi.json")
+
+	// The page loads the assets embedded in the server, without reaching any CDN
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `src="/docs/assets/swagger-ui-bundle.js"`)
+	for _, asset := range []string{"/docs/assets/swagger-ui-bundle.js", "/docs/assets/swagger-ui.css"} {
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, asset, nil))
+		assert.Equal(t, http.StatusOK, w.Code, asset)
+		assert.NotEmpty(t, w.Body.Bytes(), asset)
+	}
+
+	// A mirror of the assets replaces the embedded ones
+	h = newTestServer(t, func(cfg *Config) {
+		cfg.DocsOptions = &genericoptions.DocsOptions{UI: true, AssetsURL: "https://assets.example/swagger-ui"}
+	})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Contains(t, w.Body.String(), "https://assets.example/swagger-ui/swagger-ui-bundle.js")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/assets/swagger-ui.css", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestClient(t *testing.T) {
+	h := newTestServer(t, func(cfg *Config) { cfg.ExpiraTime = 2 * time.Second })
+
+	// The first GET of every path fails with 503, as an overloaded proxy would
+	var mu sync.Mutex
+	failed := map[string]bool{}
+	requestIDs := map[string]bool{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requestIDs[r.Header.Get("X-Request-ID")] = true
+		flaky := r.Method == http.MethodGet && !failed[r.URL.Path]
+		failed[r.URL.Path] = true
+		mu.Unlock()
+		if flaky {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	ctx := context.Background()
+	c := client.New(ts.URL, client.WithRetries(2, time.Millisecond))
+	created, err := c.Users().Create(ctx, &apiv1.CreateUserRequest{
+		Username: "niaj", Password: "fastgo1234", Email: "niaj@example.com", Phone: "18800000014",
+	})
+	require.NoError(t, err)
+	_, err = c.Login(ctx, "niaj", "fastgo1234")
+	require.NoError(t, err)
+	_, _, refreshToken := c.Token()
+
+	// Idempotent calls are retried
+	got, err := c.Users().Get(ctx, &apiv1.GetUserRequest{UserID: "niaj"})
+	require.NoError(t, err)
+	assert.Equal(t, created.UserID, got.User.UserID)
+
+	post, err := c.Posts().Create(ctx, &apiv1.CreatePostRequest{Title: "from the sdk", Content: "content"})
+	require.NoError(t, err)
+	list, err := c.Posts().List(ctx, &apiv1.ListPostRequest{Filter: "title~sdk"})
+	require.NoError(t, err)
+	require.Len(t, list.Posts, 1)
+	assert.Equal(t, post.PostID, list.Posts[0].PostID)
+
+	// Error responses keep their reason and the request ID of the call
+	_, err = c.Posts().Get(client.WithRequestID(ctx, "sdk-request"), &apiv1.GetPostRequest{PostID: "post-missing"})
+	assert.ErrorIs(t, err, client.ErrPermissionDenied)
+	assert.Equal(t, "PermissionDenied", client.Reason(err))
+	var errx *client.Error
+	require.ErrorAs(t, err, &errx)
+	assert.Equal(t, "sdk-request", errx.Metadata["X-Request-ID"])
+	assert.True(t, requestIDs["sdk-request"])
+
+	// The access token, which lives for less than the refresh skew, is refreshed before it expires
+	_, err = c.Posts().Delete(ctx, &apiv1.DeletePostRequest{PostID: []string{post.PostID}})
+	require.NoError(t, err)
+	_, _, rotated := c.Token()
+	assert.NotEqual(t, refreshToken, rotated)
+
+	require.NoError(t, c.Logout(ctx))
+	_, err = c.Users().Get(ctx, &apiv1.GetUserRequest{UserID: "niaj"})
+	assert.ErrorIs(t, err, client.ErrTokenInvalid)
+}
+
+func TestFgctl(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(t))
+	t.Cleanup(ts.Close)
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+
+	fgctl := func(stdin string, args ...string) (string, error) {
+		var out bytes.Buffer
+		cmd := fgctlapp.NewFgctlCommand()
+		cmd.SetArgs(append([]string{"--config", configFile, "--server", ts.URL}, args...))
+		cmd.SetIn(strings.NewReader(stdin))
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	_, err := fgctl("", "user", "create", "--username", "olivia", "--password", "fastgo1234",
+		"--email", "olivia@example.com", "--phone", "18800000015")
+	require.NoError(t, err)
+	_, err = fgctl("fastgo1234\n", "login", "olivia", "--password-stdin")
+	require.NoError(t, err)
+	config, err := os.ReadFile(configFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(config), "refresh_token:")
+
+	out, err := fgctl("", "user", "get", "olivia", "-o", "json")
+	require.NoError(t, err)
+	var user apiv1.User
+	require.NoError(t, json.Unmarshal([]byte(out), &user))
+	assert.Equal(t, "olivia@example.com", user.Email)
+
+	out, err = fgctl("", "post", "create", "--title", "from fgctl", "--content", "content", "-o", "yaml")
+	require.NoError(t, err)
+	assert.Contains(t, out, "post_id: postID-")
+
+	out, err = fgctl("", "post", "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "TITLE")
+	assert.Contains(t, out, "from fgctl")
+	assert.Contains(t, out, "Total: 1")
+
+	// Changing the own password revokes the session kept in the config file
+	out, err = fgctl("fastgo1234\nfastgo5678\n", "password", "change")
+	require.NoError(t, err)
+	assert.Contains(t, out, "log in again")
+	_, err = fgctl("", "post", "list")
+	assert.True(t, errors.Is(err, client.ErrTokenInvalid), "%v", err)
+}
+
+func TestMetrics(t *testing.T) {
+	srv := newServer(t, func(cfg *Config) {
+		cfg.MetricsOptions = &genericoptions.MetricsOptions{Addr: "127.0.0.1:0"}
+	})
+	h := srv.srv.Handler
+	createUser(t, h, "peggy", "18800000016")
+	require.Equal(t, http.StatusUnauthorized, doRequest(t, h, http.MethodPost, "/login", "", `{"username":"peggy","password":"wrong-password"}`, nil))
+	doRequest(t, h, http.MethodGet, "/no/such/path", "", "", nil)
+
+	// The metrics are not served by the API
+	require.Equal(t, http.StatusNotFound, doRequest(t, h, http.MethodGet, "/metrics", "", "", nil))
+
+	w := httptest.NewRecorder()
+	srv.metricsSrv.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	body := w.Body.String()
+	for _, series := range []string{
+		`fastgo_http_requests_total{code="200",method="POST",route="/login"}`,
+		`fastgo_http_requests_total{code="404",method="GET",route="unmatched"}`,
+		`fastgo_http_request_duration_seconds_count{method="POST",route="/api/v1/user"}`,
+		`fastgo_db_query_duration_seconds_count{operation="create",table="user"}`,
+		`fastgo_auth_login_attempts_total{result="success"}`,
+		`fastgo_auth_login_attempts_total{result="failure"}`,
+		`go_sql_max_open_connections{db_name="memory"} 1`,
+		`fastgo_build_info{`,
+	} {
+		assert.Contains(t, body, series)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	h := newTestServer(t)
+	userID, _ := createUser(t, h, "quentin", "18800000017")
+	require.NoError(t, store.Store.DB(context.Background()).Model(&model.User{}).
+		Where("userID = ?", userID).Update("role", known.RoleAdmin).Error)
+	token := login(t, h, "quentin")
+
+	// The trace of the caller is continued
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/user?filter=username=quentin", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+	server, biz, count := spans["GET /api/v1/user"], spans["userBiz.List"], spans["userBiz.countPosts"]
+	require.NotNil(t, server, "spans: %v", slices.Collect(maps.Keys(spans)))
+	require.NotNil(t, biz)
+	require.NotNil(t, count)
+	assert.Equal(t, server.SpanContext().SpanID(), biz.Parent().SpanID())
+	assert.Equal(t, biz.SpanContext().SpanID(), count.Parent().SpanID())
+
+	// The post count query of the fan-out is a child of its goroutine span
+	var statement string
+	for _, span := range recorder.Ended() {
+		if span.Name() == "gorm.query" && span.Parent().SpanID() == count.SpanContext().SpanID() {
+			for _, attr := range span.Attributes() {
+				if attr.Key == "db.query.text" {
+					statement = attr.Value.AsString()
+				}
+			}
+		}
+	}
+	assert.Contains(t, statement, "FROM `post`")
+
+	// The logs of the request carry its trace ID
+	var buf strings.Builder
+	logger := slog.New(log.NewHandler(slog.NewTextHandler(&buf, nil)))
+	ctx, span := tracing.Start(trace.ContextWithSpanContext(context.Background(), server.SpanContext()), "log")
+	logger.InfoContext(ctx, "traced")
+	span.End()
+	assert.Contains(t, buf.String(), "trace_id="+traceID)
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	previous := slog.Default()
+	slog.SetDefault(slog.New(log.NewHandler(slog.NewJSONHandler(lockedWriter{&mu, &buf}, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	h := newTestServer(t)
+	userID, token := createUser(t, h, "rupert", "18800000018")
+
+	// The access log carries the request ID and the user ID of the caller
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/user/"+userID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-Request-ID", "log-request")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	mu.Lock()
+	var access map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		if record["msg"] == "HTTP request" && record["request_id"] == "log-request" {
+			access = record
+		}
+	}
+	mu.Unlock()
+	require.NotNil(t, access)
+	assert.Equal(t, userID, access["user_id"])
+	assert.Equal(t, http.MethodGet, access["method"])
+	assert.EqualValues(t, http.StatusOK, access["status"])
+	assert.Contains(t, access, "latency")
+	assert.Contains(t, access, "client_ip")
+	assert.Positive(t, access["bytes"])
+
+	// Only administrators may change the log level
+	require.Equal(t, http.StatusForbidden, doRequest(t, h, http.MethodPut, "/admin/log-level", token, `{"level":"debug"}`, nil))
+	require.NoError(t, store.Store.DB(context.Background()).Model(&model.User{}).
+		Where("userID = ?", userID).Update("role", known.RoleAdmin).Error)
+	token = login(t, h, "rupert")
+
+	var level apiv1.UpdateLogLevelResponse
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPut, "/admin/log-level", token, `{"level":"debug"}`, &level))
+	assert.Equal(t, "debug", level.Level)
+	t.Cleanup(func() { _ = log.SetLevel("info") })
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/admin/log-level", token, "", &level))
+	assert.Equal(t, "debug", level.Level)
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodPut, "/admin/log-level", token, `{"level":"verbose"}`, nil))
+}
+
+// lockedWriter serializes the writes of concurrent log records
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (lw lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+func TestHealth(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "fastgo.log")
+	srv := newServer(t, func(cfg *Config) {
+		cfg.LogOptions = &log.Options{Output: logFile}
+		cfg.HealthOptions = &genericoptions.HealthOptions{Timeout: time.Second, MinFreeDiskMB: 1}
+	})
+	h := srv.srv.Handler
+
+	checks := func(result *health.Result) map[string]health.Status {
+		statuses := make(map[string]health.Status)
+		for _, check := range result.Checks {
+			statuses[check.Name] = check.Status
+		}
+		return statuses
+	}
+
+	var result health.Result
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/livez", "", "", &result))
+	assert.Equal(t, health.StatusOK, result.Status)
+
+	for _, path := range []string{"/readyz", "/healthz"} {
+		require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, path, "", "", &result), path)
+		assert.Equal(t, map[string]health.Status{
+			"shutdown":   health.StatusOK,
+			"database":   health.StatusOK,
+			"migrations": health.StatusOK,
+			"disk":       health.StatusOK,
+		}, checks(&result), path)
+	}
+
+	// The readiness fails once the server shuts down, while it is still alive
+	srv.health.ShutDown()
+	require.Equal(t, http.StatusServiceUnavailable, doRequest(t, h, http.MethodGet, "/readyz", "", "", &result))
+	assert.Equal(t, health.StatusFailed, result.Status)
+	assert.Equal(t, health.StatusFailed, checks(&result)["shutdown"])
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/livez", "", "", nil))
+}
+
+func TestShutdown(t *testing.T) {
+	// A busy address fails the start, which is returned instead of exiting the process
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+	srv := newServer(t, func(cfg *Config) { cfg.Addr = busy.Addr().String() })
+	require.ErrorContains(t, srv.Run(context.Background()), "start http server")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	srv = newServer(t, func(cfg *Config) {
+		cfg.Addr = addr
+		cfg.ShutdownOptions = &genericoptions.ShutdownOptions{PreStopDelay: 500 * time.Millisecond, DrainPeriod: time.Second, Timeout: 5 * time.Second}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- srv.Run(ctx) }()
+
+	client := &http.Client{Timeout: time.Second}
+	defer client.CloseIdleConnections()
+	probe := func(path string) int {
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			return 0
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+	require.Eventually(t, func() bool { return probe("/readyz") == http.StatusOK }, 5*time.Second, 10*time.Millisecond)
+
+	// The server keeps serving during the pre-stop delay, but it is no longer ready
+	cancel()
+	require.Eventually(t, func() bool { return probe("/readyz") == http.StatusServiceUnavailable }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, probe("/livez"))
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not shut down")
+	}
+
+	// The listener and the database are closed
+	assert.Zero(t, probe("/livez"))
+	sqlDB, err := srv.db.DB()
+	require.NoError(t, err)
+	assert.Error(t, sqlDB.Ping())
+}
+
+func TestRateLimit(t *testing.T) {
+	h := newTestServer(t, func(cfg *Config) {
+		cfg.RateLimitOptions = &genericoptions.RateLimitOptions{
+			Enabled: true,
+			Auth:    genericoptions.RouteRateLimit{Requests: 3, Period: time.Hour, Key: genericoptions.RateLimitKeyIP},
+			SignUp:  genericoptions.RouteRateLimit{Key: genericoptions.RateLimitKeyIP},
+			API:     genericoptions.RouteRateLimit{Requests: 2, Period: time.Hour, Key: genericoptions.RateLimitKeyUser},
+		}
+	})
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// Both logins and a failed one exhaust the logins of the client IP
+	sybilID, sybilToken := createUser(t, h, "sybil", "18800000019")
+	tristanID, tristanToken := createUser(t, h, "tristan", "18800000020")
+	w := send(http.MethodPost, "/login", "", `{"username":"sybil","password":"wrong-password"}`)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "3", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	w = send(http.MethodPost, "/login", "", `{"username":"sybil","password":"fastgo1234"}`)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	var errResp core.ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+	assert.Equal(t, errorx.ErrTooManyRequests.Reason, errResp.Reason)
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Positive(t, retryAfter)
+
+	// A forged X-Forwarded-For header does not get the client a new bucket
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"sybil","password":"fastgo1234"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	// The authenticated routes are limited per user
+	for range 2 {
+		require.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/user/"+sybilID, sybilToken, "").Code)
+	}
+	require.Equal(t, http.StatusTooManyRequests, send(http.MethodGet, "/api/v1/user/"+sybilID, sybilToken, "").Code)
+	require.Equal(t, http.StatusOK, send(http.MethodGet, "/api/v1/user/"+tristanID, tristanToken, "").Code)
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &Config{
+		StorageOptions: &genericoptions.StorageOptions{Driver: genericoptions.DriverMemory},
+		Addr:           "127.0.0.1:0",
+		GRPCAddr:       "127.0.0.1:0",
+		JWTKey:         "fastgo-test-key",
+		ExpiraTime:     time.Hour,
+		RateLimitOptions: &genericoptions.RateLimitOptions{
+			Enabled: true,
+			Auth:    genericoptions.RouteRateLimit{Requests: 2, Period: time.Hour, Key: genericoptions.RateLimitKeyIP},
+			SignUp:  genericoptions.RouteRateLimit{Requests: 1, Period: time.Hour, Key: genericoptions.RateLimitKeyIP},
+			API:     genericoptions.RouteRateLimit{Requests: 1, Period: time.Hour, Key: genericoptions.RateLimitKeyUser},
+		},
+
+		RefreshExpiraTime: 24 * time.Hour,
+	}
+	users := rpcv1.NewUserServiceClient(dialGRPC(t, cfg))
+	ctx := context.Background()
+
+	// The public methods are limited like their routes
+	_, err := users.CreateUser(ctx, &rpcv1.CreateUserRequest{
+		Username: "zelda", Password: "fastgo1234", Email: "zelda@example.com", Phone: "18800000026",
+	})
+	require.NoError(t, err)
+	_, err = users.CreateUser(ctx, &rpcv1.CreateUserRequest{
+		Username: "zora", Password: "fastgo1234", Email: "zora@example.com", Phone: "18800000027",
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = users.Login(ctx, &rpcv1.LoginRequest{Username: "zelda", Password: "wrong-password"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	login, err := users.Login(ctx, &rpcv1.LoginRequest{Username: "zelda", Password: "fastgo1234"})
+	require.NoError(t, err)
+	var header metadata.MD
+	_, err = users.Login(ctx, &rpcv1.LoginRequest{Username: "zelda", Password: "fastgo1234"}, grpc.Header(&header))
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, errorx.ErrTooManyRequests.Reason, st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	assert.Equal(t, []string{"2"}, header.Get("ratelimit-limit"))
+	require.Len(t, header.Get("retry-after"), 1)
+	retryAfter, err := strconv.Atoi(header.Get("retry-after")[0])
+	require.NoError(t, err)
+	assert.Positive(t, retryAfter)
+
+	// The authenticated methods are limited per user
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+login.GetToken())
+	_, err = users.GetUser(authed, &rpcv1.GetUserRequest{UserId: "zelda"})
+	require.NoError(t, err)
+	_, err = users.GetUser(authed, &rpcv1.GetUserRequest{UserId: "zelda"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestTrustedProxies(t *testing.T) {
+	// httptest requests come from 192.0.2.1
+	h := newTestServer(t, func(cfg *Config) {
+		cfg.TrustedProxies = []string{"192.0.2.0/24"}
+		cfg.RateLimitOptions = &genericoptions.RateLimitOptions{
+			Enabled: true,
+			Auth:    genericoptions.RouteRateLimit{Requests: 1, Period: time.Hour, Key: genericoptions.RateLimitKeyIP},
+			SignUp:  genericoptions.RouteRateLimit{Key: genericoptions.RateLimitKeyIP},
+			API:     genericoptions.RouteRateLimit{Key: genericoptions.RateLimitKeyIP},
+		}
+	})
+
+	login := func(forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"nobody","password":"wrong-password"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// The clients behind the trusted proxy are limited apart
+	require.Equal(t, http.StatusUnauthorized, login("203.0.113.7"))
+	require.Equal(t, http.StatusTooManyRequests, login("203.0.113.7"))
+	require.Equal(t, http.StatusUnauthorized, login("203.0.113.8"))
+	// Addresses prepended by the client are skipped, the client IP is the one the proxy saw
+	require.Equal(t, http.StatusTooManyRequests, login("198.51.100.1, 203.0.113.8"))
+}
+
+func TestLockout(t *testing.T) {
+	h := newTestServer(t, func(cfg *Config) {
+		cfg.LockoutOptions = &genericoptions.LockoutOptions{
+			Enabled: true, MaxFailures: 2, IPMaxFailures: 6,
+			Duration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute,
+		}
+	})
+	ursulaID, ursulaToken := createUser(t, h, "ursula", "18800000021")
+	victorID, _ := createUser(t, h, "victor", "18800000022")
+	require.NoError(t, store.Store.DB(context.Background()).Model(&model.User{}).
+		Where("userID = ?", victorID).Update("role", known.RoleAdmin).Error)
+	adminToken := login(t, h, "victor")
+
+	loginAs := func(username, password string) (int, core.ErrorResponse) {
+		var resp core.ErrorResponse
+		body := fmt.Sprintf(`{"username":%q,"password":%q}`, username, password)
+		return doRequest(t, h, http.MethodPost, "/login", "", body, &resp), resp
+	}
+
+	// Unknown users and wrong passwords are rejected alike, and lock the account alike
+	// The usernames differing by case, which the database matches alike, share the failures
+	for _, usernames := range [][]string{{"ursula", "nobody"}, {"URSULA", "Nobody"}} {
+		for _, username := range usernames {
+			code, resp := loginAs(username, "wrong-password")
+			require.Equal(t, http.StatusUnauthorized, code)
+			assert.Equal(t, errorx.ErrInvalidCredentials.Reason, resp.Reason)
+			assert.Equal(t, errorx.ErrInvalidCredentials.Message, resp.Message)
+		}
+	}
+	for _, username := range []string{"ursula", "nobody"} {
+		code, resp := loginAs(username, "fastgo1234")
+		require.Equal(t, http.StatusTooManyRequests, code)
+		assert.Equal(t, errorx.ErrLoginLocked.Reason, resp.Reason)
+		assert.NotEmpty(t, resp.Metadata["retry_after"])
+	}
+
+	// Only administrators see and clear the lock of an account
+	require.Equal(t, http.StatusForbidden, doRequest(t, h, http.MethodGet, "/api/v1/user/"+ursulaID+"/lock", ursulaToken, "", nil))
+	var lock apiv1.GetUserLockResponse
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user/ursula/lock", adminToken, "", &lock))
+	assert.True(t, lock.Locked)
+	assert.EqualValues(t, 2, lock.FailedLogins)
+	require.NotNil(t, lock.LockedUntil)
+	assert.True(t, lock.LockedUntil.After(time.Now()))
+
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodDelete, "/api/v1/user/"+ursulaID+"/lock", adminToken, "", nil))
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodGet, "/api/v1/user/ursula/lock", adminToken, "", &lock))
+	assert.False(t, lock.Locked)
+	assert.Zero(t, lock.FailedLogins)
+	login(t, h, "ursula")
+
+	// The client IP is locked after failed logins on any account
+	for _, username := range []string{"ghost1", "ghost2"} {
+		code, _ := loginAs(username, "wrong-password")
+		require.Equal(t, http.StatusUnauthorized, code)
+	}
+	code, resp := loginAs("victor", "fastgo1234")
+	require.Equal(t, http.StatusTooManyRequests, code)
+	assert.Equal(t, errorx.ErrLoginLocked.Reason, resp.Reason)
+}
+
+func TestLockoutParallelLogins(t *testing.T) {
+	h := newTestServer(t, func(cfg *Config) {
+		cfg.LockoutOptions = &genericoptions.LockoutOptions{
+			Enabled: true, MaxFailures: 3,
+			Duration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute,
+		}
+	})
+
+	// Parallel guesses are not checked against the lock before the others are counted
+	var mu sync.Mutex
+	codes := make(map[int]int)
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code := doRequest(t, h, http.MethodPost, "/login", "", `{"username":"nobody","password":"wrong-password"}`, nil)
+			mu.Lock()
+			codes[code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, map[int]int{http.StatusUnauthorized: 3, http.StatusTooManyRequests: 17}, codes)
+}
+
+func TestPasswordHashing(t *testing.T) {
+	passwordOptions := func(mutate func(*genericoptions.PasswordOptions)) func(*Config) {
+		return func(cfg *Config) {
+			cfg.PasswordOptions = genericoptions.NewPasswordOptions()
+			mutate(cfg.PasswordOptions)
+		}
+	}
+	storedHash := func(userID string) string {
+		var userM model.User
+		require.NoError(t, store.Store.DB(context.Background()).Where("userID = ?", userID).First(&userM).Error)
+		return userM.Password
+	}
+
+	// The hashes of another algorithm, or of other parameters, are replaced on the next login
+	bcryptServer := newTestServer(t, passwordOptions(func(o *genericoptions.PasswordOptions) {
+		o.Algorithm = genericoptions.AlgorithmBcrypt
+	}))
+	wendyID, _ := createUser(t, bcryptServer, "wendy", "18800000023")
+	assert.True(t, strings.HasPrefix(storedHash(wendyID), "$2a$"))
+
+	login(t, newTestServer(t), "wendy")
+	assert.True(t, strings.HasPrefix(storedHash(wendyID), "$argon2id$v=19$m=65536,t=3,p=2$"))
+
+	login(t, newTestServer(t, passwordOptions(func(o *genericoptions.PasswordOptions) {
+		o.Argon2Memory = 32 * 1024
+	})), "wendy")
+	assert.True(t, strings.HasPrefix(storedHash(wendyID), "$argon2id$v=19$m=32768,t=3,p=2$"))
+	login(t, bcryptServer, "wendy")
+	assert.True(t, strings.HasPrefix(storedHash(wendyID), "$2a$"))
+
+	// bcrypt hashes 72 bytes at most, longer passwords are rejected whatever their characters
+	longPassword := "1" + strings.Repeat("é", 40)
+	body := fmt.Sprintf(`{"username":"yvonne","password":%q,"email":"yvonne@example.com","phone":"18800000025"}`, longPassword)
+	require.Equal(t, http.StatusBadRequest, doRequest(t, bcryptServer, http.MethodPost, "/api/v1/user", "", body, nil))
+
+	// The leaked passwords, listed in plain text or as SHA-1, are rejected
+	breachList := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(breachList, []byte("# leaked passwords\nfastgo5678\n"+
+		"70CCD9007338D6D81DD3B6271621B9CF9A97EA00:42\n"), 0o600)) // SHA-1 of Password1
+	h := newTestServer(t, passwordOptions(func(o *genericoptions.PasswordOptions) {
+		o.BreachListFile = breachList
+	}))
+
+	body = `{"username":"xavier","password":"Password1","email":"xavier@example.com","phone":"18800000024"}`
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodPost, "/api/v1/user", "", body, nil))
+	xavierID, token := createUser(t, h, "xavier", "18800000024")
+
+	body = `{"old_password":"fastgo1234","new_password":"fastgo5678"}`
+	require.Equal(t, http.StatusBadRequest, doRequest(t, h, http.MethodPut, "/api/v1/user/"+xavierID+"/change-password", token, body, nil))
+	body = `{"old_password":"fastgo1234","new_password":"fastgo9012"}`
+	require.Equal(t, http.StatusOK, doRequest(t, h, http.MethodPut, "/api/v1/user/"+xavierID+"/change-password", token, body, nil))
+}
@@ -1,6 +1,9 @@
 package store
 
-import "log/slog"
+import (
+	"context"
+	"log/slog"
+)
 
 type Logger struct {
 }
@@ -9,6 +12,7 @@ func NewLogger() *Logger {
 	return &Logger{}
 }
 
-func (l *Logger) Error(err error, msg string, kvs ...any) {
-	slog.Error(msg, append(kvs, "err", err)...)
+// Error logs err with the request fields of ctx
+func (l *Logger) Error(ctx context.Context, err error, msg string, kvs ...any) {
+	slog.ErrorContext(ctx, msg, append(kvs, "err", err)...)
 }
@@ -44,16 +44,16 @@ func newPostStore(store *datastore) *postStore {
 
 func (s *postStore) Create(ctx context.Context, obj *model.Post) error {
 	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
-		slog.Error("Failed to insert post into database", "err", err, "post", obj)
-		return errorx.ErrDBWrite.WithMessage(err.Error())
+		slog.ErrorContext(ctx, "Failed to insert post into database", "err", err, "post", obj)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
 	}
 	return nil
 }
 
 func (s *postStore) Update(ctx context.Context, obj *model.Post) error {
 	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
-		slog.Error("Failed to update post in database", "err", err, "post", obj)
-		return errorx.ErrDBWrite.WithMessage(err.Error())
+		slog.ErrorContext(ctx, "Failed to update post in database", "err", err, "post", obj)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
 	}
 	return nil
 }
@@ -61,8 +61,8 @@ func (s *postStore) Update(ctx context.Context, obj *model.Post) error {
 func (s *postStore) Delete(ctx context.Context, opts *where.Options) error {
 	err := s.store.DB(ctx, opts).Delete(&model.Post{}).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		slog.Error("Failed to delete post from database", "err", err, "opts", opts)
-		return errorx.ErrDBWrite.WithMessage(err.Error())
+		slog.ErrorContext(ctx, "Failed to delete post from database", "err", err, "opts", opts)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
 	}
 	return nil
 }
@@ -72,10 +72,10 @@ func (s *postStore) Get(ctx context.Context, opts *where.Options) (*model.Post,
 	err := s.store.DB(ctx, opts).First(&post).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errorx.ErrPostNotFound.WithMessage(err.Error())
+			return nil, errorx.ErrPostNotFound.WithMessage("%v", err)
 		}
-		slog.Error("Failed to get post from database", "err", err, "opts", opts)
-		return nil, errorx.ErrDBRead.WithMessage(err.Error())
+		slog.ErrorContext(ctx, "Failed to get post from database", "err", err, "opts", opts)
+		return nil, errorx.ErrDBRead.WithMessage("%v", err)
 	}
 	return &post, nil
 }
@@ -87,16 +87,17 @@ func (s *postStore) List(ctx context.Context, opts *where.Options) (int64, []*mo
 		posts []*model.Post
 	)
 
-	baseDB := s.store.DB(ctx, opts).Model(&model.Post{})
-
-	if err := baseDB.Session(&gorm.Session{}).Count(&total).Error; err != nil {
-		slog.Error("Failed to count users", "err", err, "conditions", opts)
-		return 0, nil, errorx.ErrDBRead.WithMessage(err.Error())
+	// Count every matching record, regardless of the page being listed
+	if !opts.SkipCount {
+		if err := s.store.DB(ctx, opts.Unpaged()).Model(&model.Post{}).Count(&total).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to count posts", "err", err, "conditions", opts)
+			return 0, nil, errorx.ErrDBRead.WithMessage("%v", err)
+		}
 	}
 
-	if err := baseDB.Order("id desc").Find(&posts).Error; err != nil {
-		slog.Error("Failed to list users", "err", err, "conditions", opts)
-		return 0, nil, errorx.ErrDBRead.WithMessage(err.Error())
+	if err := s.store.DB(ctx, opts).Order("id desc").Find(&posts).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to list posts", "err", err, "conditions", opts)
+		return 0, nil, errorx.ErrDBRead.WithMessage("%v", err)
 	}
 
 	return total, posts, nil
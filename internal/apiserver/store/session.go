@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/model"
+	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"gorm.io/gorm"
+)
+
+type SessionStore interface {
+	Create(ctx context.Context, obj *model.Session) error
+	Update(ctx context.Context, obj *model.Session) error
+	Delete(ctx context.Context, opts *where.Options) error
+	Get(ctx context.Context, opts *where.Options) (*model.Session, error)
+	List(ctx context.Context, opts *where.Options) (int64, []*model.Session, error)
+
+	SessionExpansion
+}
+
+// SessionExpansion is an interface that defines additional methods for the SessionStore
+type SessionExpansion interface {
+	// Revoke marks every session matching opts as revoked
+	Revoke(ctx context.Context, opts *where.Options) error
+	// Rotate replaces the refresh token hash and the expiration of the session with those of
+	// obj, provided its refresh token hash is still previousHash and it is not revoked. It
+	// reports false when a concurrent rotation or a revocation changed the session first.
+	Rotate(ctx context.Context, obj *model.Session, previousHash string) (bool, error)
+}
+
+// sessionStore is a struct that implements the SessionStore interface
+type sessionStore struct {
+	// db instance
+	store *datastore
+}
+
+var _ SessionStore = (*sessionStore)(nil)
+
+func newSessionStore(store *datastore) *sessionStore {
+	return &sessionStore{
+		store: store,
+	}
+}
+
+func (s *sessionStore) Create(ctx context.Context, obj *model.Session) error {
+	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to insert session into database", "err", err, "sessionID", obj.SessionID)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
+	}
+	return nil
+}
+
+func (s *sessionStore) Update(ctx context.Context, obj *model.Session) error {
+	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to update session in database", "err", err, "sessionID", obj.SessionID)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
+	}
+	return nil
+}
+
+func (s *sessionStore) Delete(ctx context.Context, opts *where.Options) error {
+	err := s.store.DB(ctx, opts).Delete(new(model.Session)).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		slog.ErrorContext(ctx, "Failed to delete session from database", "err", err, "opts", opts)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
+	}
+	return nil
+}
+
+func (s *sessionStore) Get(ctx context.Context, opts *where.Options) (*model.Session, error) {
+	var obj model.Session
+	if err := s.store.DB(ctx, opts).First(&obj).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errorx.ErrSessionNotFound
+		}
+		slog.ErrorContext(ctx, "Failed to get session from database", "err", err, "opts", opts)
+		return nil, errorx.ErrDBRead.WithMessage("%v", err)
+	}
+	return &obj, nil
+}
+
+func (s *sessionStore) List(ctx context.Context, opts *where.Options) (int64, []*model.Session, error) {
+	var (
+		total    int64
+		sessions []*model.Session
+	)
+
+	baseDB := s.store.DB(ctx, opts).Model(&model.Session{})
+
+	if err := baseDB.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to count sessions", "err", err, "conditions", opts)
+		return 0, nil, errorx.ErrDBRead.WithMessage("%v", err)
+	}
+
+	if err := baseDB.Order("id desc").Find(&sessions).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to list sessions", "err", err, "conditions", opts)
+		return 0, nil, errorx.ErrDBRead.WithMessage("%v", err)
+	}
+
+	return total, sessions, nil
+}
+
+func (s *sessionStore) Revoke(ctx context.Context, opts *where.Options) error {
+	err := s.store.DB(ctx, opts).Model(&model.Session{}).
+		Where("revokedAt IS NULL").
+		Update("revokedAt", time.Now()).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke sessions", "err", err, "opts", opts)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
+	}
+	return nil
+}
+
+func (s *sessionStore) Rotate(ctx context.Context, obj *model.Session, previousHash string) (bool, error) {
+	result := s.store.DB(ctx).Model(&model.Session{}).
+		Where("id = ? AND refreshTokenHash = ? AND revokedAt IS NULL", obj.ID, previousHash).
+		Updates(map[string]any{
+			"refreshTokenHash":  obj.RefreshTokenHash,
+			"previousTokenHash": previousHash,
+			"expiresAt":         obj.ExpiresAt,
+		})
+	if result.Error != nil {
+		slog.ErrorContext(ctx, "Failed to rotate the refresh token of the session", "err", result.Error, "sessionID", obj.SessionID)
+		return false, errorx.ErrDBWrite.WithMessage("%v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"github.com/MortalSC/FastGO/internal/commonpkg/where"
@@ -17,9 +18,12 @@ var (
 type IStore interface {
 	DB(ctx context.Context, wheres ...where.Where) *gorm.DB
 	TX(ctx context.Context, fn func(ctx context.Context) error) error
+	// Ping checks that the database is reachable
+	Ping(ctx context.Context) error
 
 	User() UserStore
 	Post() PostStore
+	Session() SessionStore
 }
 
 // transactionKey is the key used to store the transaction in the context
@@ -44,6 +48,7 @@ func NewStore(db *gorm.DB) *datastore {
 
 // DB filters database instances based on the incoming conditions (wheres).
 // If no conditions are passed in, the database instance (transaction instance or core database instance) in the context is returned.
+// The statements run with ctx, which carries its deadline and its trace span.
 func (store *datastore) DB(ctx context.Context, wheres ...where.Where) *gorm.DB {
 	db := store.gormDBCore
 
@@ -51,6 +56,7 @@ func (store *datastore) DB(ctx context.Context, wheres ...where.Where) *gorm.DB
 	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
 		db = tx
 	}
+	db = db.WithContext(ctx)
 
 	// loop through the wheres and apply them to the db instance
 	for _, whr := range wheres {
@@ -71,6 +77,24 @@ func (store *datastore) TX(ctx context.Context, fn func(ctx context.Context) err
 	)
 }
 
+// Ping checks that the database is reachable
+func (store *datastore) Ping(ctx context.Context) error {
+	sqlDB, err := store.gormDBCore.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// isDuplicatedKey reports whether err violates a unique index, e.g. MySQL error 1062 or a sqlite
+// UNIQUE constraint, whether or not the errors of the database are translated by GORM
+func (store *datastore) isDuplicatedKey(err error) bool {
+	if translator, ok := store.gormDBCore.Dialector.(gorm.ErrorTranslator); ok {
+		err = translator.Translate(err)
+	}
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
 // Users returns an instance that implements the UserStore interface
 func (store *datastore) User() UserStore {
 	return newUserStore(store)
@@ -80,3 +104,8 @@ func (store *datastore) User() UserStore {
 func (store *datastore) Post() PostStore {
 	return newPostStore(store)
 }
+
+// Session returns an instance that implements the SessionStore interface
+func (store *datastore) Session() SessionStore {
+	return newSessionStore(store)
+}
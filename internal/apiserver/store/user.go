@@ -23,9 +23,8 @@ type UserStore interface {
 
 // UserExpansion is an interface that defines additional methods for the UserStore
 type UserExpansion interface {
-	// Add any additional methods for the UserStore here
-	// For example:
-	// GetByEmail(ctx context.Context, email string) (*model.User, error)
+	// GetByIDOrName returns the user whose userID or username equals idOrName
+	GetByIDOrName(ctx context.Context, idOrName string) (*model.User, error)
 }
 
 // userStore is a struct that implements the UserStore interface
@@ -44,16 +43,22 @@ func newUserStore(store *datastore) *userStore {
 
 func (s *userStore) Create(ctx context.Context, obj *model.User) error {
 	if err := s.store.DB(ctx).Create(obj).Error; err != nil {
-		slog.Error("Failed to insert user into database", "err", err, "user", obj)
-		return errorx.ErrDBWrite.WithMessage(err.Error())
+		if s.store.isDuplicatedKey(err) {
+			return errorx.ErrUserAlreadyExists
+		}
+		slog.ErrorContext(ctx, "Failed to insert user into database", "err", err, "user", obj)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
 	}
 	return nil
 }
 
 func (s *userStore) Update(ctx context.Context, obj *model.User) error {
 	if err := s.store.DB(ctx).Save(obj).Error; err != nil {
-		slog.Error("Failed to update user in database", "err", err, "user", obj)
-		return errorx.ErrDBWrite.WithMessage(err.Error())
+		if s.store.isDuplicatedKey(err) {
+			return errorx.ErrUserAlreadyExists
+		}
+		slog.ErrorContext(ctx, "Failed to update user in database", "err", err, "user", obj)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
 	}
 	return nil
 }
@@ -61,8 +66,8 @@ func (s *userStore) Update(ctx context.Context, obj *model.User) error {
 func (s *userStore) Delete(ctx context.Context, opts *where.Options) error {
 	err := s.store.DB(ctx, opts).Delete(new(model.User)).Error
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		slog.Error("Failed to delete user from database", "err", err, "opts", opts)
-		return errorx.ErrDBWrite.WithMessage(err.Error())
+		slog.ErrorContext(ctx, "Failed to delete user from database", "err", err, "opts", opts)
+		return errorx.ErrDBWrite.WithMessage("%v", err)
 	}
 	return nil
 }
@@ -70,11 +75,11 @@ func (s *userStore) Delete(ctx context.Context, opts *where.Options) error {
 func (s *userStore) Get(ctx context.Context, opts *where.Options) (*model.User, error) {
 	var obj model.User
 	if err := s.store.DB(ctx, opts).First(&obj).Error; err != nil {
-		slog.Error("Failed to get user from database", "err", err, "opts", opts)
+		slog.ErrorContext(ctx, "Failed to get user from database", "err", err, "opts", opts)
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errorx.ErrUserNotFound
 		}
-		return nil, errorx.ErrDBRead.WithMessage(err.Error())
+		return nil, errorx.ErrDBRead.WithMessage("%v", err)
 	}
 	return &obj, nil
 }
@@ -86,17 +91,22 @@ func (s *userStore) List(ctx context.Context, opts *where.Options) (int64, []*mo
 		users []*model.User
 	)
 
-	baseDB := s.store.DB(ctx, opts).Model(&model.User{})
-
-	if err := baseDB.Session(&gorm.Session{}).Count(&total).Error; err != nil {
-		slog.Error("Failed to count users", "err", err, "conditions", opts)
-		return 0, nil, errorx.ErrDBRead.WithMessage(err.Error())
+	// Count every matching record, regardless of the page being listed
+	if !opts.SkipCount {
+		if err := s.store.DB(ctx, opts.Unpaged()).Model(&model.User{}).Count(&total).Error; err != nil {
+			slog.ErrorContext(ctx, "Failed to count users", "err", err, "conditions", opts)
+			return 0, nil, errorx.ErrDBRead.WithMessage("%v", err)
+		}
 	}
 
-	if err := baseDB.Order("id desc").Find(&users).Error; err != nil {
-		slog.Error("Failed to list users", "err", err, "conditions", opts)
-		return 0, nil, errorx.ErrDBRead.WithMessage(err.Error())
+	if err := s.store.DB(ctx, opts).Order("id desc").Find(&users).Error; err != nil {
+		slog.ErrorContext(ctx, "Failed to list users", "err", err, "conditions", opts)
+		return 0, nil, errorx.ErrDBRead.WithMessage("%v", err)
 	}
 
 	return total, users, nil
 }
+
+func (s *userStore) GetByIDOrName(ctx context.Context, idOrName string) (*model.User, error) {
+	return s.Get(ctx, where.NewWhere().Q("userID = ? OR username = ?", idOrName, idOrName))
+}
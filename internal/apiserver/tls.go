@@ -0,0 +1,65 @@
+package apiserver
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/tlsx"
+)
+
+// NewTLSConfig creates the TLS configuration of the HTTPS and gRPC servers, or returns nil
+// when TLS is disabled. The certificates are reloaded when they change on disk
+func (cfg *Config) NewTLSConfig() (*tls.Config, error) {
+	opts := cfg.TLSOptions
+	if !opts.Enabled() {
+		return nil, nil
+	}
+
+	minVersion, err := opts.Version()
+	if err != nil {
+		return nil, err
+	}
+	ciphers, err := opts.Ciphers()
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := opts.ClientAuthType()
+	if err != nil {
+		return nil, err
+	}
+
+	reloader, err := tlsx.NewReloader(opts.CertFile, opts.KeyFile, opts.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader.Config(&tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: ciphers,
+		ClientAuth:   clientAuth,
+		// HTTP/2 is required by gRPC clients
+		NextProtos: []string{"h2", "http/1.1"},
+	}), nil
+}
+
+// newRedirectServer creates the plain HTTP server redirecting every request to the HTTPS address
+func (cfg *Config) newRedirectServer() *http.Server {
+	_, port, _ := net.SplitHostPort(cfg.Addr)
+
+	return &http.Server{
+		Addr: cfg.TLSOptions.RedirectAddr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host := r.Host
+			if h, _, err := net.SplitHostPort(r.Host); err == nil {
+				host = h
+			}
+			if port != "443" {
+				host = net.JoinHostPort(host, port)
+			}
+
+			target := "https://" + host + r.URL.RequestURI()
+			http.Redirect(w, r, target, http.StatusPermanentRedirect)
+		}),
+	}
+}
@@ -0,0 +1,55 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/model"
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/pkg/known"
+	"github.com/MortalSC/FastGO/internal/pkg/validation"
+	apiv1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/jinzhu/copier"
+	"gorm.io/gorm"
+)
+
+// CreateUser creates a user with role in the database, which is how the first administrator
+// is created since the sign up only creates users. The request is validated and the password
+// hashed like those of the sign up, and the schema must be up to date.
+func (cfg *Config) CreateUser(ctx context.Context, db *gorm.DB, req *apiv1.CreateUserRequest, role string) (string, error) {
+	if role != known.RoleAdmin && role != known.RoleUser {
+		return "", fmt.Errorf("invalid role '%s', must be one of: %s, %s", role, known.RoleAdmin, known.RoleUser)
+	}
+
+	if err := cfg.prepareSchema(db); err != nil {
+		return "", err
+	}
+	migrator, err := cfg.NewMigrator(db)
+	if err != nil {
+		return "", err
+	}
+	if err := migrator.EnsureUpToDate(ctx); err != nil {
+		return "", err
+	}
+
+	passwords, err := cfg.newPasswords()
+	if err != nil {
+		return "", err
+	}
+	store := store.NewStore(db)
+	if err := validation.NewValidation(store, passwords.policy).ValidateCreateUserRequest(ctx, req); err != nil {
+		return "", err
+	}
+
+	var userM model.User
+	_ = copier.Copy(&userM, req)
+	userM.Role = role
+	if userM.Password, err = passwords.hasher.Hash(req.Password); err != nil {
+		return "", fmt.Errorf("failed to hash the password: %w", err)
+	}
+	if err := store.User().Create(ctx, &userM); err != nil {
+		return "", err
+	}
+
+	return userM.UserID, nil
+}
@@ -3,8 +3,7 @@ package authn
 import (
 	"context"
 
-	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/MortalSC/FastGO/pkg/token"
 )
 
 // IToken defines methods to implement a generic token
@@ -16,6 +15,12 @@ type IToken interface {
 	// Get token expiration timestamp
 	GetExpireAt() int64
 
+	// GetRefreshToken returns the opaque token used to obtain a new token
+	GetRefreshToken() string
+
+	// Get refresh token expiration timestamp
+	GetRefreshExpireAt() int64
+
 	EncodeToJSON() ([]byte, error)
 }
 
@@ -24,23 +29,18 @@ type AuthenTicator interface {
 	// Sign is used to generate a tekon
 	Sign(ctx context.Context, userID string) (IToken, error)
 
+	// Refresh exchanges a refresh token for a new token, the refresh token can only be used once
+	Refresh(ctx context.Context, refreshToken string) (IToken, error)
+
 	// Destroy is used to destroy a taken
 	Destroy(ctx context.Context, accessToken string) error
 
-	// ParseClaims parse the token and return the claims
-	ParseClaims(ctx context.Context, accessToken string) (*jwt.RegisteredClaims, error)
+	// DestroyAll destroys every token issued to the user
+	DestroyAll(ctx context.Context, userID string) error
+
+	// ParseClaims parse the token and return the claims, revoked tokens are rejected
+	ParseClaims(ctx context.Context, accessToken string) (*token.Claims, error)
 
 	// Release used to release the requested resource
 	Release() error
 }
-
-// Encrypt encrypts the plain text with bcrypt
-func Encrypt(source string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(source), bcrypt.DefaultCost)
-	return string(hashedBytes), err
-}
-
-// Compare compares the encrypted text with the plain text if it's the same.
-func Compare(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-}
@@ -2,12 +2,17 @@ package core
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/MortalSC/FastGO/internal/commonpkg/errorx"
 	"github.com/gin-gonic/gin"
 )
 
+// requestIDHeader is the response header the request ID middleware writes the request ID to.
+const requestIDHeader = "X-Request-ID"
+
 // Validator is a type of validation function used to validate bound data structures.
 type Validator[T any] func(context.Context, *T) error
 
@@ -19,10 +24,13 @@ type Handler[T any, R any] func(ctx context.Context, req *T) (R, error)
 
 // ErrorResponse defines the structure of the error response
 // Used to return a unified formatting error message when an error occurs in the API request.
+// Code repeats the HTTP status code, Reason is the stable reason of the error, and Metadata
+// carries its details along with the X-Request-ID of the request.
 type ErrorResponse struct {
-	Reason   string            `json:"reason",omitempty`
-	Message  string            `json:"message",omitempty`
-	Metadata map[string]string `json:"metadata",omitempty`
+	Code     int               `json:"code,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // HandleJSONRequest is a shortcut function for handling JSON requests.
@@ -40,6 +48,12 @@ func HandleUriRequest[T any, R any](c *gin.Context, handler Handler[T, R], valid
 	HandleRequest(c, c.ShouldBindUri, handler, validators...)
 }
 
+// HandleAllRequest is a shortcut function for handling requests whose fields come from the URI,
+// the query parameters and the JSON body at once.
+func HandleAllRequest[T any, R any](c *gin.Context, handler Handler[T, R], validators ...Validator[T]) {
+	HandleRequest(c, BindAll(c), handler, validators...)
+}
+
 // HandleRequest is a common request processing function.
 // Be responsible for binding request data, performing verification, and calling the actual business processing logic functions.
 func HandleRequest[T any, R any](c *gin.Context, binder Binder, handler Handler[T, R], validators ...Validator[T]) {
@@ -68,6 +82,34 @@ func ShouldBindUri[T any](c *gin.Context, req *T, validatros ...Validator[T]) er
 	return ReadRequest(c, req, c.ShouldBindUri, validatros...)
 }
 
+func ShouldBindAll[T any](c *gin.Context, req *T, validatros ...Validator[T]) error {
+	return ReadRequest(c, req, BindAll(c), validatros...)
+}
+
+// BindAll returns a binder which binds the JSON body, then the query parameters and finally
+// the URI parameters, so the path takes precedence over the query, and the query over the body.
+// An empty body, or a request without query or URI parameters, is not an error.
+func BindAll(c *gin.Context) Binder {
+	return func(obj any) error {
+		if c.Request.Body != nil && c.Request.Body != http.NoBody && c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(obj); err != nil && !errors.Is(err, io.EOF) {
+				return err
+			}
+		}
+		if c.Request.URL.RawQuery != "" {
+			if err := c.ShouldBindQuery(obj); err != nil {
+				return err
+			}
+		}
+		if len(c.Params) > 0 {
+			if err := c.ShouldBindUri(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 // ReadRequest is a common utility function used for binding and verifying request data.
 // - It is responsible for calling the binding function to bind the request data.
 // - If the target type implements the Default interface, its Default method will be called to set the default value.
@@ -75,7 +117,7 @@ func ShouldBindUri[T any](c *gin.Context, req *T, validatros ...Validator[T]) er
 func ReadRequest[T any](c *gin.Context, req *T, binder Binder, validators ...Validator[T]) error {
 	// Call the binding function to bind the request data
 	if err := binder(req); err != nil {
-		return errorx.ErrBind.WithMessage(err.Error())
+		return errorx.ErrBind.WithMessage("%v", err)
 	}
 
 	if defaulter, ok := any(req).(interface{ Default() }); ok {
@@ -93,10 +135,15 @@ func ReadRequest[T any](c *gin.Context, req *T, binder Binder, validators ...Val
 	return nil
 }
 
+// WriteResponse writes data as the JSON response, or the ErrorResponse of err if it is not nil.
 func WriteResponse(c *gin.Context, data any, err error) {
 	if err != nil {
 		errx := errorx.FromError(err)
+		if requestID := c.Writer.Header().Get(requestIDHeader); requestID != "" {
+			errx = errx.WithRequestID(requestID)
+		}
 		c.JSON(errx.Code, ErrorResponse{
+			Code:     errx.Code,
 			Reason:   errx.Reason,
 			Message:  errx.Message,
 			Metadata: errx.Metadata,
@@ -3,6 +3,7 @@ package errorx
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 
 	httpstatus "github.com/go-kratos/kratos/v2/transport/http/status"
@@ -10,18 +11,24 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// ErrorX is the error model shared by every layer of the services.
+// Code is the HTTP status code, mapped to a gRPC code by GRPCStatus, and Reason is a stable
+// machine readable reason which clients may rely on. Errors are compared by code and reason.
+//
+// The predefined errors are shared, so the With* methods and KV return a modified copy and
+// never change the receiver.
 type ErrorX struct {
-	Code     int               `json:"code",omitempty`
-	Reason   string            `json:"reason",omitempty`
-	Message  string            `json:"message",omitempty`
-	Metadata map[string]string `json:"metadata",omitempty`
+	Code     int               `json:"code,omitempty"`
+	Reason   string            `json:"reason,omitempty"`
+	Message  string            `json:"message,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 func New(code int, reason string, format string, args ...any) *ErrorX {
 	return &ErrorX{
 		Code:    code,
 		Reason:  reason,
-		Message: format,
+		Message: fmt.Sprintf(format, args...),
 	}
 }
 
@@ -29,27 +36,37 @@ func (err *ErrorX) Error() string {
 	return fmt.Sprintf("error: code: %d reason: %s, message: %s, metadata=%v", err.Code, err.Reason, err.Message, err.Metadata)
 }
 
+// clone returns a copy of the error which does not share its metadata
+func (err *ErrorX) clone() *ErrorX {
+	cp := *err
+	cp.Metadata = maps.Clone(err.Metadata)
+	return &cp
+}
+
 func (err *ErrorX) WithMessage(format string, args ...any) *ErrorX {
-	err.Message = fmt.Sprintf(format, args...)
-	return err
+	cp := err.clone()
+	cp.Message = fmt.Sprintf(format, args...)
+	return cp
 }
 
 func (err *ErrorX) WithMetadata(md map[string]string) *ErrorX {
-	err.Metadata = md
-	return err
+	cp := err.clone()
+	cp.Metadata = maps.Clone(md)
+	return cp
 }
 
 func (err *ErrorX) KV(kvs ...string) *ErrorX {
-	if err.Metadata == nil {
-		err.Metadata = make(map[string]string)
+	cp := err.clone()
+	if cp.Metadata == nil {
+		cp.Metadata = make(map[string]string)
 	}
 
 	for i := 0; i < len(kvs); i += 2 {
 		if i+1 < len(kvs) {
-			err.Metadata[kvs[i]] = kvs[i+1]
+			cp.Metadata[kvs[i]] = kvs[i+1]
 		}
 	}
-	return err
+	return cp
 }
 
 func (err *ErrorX) GRPCStatus() *status.Status {
@@ -106,12 +123,12 @@ func FromError(err error) *ErrorX {
 	// 则返回一个带有默认值的 ErrorX，表示是一个未知类型的错误.
 	gs, ok := status.FromError(err)
 	if !ok {
-		return New(ErrInternal.Code, ErrInternal.Reason, err.Error())
+		return New(ErrInternal.Code, ErrInternal.Reason, "%v", err)
 	}
 
 	// 如果 err 是 gRPC 的错误类型，会成功返回一个 gRPC status 对象（gs）.
 	// 使用 gRPC 状态中的错误代码和消息创建一个 ErrorX.
-	ret := New(httpstatus.FromGRPCCode(gs.Code()), ErrInternal.Reason, gs.Message())
+	ret := New(httpstatus.FromGRPCCode(gs.Code()), ErrInternal.Reason, "%s", gs.Message())
 
 	// 遍历 gRPC 错误详情中的所有附加信息（Details）.
 	for _, detail := range gs.Details() {
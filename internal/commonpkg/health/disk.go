@@ -0,0 +1,21 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiskSpace checks that the file system holding path has at least minFree bytes available
+// to unprivileged users, e.g. for the log file. It always passes on platforms without statfs.
+func DiskSpace(name, path string, minFree uint64) Checker {
+	return NewChecker(name, func(context.Context) error {
+		free, err := freeSpace(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%d MiB available in %s, below the %d MiB required", free>>20, path, minFree>>20)
+		}
+		return nil
+	})
+}
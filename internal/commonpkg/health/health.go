@@ -0,0 +1,165 @@
+// Package health reports the liveness and the readiness of a server from pluggable checks.
+//
+// Liveness tells whether the process works at all, a failing liveness gets it restarted.
+// Readiness tells whether it can serve requests, which also requires its dependencies,
+// such as the database, and fails as soon as the server starts shutting down so that load
+// balancers stop routing requests to it.
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the outcome of a check, or of all checks
+type Status string
+
+const (
+	StatusOK     Status = "ok"
+	StatusFailed Status = "failed"
+)
+
+// ErrShuttingDown fails the readiness of a server which is shutting down
+var ErrShuttingDown = errors.New("the server is shutting down")
+
+// Checker checks a single aspect of the health of the server
+type Checker interface {
+	// Name identifies the check in the results
+	Name() string
+	// Check returns nil if healthy, it must return once ctx is done
+	Check(ctx context.Context) error
+}
+
+type checkerFunc struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c checkerFunc) Name() string { return c.name }
+
+func (c checkerFunc) Check(ctx context.Context) error { return c.check(ctx) }
+
+// NewChecker creates a checker named name running check
+func NewChecker(name string, check func(ctx context.Context) error) Checker {
+	return checkerFunc{name: name, check: check}
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// Error explains why the check failed
+	Error string `json:"error,omitempty"`
+	// Duration is how long the check took, e.g. 1.5ms
+	Duration string `json:"duration"`
+}
+
+// Result is the outcome of the checks of a probe, it is failed if any check failed
+type Result struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// OK reports whether every check passed
+func (r *Result) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Health holds the liveness and the readiness checks of a server, it is safe for concurrent use
+type Health struct {
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []Checker
+	readiness []Checker
+
+	shuttingDown atomic.Bool
+}
+
+// New creates a Health whose checks are cancelled after timeout
+func New(timeout time.Duration) *Health {
+	return &Health{timeout: timeout}
+}
+
+// AddLivenessChecks adds checks to the liveness, which is part of the readiness too
+func (h *Health) AddLivenessChecks(checkers ...Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, checkers...)
+}
+
+// AddReadinessChecks adds checks to the readiness only
+func (h *Health) AddReadinessChecks(checkers ...Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, checkers...)
+}
+
+// ShutDown marks the server as shutting down, which fails its readiness from now on
+func (h *Health) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Live runs the liveness checks
+func (h *Health) Live(ctx context.Context) *Result {
+	h.mu.RLock()
+	checkers := h.liveness
+	h.mu.RUnlock()
+
+	return h.run(ctx, checkers)
+}
+
+// Ready runs the liveness and the readiness checks, and fails once the server is shutting down
+func (h *Health) Ready(ctx context.Context) *Result {
+	h.mu.RLock()
+	checkers := make([]Checker, 0, len(h.liveness)+len(h.readiness)+1)
+	checkers = append(checkers, NewChecker("shutdown", func(context.Context) error {
+		if h.shuttingDown.Load() {
+			return ErrShuttingDown
+		}
+		return nil
+	}))
+	checkers = append(checkers, h.liveness...)
+	checkers = append(checkers, h.readiness...)
+	h.mu.RUnlock()
+
+	return h.run(ctx, checkers)
+}
+
+// run runs the checks concurrently and collects their results in order
+func (h *Health) run(ctx context.Context, checkers []Checker) *Result {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	result := &Result{Status: StatusOK, Checks: make([]CheckResult, len(checkers))}
+
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := checker.Check(ctx)
+			check := CheckResult{Name: checker.Name(), Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				check.Status, check.Error = StatusFailed, err.Error()
+			}
+			result.Checks[i] = check
+		}()
+	}
+	wg.Wait()
+
+	for _, check := range result.Checks {
+		if check.Status != StatusOK {
+			result.Status = StatusFailed
+		}
+	}
+	return result
+}
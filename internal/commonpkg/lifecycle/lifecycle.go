@@ -0,0 +1,155 @@
+// Package lifecycle starts and stops the components of a server in order.
+//
+// Components are started in the order their hooks were appended and stopped in the
+// reverse order, so that a component is stopped before the components it depends on.
+// Long-running work, such as the serving loop of a listener, runs through Go, whose
+// failure is reported to the owner of the Lifecycle instead of exiting the process.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Hook starts and stops a component, both functions are optional
+type Hook struct {
+	// Name identifies the component in the logs and the errors
+	Name string
+	// OnStart starts the component, it must not block: long-running work is run with Lifecycle.Go
+	OnStart func(ctx context.Context) error
+	// OnStop stops the component, it must return once ctx is done
+	OnStop func(ctx context.Context) error
+}
+
+// Lifecycle runs the hooks of the components of a server
+type Lifecycle struct {
+	mu    sync.Mutex
+	hooks []Hook
+	// started is the number of hooks whose OnStart succeeded
+	started int
+
+	failed chan error
+}
+
+// New creates an empty Lifecycle
+func New() *Lifecycle {
+	return &Lifecycle{failed: make(chan error, 1)}
+}
+
+// Append adds hooks, which start after and stop before the hooks already appended
+func (l *Lifecycle) Append(hooks ...Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hooks...)
+}
+
+// Start runs the OnStart hooks in order
+// If one fails, the components already started are stopped and the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.started < len(l.hooks) {
+		hook := l.hooks[l.started]
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				return errors.Join(fmt.Errorf("start %s: %w", hook.Name, err), l.stop(ctx))
+			}
+		}
+		l.started++
+	}
+	return nil
+}
+
+// Stop runs the OnStop hooks of the started components in the reverse order
+// A failing hook does not prevent the next ones from running, their errors are joined.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.stop(ctx)
+}
+
+func (l *Lifecycle) stop(ctx context.Context) error {
+	var errs []error
+	for ; l.started > 0; l.started-- {
+		if err := stopHook(ctx, l.hooks[l.started-1]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stopHook runs the OnStop hook of a started component, if any, and logs its outcome
+func stopHook(ctx context.Context, hook Hook) error {
+	if hook.OnStop == nil {
+		return nil
+	}
+
+	start := time.Now()
+	if err := hook.OnStop(ctx); err != nil {
+		slog.Error("Failed to stop component", "component", hook.Name, "err", err)
+		return fmt.Errorf("stop %s: %w", hook.Name, err)
+	}
+	slog.Info("Stopped component", "component", hook.Name, "duration", time.Since(start))
+	return nil
+}
+
+// Group combines independent hooks into one, which starts them in order and stops them
+// concurrently, so that they share the time given to stop the group instead of taking it in turn.
+// If one fails to start, the hooks already started are stopped and the error is returned.
+func Group(name string, hooks ...Hook) Hook {
+	var started []Hook
+	stop := func(ctx context.Context) error {
+		errs := make([]error, len(started))
+		var wg sync.WaitGroup
+		for i, hook := range started {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = stopHook(ctx, hook)
+			}()
+		}
+		wg.Wait()
+		started = nil
+		return errors.Join(errs...)
+	}
+
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			for _, hook := range hooks {
+				if hook.OnStart != nil {
+					if err := hook.OnStart(ctx); err != nil {
+						return errors.Join(fmt.Errorf("start %s: %w", hook.Name, err), stop(ctx))
+					}
+				}
+				started = append(started, hook)
+			}
+			return nil
+		},
+		OnStop: stop,
+	}
+}
+
+// Go runs fn in a goroutine, an error it returns is reported by Failed
+func (l *Lifecycle) Go(name string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			select {
+			case l.failed <- fmt.Errorf("%s: %w", name, err):
+			default:
+				// Only the first failure is reported, the others are logged
+				slog.Error("Component failed", "component", name, "err", err)
+			}
+		}
+	}()
+}
+
+// Failed returns a channel receiving the first error of the functions run by Go
+func (l *Lifecycle) Failed() <-chan error {
+	return l.failed
+}
@@ -0,0 +1,84 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the starts and stops of the hooks it creates
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// hook creates a hook whose OnStop blocks until ctx is done
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			<-ctx.Done()
+			r.record("stop " + name)
+			return ctx.Err()
+		},
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	var r recorder
+	lc := New()
+	lc.Append(r.hook("database", nil), r.hook("server", nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	require.NoError(t, lc.Start(ctx))
+	err := lc.Stop(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorContains(t, err, "stop database")
+	assert.Equal(t, []string{"start database", "start server", "stop server", "stop database"}, r.events)
+
+	// The hooks are stopped once
+	assert.NoError(t, lc.Stop(ctx))
+}
+
+func TestGroup(t *testing.T) {
+	var r recorder
+	group := Group("listeners", r.hook("grpc", nil), r.hook("http", nil), r.hook("metrics", nil))
+	require.NoError(t, group.OnStart(context.Background()))
+	assert.Equal(t, []string{"start grpc", "start http", "start metrics"}, r.events)
+
+	// The hooks share the deadline of the group instead of waiting for it in turn
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := group.OnStop(ctx)
+	assert.Less(t, time.Since(start), 250*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ElementsMatch(t, []string{"stop grpc", "stop http", "stop metrics"}, r.events[3:])
+
+	// A failed start stops the hooks already started
+	r = recorder{}
+	busy := errors.New("address already in use")
+	group = Group("listeners", r.hook("grpc", nil), r.hook("http", busy), r.hook("metrics", nil))
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = group.OnStart(ctx)
+	assert.ErrorIs(t, err, busy)
+	assert.ErrorContains(t, err, "start http")
+	assert.Equal(t, []string{"start grpc", "start http", "stop grpc"}, r.events)
+}
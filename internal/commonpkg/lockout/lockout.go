@@ -0,0 +1,137 @@
+// Package lockout locks keys, such as accounts or client IPs, after repeated failures.
+//
+// Every attempt of a key is recorded before it is made, and counts as a failure until it ends,
+// so that parallel attempts cannot exceed MaxFailures. Once MaxFailures consecutive failures
+// are reached the key is locked for Duration, which doubles with every further failure up to
+// MaxDuration. Failures are forgotten after a success, or once Window has elapsed since the
+// last failure or the end of the lockout. The states are kept by a Store: MemoryStore locks
+// the keys in a single process, a shared Store locks them in all the replicas.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Policy decides when and for how long a key is locked
+type Policy struct {
+	// MaxFailures is the number of consecutive failures locking the key, 0 never locks it
+	MaxFailures int
+	// Duration is the lockout after MaxFailures failures
+	Duration time.Duration
+	// MaxDuration caps the lockout, which doubles with every further failure
+	MaxDuration time.Duration
+	// Window is how long the failures are remembered after the last failure or the end of the lockout
+	Window time.Duration
+}
+
+// lockDuration returns how long a key is locked after failures consecutive failures
+func (p Policy) lockDuration(failures int) time.Duration {
+	if p.MaxFailures <= 0 || failures < p.MaxFailures {
+		return 0
+	}
+
+	d := p.Duration
+	for i := p.MaxFailures; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDuration)
+}
+
+// State is the record of the failures of a key
+type State struct {
+	// Failures is the number of consecutive failures
+	Failures int
+	// LastFailure is the time of the last failure
+	LastFailure time.Time
+	// LockedUntil is the end of the lockout, zero if never locked
+	LockedUntil time.Time
+	// Pending is the number of attempts which have not ended yet
+	Pending int
+	// LastAttempt is the time of the last attempt
+	LastAttempt time.Time
+}
+
+// Locked reports whether the key is locked at now
+func (s State) Locked(now time.Time) bool {
+	return now.Before(s.LockedUntil)
+}
+
+// RetryAfter returns the time left until the lockout ends at now
+func (s State) RetryAfter(now time.Time) time.Duration {
+	return max(s.LockedUntil.Sub(now), 0)
+}
+
+// Expired reports whether the failures are forgotten at now
+// The window starts at the last failure, the end of the lockout or the last attempt, whichever
+// is the latest, so that the failures locking a key outlive its lockout and the backoff keeps
+// doubling, and that the attempts which never ended are forgotten too.
+func (s State) Expired(policy Policy, now time.Time) bool {
+	last := s.LastFailure
+	for _, t := range []time.Time{s.LockedUntil, s.LastAttempt} {
+		if t.After(last) {
+			last = t
+		}
+	}
+	return !s.Locked(now) && now.Sub(last) >= policy.Window
+}
+
+// Attempt records an attempt at now, unless the key is locked or the pending attempts would
+// lock it if they failed, and reports whether the attempt is allowed
+// It is exported for the Store implementations.
+func (s State) Attempt(policy Policy, now time.Time) (State, bool) {
+	if s.Expired(policy, now) {
+		s = State{}
+	}
+
+	if s.Locked(now) {
+		return s, false
+	}
+	// Once the failures lock the key, the attempts after the lockout are allowed one at a time
+	if policy.MaxFailures > 0 && s.Pending > 0 && s.Failures+s.Pending >= policy.MaxFailures {
+		return s, false
+	}
+
+	s.Pending++
+	s.LastAttempt = now
+	return s, true
+}
+
+// Fail records a failure at now and locks the key according to policy
+// It ends a pending attempt, if any. It is exported for the Store implementations.
+func (s State) Fail(policy Policy, now time.Time) State {
+	if s.Expired(policy, now) {
+		s = State{}
+	}
+
+	s.Failures++
+	s.Pending = max(s.Pending-1, 0)
+	s.LastFailure = now
+	if d := policy.lockDuration(s.Failures); d > 0 {
+		s.LockedUntil = now.Add(d)
+	}
+	return s
+}
+
+// Release ends a pending attempt which did not fail
+// It is exported for the Store implementations.
+func (s State) Release() State {
+	s.Pending = max(s.Pending-1, 0)
+	return s
+}
+
+// Store keeps the states of the keys
+// Implementations sharing the states between replicas must record the attempts and the
+// failures atomically, e.g. with State.Attempt and State.Fail in a transaction.
+type Store interface {
+	// Get returns the state of key, which is empty if it has no failures
+	Get(ctx context.Context, key string, policy Policy) (State, error)
+	// Attempt records an attempt of key and reports whether it is allowed, along with the new state
+	Attempt(ctx context.Context, key string, policy Policy) (State, bool, error)
+	// Fail records a failure of key, which ends an attempt, and returns its new state
+	Fail(ctx context.Context, key string, policy Policy) (State, error)
+	// Release ends an attempt of key which did not fail
+	Release(ctx context.Context, key string, policy Policy) error
+	// Reset forgets the failures of key, which unlocks it
+	Reset(ctx context.Context, key string) error
+}
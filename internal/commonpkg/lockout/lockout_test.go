@@ -0,0 +1,151 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockDuration(t *testing.T) {
+	policy := Policy{MaxFailures: 3, Duration: time.Minute, MaxDuration: 5 * time.Minute}
+	for failures, want := range map[int]time.Duration{
+		0: 0,
+		2: 0,
+		3: time.Minute,
+		4: 2 * time.Minute,
+		5: 4 * time.Minute,
+		6: 5 * time.Minute,
+		9: 5 * time.Minute,
+	} {
+		assert.Equal(t, want, policy.lockDuration(failures), "%d failures", failures)
+	}
+
+	assert.Zero(t, Policy{Duration: time.Minute, MaxDuration: time.Hour}.lockDuration(100))
+}
+
+func TestFail(t *testing.T) {
+	policy := Policy{MaxFailures: 2, Duration: 10 * time.Minute, MaxDuration: time.Hour, Window: 5 * time.Minute}
+	now := time.Now()
+
+	var s State
+	s = s.Fail(policy, now)
+	assert.False(t, s.Locked(now))
+	s = s.Fail(policy, now)
+	require.True(t, s.Locked(now))
+	assert.Equal(t, 10*time.Minute, s.RetryAfter(now))
+
+	// The lockout is longer than the window, the failures are remembered after it ends
+	now = now.Add(10*time.Minute + time.Second)
+	assert.False(t, s.Locked(now))
+	assert.False(t, s.Expired(policy, now))
+	s = s.Fail(policy, now)
+	assert.Equal(t, 3, s.Failures)
+	assert.Equal(t, 20*time.Minute, s.RetryAfter(now))
+
+	// They are forgotten once the window has elapsed since the end of the lockout
+	now = s.LockedUntil.Add(policy.Window)
+	assert.True(t, s.Expired(policy, now))
+	s = s.Fail(policy, now)
+	assert.Equal(t, 1, s.Failures)
+	assert.False(t, s.Locked(now))
+}
+
+func TestAttempt(t *testing.T) {
+	policy := Policy{MaxFailures: 3, Duration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute}
+	now := time.Now()
+
+	// The pending attempts count as failures
+	var s State
+	var ok bool
+	for range 3 {
+		s, ok = s.Attempt(policy, now)
+		require.True(t, ok)
+	}
+	_, ok = s.Attempt(policy, now)
+	assert.False(t, ok)
+
+	// Ended attempts free their place
+	s = s.Release()
+	s, ok = s.Attempt(policy, now)
+	require.True(t, ok)
+
+	for range 3 {
+		s = s.Fail(policy, now)
+	}
+	assert.Zero(t, s.Pending)
+	require.True(t, s.Locked(now))
+	_, ok = s.Attempt(policy, now)
+	assert.False(t, ok)
+
+	// After the lockout the attempts are allowed one at a time
+	now = s.LockedUntil
+	s, ok = s.Attempt(policy, now)
+	require.True(t, ok)
+	_, ok = s.Attempt(policy, now)
+	assert.False(t, ok)
+
+	// The attempts which never ended are forgotten after the window
+	now = now.Add(policy.Window)
+	s, ok = s.Attempt(policy, now)
+	require.True(t, ok)
+	assert.Equal(t, State{Pending: 1, LastAttempt: now}, s)
+
+	// Policies without MaxFailures allow every attempt
+	s = State{}
+	for range 10 {
+		s, ok = s.Attempt(Policy{Window: time.Minute}, now)
+		require.True(t, ok)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	policy := Policy{MaxFailures: 5, Duration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute}
+	store := NewMemoryStore()
+
+	// Parallel attempts do not exceed MaxFailures
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := store.Attempt(ctx, "key", policy)
+			assert.NoError(t, err)
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 5, allowed.Load())
+
+	for range 5 {
+		_, err := store.Fail(ctx, "key", policy)
+		require.NoError(t, err)
+	}
+	state, err := store.Get(ctx, "key", policy)
+	require.NoError(t, err)
+	assert.True(t, state.Locked(time.Now()))
+	assert.Equal(t, 5, state.Failures)
+	assert.Zero(t, state.Pending)
+
+	// Other keys are not locked
+	_, ok, err := store.Attempt(ctx, "other", policy)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, store.Release(ctx, "other", policy))
+	state, err = store.Get(ctx, "other", policy)
+	require.NoError(t, err)
+	assert.Zero(t, state.Pending)
+
+	require.NoError(t, store.Reset(ctx, "key"))
+	state, err = store.Get(ctx, "key", policy)
+	require.NoError(t, err)
+	assert.Equal(t, State{}, state)
+}
@@ -0,0 +1,107 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the expired states are removed from a MemoryStore
+const sweepInterval = time.Minute
+
+// memoryState is a state along with the policy it was recorded with
+type memoryState struct {
+	State
+	policy Policy
+}
+
+// MemoryStore keeps the states in the memory of the process
+type MemoryStore struct {
+	mu        sync.Mutex
+	states    map[string]memoryState
+	lastSweep time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		states:    make(map[string]memoryState),
+		lastSweep: time.Now(),
+	}
+}
+
+// Get returns the state of key
+func (s *MemoryStore) Get(_ context.Context, key string, policy Policy) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.states[key]
+	if !ok || st.Expired(policy, time.Now()) {
+		return State{}, nil
+	}
+	return st.State, nil
+}
+
+// Attempt records an attempt of key and reports whether it is allowed
+func (s *MemoryStore) Attempt(_ context.Context, key string, policy Policy) (State, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	st, ok := s.states[key].Attempt(policy, now)
+	s.states[key] = memoryState{State: st, policy: policy}
+	return st, ok, nil
+}
+
+// Fail records a failure of key
+func (s *MemoryStore) Fail(_ context.Context, key string, policy Policy) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	st := s.states[key].Fail(policy, now)
+	s.states[key] = memoryState{State: st, policy: policy}
+	return st, nil
+}
+
+// Release ends an attempt of key which did not fail
+func (s *MemoryStore) Release(_ context.Context, key string, _ Policy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if st, ok := s.states[key]; ok {
+		st.State = st.Release()
+		s.states[key] = st
+	}
+	return nil
+}
+
+// Reset forgets the failures of key
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, key)
+	return nil
+}
+
+// sweep removes the states whose failures are forgotten
+// This bounds the memory to the keys which failed during the last windows.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, st := range s.states {
+		if st.Expired(st.policy, now) {
+			delete(s.states, key)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Package migrate applies ordered, checksummed SQL migration files to a GORM database
+// and records the applied versions in a bookkeeping table.
+//
+// Migration files are named "<version>_<name>.up.sql" and "<version>_<name>.down.sql",
+// where version is a positive integer. Statements inside a file are separated by a
+// semicolon at the end of a line.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TableName is the name of the bookkeeping table which stores the applied migrations
+const TableName = "schema_migrations"
+
+var (
+	// fileRegexp matches migration file names, e.g. "0001_init.up.sql"
+	fileRegexp = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_\-]+)\.(up|down)\.sql$`)
+	// nameRegexp matches valid migration names
+	nameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+	// ErrChecksumMismatch means an applied migration file was modified afterwards
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+	// ErrPending means the database schema is behind the migration files
+	ErrPending = errors.New("pending migrations exist")
+)
+
+// Migration is a single versioned schema change
+type Migration struct {
+	Version  uint64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status describes whether a migration has been applied
+type Status struct {
+	Version   uint64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Modified reports that the file differs from the applied version
+	Modified bool
+}
+
+// record is a row of the bookkeeping table
+type record struct {
+	Version   uint64    `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name;type:varchar(255);not null"`
+	Checksum  string    `gorm:"column:checksum;type:varchar(64);not null"`
+	AppliedAt time.Time `gorm:"column:appliedAt;not null"`
+}
+
+// TableName returns the bookkeeping table name
+func (*record) TableName() string {
+	return TableName
+}
+
+// Migrator applies migrations loaded from a file system to a database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+// New creates a Migrator for the migration files found at the root of fsys
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads all migration files at the root of fsys, sorted by version
+// Every version must provide an up file, the down file is optional
+func Load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		m := fileRegexp.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, err := strconv.ParseUint(m[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names: %s and %s", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(content)
+			sum := sha256.Sum256(content)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Checksum == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Status returns the state of every known migration, verifying the checksums of the applied ones
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := Status{Version: mig.Version, Name: mig.Name}
+		if rec, ok := applied[mig.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = rec.AppliedAt
+			st.Modified = rec.Checksum != mig.Checksum
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations which have not been applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []*Migration
+	for _, mig := range m.migrations {
+		rec, ok := applied[mig.Version]
+		if !ok {
+			pending = append(pending, mig)
+			continue
+		}
+		if rec.Checksum != mig.Checksum {
+			return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies at most steps pending migrations in version order, all of them if steps <= 0
+func (m *Migrator) Up(ctx context.Context, steps int) ([]*Migration, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if steps > 0 && steps < len(pending) {
+		pending = pending[:steps]
+	}
+
+	for i, mig := range pending {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, mig.Up); err != nil {
+				return err
+			}
+			return tx.Create(&record{
+				Version:   mig.Version,
+				Name:      mig.Name,
+				Checksum:  mig.Checksum,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("failed to apply migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	return pending, nil
+}
+
+// Down reverts at most steps applied migrations in reverse version order, all of them if steps <= 0
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []*Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if steps > 0 && len(reverted) == steps {
+			break
+		}
+
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+		}
+
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := exec(tx, mig.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&record{}, "version = ?", mig.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("failed to revert migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		reverted = append(reverted, mig)
+	}
+
+	return reverted, nil
+}
+
+// EnsureUpToDate returns ErrPending when there are migrations which have not been applied
+func (m *Migrator) EnsureUpToDate(ctx context.Context) error {
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: %d migration(s) starting at %d_%s, run `fg-apiserver migrate up`",
+			ErrPending, len(pending), pending[0].Version, pending[0].Name)
+	}
+	return nil
+}
+
+// createTable creates the bookkeeping table if needed, only the migrations modify the schema
+func (m *Migrator) createTable(ctx context.Context) error {
+	if err := m.db.WithContext(ctx).AutoMigrate(&record{}); err != nil {
+		return fmt.Errorf("failed to create table %s: %w", TableName, err)
+	}
+	return nil
+}
+
+// applied returns the applied migrations by version, none if the bookkeeping table is missing
+// It only reads the database, so that the status and the readiness checks never modify it.
+func (m *Migrator) applied(ctx context.Context) (map[uint64]*record, error) {
+	db := m.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&record{}) {
+		return map[uint64]*record{}, nil
+	}
+
+	var records []*record
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint64]*record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// Create writes an empty pair of up/down files for a new migration into dir
+// The version is one greater than the highest version found in dir
+func Create(dir string, name string) ([]string, error) {
+	if !nameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q: only letters, digits, '_' and '-' are allowed", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var version uint64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	files := make([]string, 0, 2)
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %s migration %s\n", strings.ToUpper(direction[:1])+direction[1:], filepath.Base(file))
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// exec runs every statement of a migration file
+func exec(tx *gorm.DB, script string) error {
+	for _, stmt := range Statements(script) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Statements splits a script into statements, a statement ends with a semicolon at the end of a line
+// Lines starting with "--" are treated as comments
+func Statements(script string) []string {
+	var (
+		stmts []string
+		buf   strings.Builder
+	)
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(buf.String()), ";"))
+			buf.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(buf.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
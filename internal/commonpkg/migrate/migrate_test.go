@@ -0,0 +1,216 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_init.up.sql":          {Data: []byte("-- Up migration\nCREATE TABLE post (\n  id INTEGER PRIMARY KEY\n);\n")},
+	"0001_init.down.sql":        {Data: []byte("DROP TABLE post;\n")},
+	"0002_add_title.up.sql":     {Data: []byte("ALTER TABLE post ADD COLUMN title TEXT;\nCREATE INDEX idx_title ON post (title);\n")},
+	"0002_add_title.down.sql":   {Data: []byte("DROP INDEX idx_title;\nALTER TABLE post DROP COLUMN title;\n")},
+	"0003_add_views.up.sql":     {Data: []byte("ALTER TABLE post ADD COLUMN views INTEGER;\n")},
+	"README.md":                 {Data: []byte("not a migration")},
+	"0003_add_views.down.sql":   {Data: []byte("ALTER TABLE post DROP COLUMN views;\n")},
+	"archive/0004_old.up.sql":   {Data: []byte("subdirectories are ignored")},
+	"archive/0004_old.down.sql": {Data: []byte("subdirectories are ignored")},
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	return db
+}
+
+// columns returns the columns of the post table
+func columns(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+
+	types, err := db.Migrator().ColumnTypes("post")
+	require.NoError(t, err)
+	names := make([]string, 0, len(types))
+	for _, ct := range types {
+		names = append(names, ct.Name())
+	}
+	return names
+}
+
+func versions(migrations []*Migration) []uint64 {
+	vs := make([]uint64, 0, len(migrations))
+	for _, m := range migrations {
+		vs = append(vs, m.Version)
+	}
+	return vs
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testMigrations)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 2, 3}, versions(migrations))
+	assert.Equal(t, "add_title", migrations[1].Name)
+	assert.Len(t, migrations[0].Checksum, 64)
+
+	for name, fsys := range map[string]fstest.MapFS{
+		"invalid name":      {"init.up.sql": {}},
+		"version zero":      {"0000_init.up.sql": {}},
+		"conflicting names": {"0001_init.up.sql": {}, "0001_other.down.sql": {}},
+		"missing up file":   {"0001_init.down.sql": {}},
+	} {
+		_, err := Load(fsys)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m, err := New(db, testMigrations)
+	require.NoError(t, err)
+
+	// Checking the migrations does not modify the database
+	assert.ErrorIs(t, m.EnsureUpToDate(ctx), ErrPending)
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Len(t, statuses, 3)
+	assert.False(t, db.Migrator().HasTable(TableName))
+
+	applied, err := m.Up(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1}, versions(applied))
+	assert.Equal(t, []string{"id"}, columns(t, db))
+
+	applied, err = m.Up(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 3}, versions(applied))
+	assert.Equal(t, []string{"id", "title", "views"}, columns(t, db))
+	require.NoError(t, m.EnsureUpToDate(ctx))
+
+	statuses, err = m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	for _, st := range statuses {
+		assert.True(t, st.Applied, st.Name)
+		assert.False(t, st.Modified, st.Name)
+		assert.False(t, st.AppliedAt.IsZero(), st.Name)
+	}
+
+	// Applying again does nothing
+	applied, err = m.Up(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := m.Down(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3, 2}, versions(reverted))
+	assert.Equal(t, []string{"id"}, columns(t, db))
+
+	pending, err := m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 3}, versions(pending))
+
+	reverted, err = m.Down(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1}, versions(reverted))
+	assert.False(t, db.Migrator().HasTable("post"))
+}
+
+func TestFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	fsys := fstest.MapFS{
+		"0001_init.up.sql":   testMigrations["0001_init.up.sql"],
+		"0002_broken.up.sql": {Data: []byte("ALTER TABLE post ADD COLUMN title TEXT;\nNOT SQL;\n")},
+	}
+	m, err := New(db, fsys)
+	require.NoError(t, err)
+
+	// The migrations before the failure stay applied, the failed one is rolled back
+	applied, err := m.Up(ctx, 0)
+	assert.ErrorContains(t, err, "2_broken")
+	assert.Equal(t, []uint64{1}, versions(applied))
+	assert.Equal(t, []string{"id"}, columns(t, db))
+
+	pending, err := m.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2}, versions(pending))
+
+	// Migrations without a down file cannot be reverted
+	_, err = m.Down(ctx, 0)
+	assert.ErrorContains(t, err, "has no down file")
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m, err := New(db, testMigrations)
+	require.NoError(t, err)
+	_, err = m.Up(ctx, 1)
+	require.NoError(t, err)
+
+	modified := fstest.MapFS{
+		"0001_init.up.sql": {Data: []byte("CREATE TABLE post (id INTEGER PRIMARY KEY, title TEXT);\n")},
+	}
+	m, err = New(db, modified)
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx, 0)
+	assert.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.ErrorIs(t, m.EnsureUpToDate(ctx), ErrChecksumMismatch)
+
+	statuses, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Modified)
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+
+	files, err := Create(dir, "init")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "0001_init.up.sql"), filepath.Join(dir, "0001_init.down.sql")}, files)
+
+	files, err = Create(dir, "add-title")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "0002_add-title.up.sql"), files[0])
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "-- Up migration 0002_add-title.up.sql\n", string(content))
+
+	_, err = Create(dir, "add title")
+	assert.Error(t, err)
+}
+
+func TestStatements(t *testing.T) {
+	script := `-- Create the tables
+CREATE TABLE user (
+  id INTEGER PRIMARY KEY, -- the primary key
+  name TEXT
+);
+
+INSERT INTO user (name) VALUES ('a;b');
+  -- indented comment
+UPDATE user SET name = 'c'`
+
+	assert.Equal(t, []string{
+		"CREATE TABLE user (\n  id INTEGER PRIMARY KEY, -- the primary key\n  name TEXT\n)",
+		"INSERT INTO user (name) VALUES ('a;b')",
+		"UPDATE user SET name = 'c'",
+	}, Statements(script))
+	assert.Empty(t, Statements("-- nothing\n\n"))
+}
@@ -0,0 +1,350 @@
+// Package openapi builds OpenAPI 3.0 documents from the request and response types of an API.
+//
+// Schemas are derived from the struct tags the handlers bind with: `uri` fields become path
+// parameters, `form` fields query parameters, and the remaining `json` fields the request
+// body. Pointer and `omitempty` fields are optional, every other field is required.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/errorx"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of a path, keyed by lower case HTTP method
+type PathItem map[string]*OperationObject
+
+// OperationObject is an operation of the document
+type OperationObject struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of a request
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+	// Reasons lists the error reasons returned with the status code
+	Reasons []string `json:"x-error-reasons,omitempty"`
+}
+
+// MediaType is the schema of a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas and the security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Schema is a JSON schema of the document
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// Operation describes a route of the API
+type Operation struct {
+	// ID is the operation ID, derived from the name of the request or response type if empty
+	ID string
+	// Method is the HTTP method
+	Method string
+	// Path is the route path in gin syntax, e.g. /api/v1/user/:user_id
+	Path    string
+	Summary string
+	Tags    []string
+	// Auth requires a bearer access token
+	Auth bool
+	// Request and Response are values of the request and response types, Request may be nil
+	Request  any
+	Response any
+	// Errors are the errors the operation may return, besides ErrInternal
+	Errors []*errorx.ErrorX
+}
+
+// bearerAuth is the name of the bearer token security scheme
+const bearerAuth = "bearerAuth"
+
+// errorResponse is the schema name of the error responses
+const errorResponse = "ErrorResponse"
+
+// New creates a document, errorResponse is a value of the error response type
+func New(info Info, errResponse any) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]*PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	doc.Components.Schemas[errorResponse] = doc.schema(reflect.TypeOf(errResponse), "")
+	return doc
+}
+
+// Add documents an operation
+func (d *Document) Add(op Operation) {
+	path, item := OpenAPIPath(op.Path), d.Paths[OpenAPIPath(op.Path)]
+	if item == nil {
+		item = &PathItem{}
+		d.Paths[path] = item
+	}
+
+	obj := &OperationObject{
+		OperationID: operationID(op),
+		Summary:     op.Summary,
+		Tags:        op.Tags,
+		Responses: map[string]*Response{
+			"200": {
+				Description: http.StatusText(http.StatusOK),
+				Content:     jsonContent(d.schema(reflect.TypeOf(op.Response), "")),
+			},
+		},
+	}
+	if op.Auth {
+		obj.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+	if op.Request != nil {
+		d.addRequest(obj, op.Method, reflect.TypeOf(op.Request))
+	}
+	d.addErrors(obj, append(slices.Clone(op.Errors), errorx.ErrInternal))
+
+	(*item)[strings.ToLower(op.Method)] = obj
+}
+
+// OpenAPIPath converts a gin route path to an OpenAPI path
+func OpenAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if name, ok := strings.CutPrefix(part, ":"); ok {
+			parts[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// operationID derives the operation ID from the name of the request or response type
+func operationID(op Operation) string {
+	if op.ID != "" {
+		return op.ID
+	}
+	if op.Request != nil {
+		return strings.TrimSuffix(typeName(reflect.TypeOf(op.Request)), "Request")
+	}
+	return strings.TrimSuffix(typeName(reflect.TypeOf(op.Response)), "Response")
+}
+
+// addRequest documents the parameters and the body of the request type t
+func (d *Document) addRequest(obj *OperationObject, method string, t reflect.Type) {
+	t = indirect(t)
+	body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		if name := tagName(field.Tag.Get("uri")); name != "" {
+			// A path segment binds a single value, even into a slice
+			typ := field.Type
+			if typ.Kind() == reflect.Slice {
+				typ = typ.Elem()
+			}
+			obj.Parameters = append(obj.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: d.schema(typ, "")})
+			continue
+		}
+		if name := tagName(field.Tag.Get("form")); name != "" {
+			obj.Parameters = append(obj.Parameters, &Parameter{Name: name, In: "query", Schema: d.schema(field.Type, "")})
+			continue
+		}
+		if method == http.MethodGet {
+			continue
+		}
+
+		if name, required := jsonField(field); name != "" {
+			body.Properties[name] = d.schema(field.Type, "")
+			if required {
+				body.Required = append(body.Required, name)
+			}
+		}
+	}
+
+	if len(body.Properties) > 0 {
+		d.Components.Schemas[typeName(t)] = body
+		obj.RequestBody = &RequestBody{Required: true, Content: jsonContent(&Schema{Ref: schemaRef(typeName(t))})}
+	}
+}
+
+// addErrors documents the error responses, grouped by status code
+func (d *Document) addErrors(obj *OperationObject, errs []*errorx.ErrorX) {
+	for _, err := range errs {
+		code := strconv.Itoa(err.Code)
+		resp, ok := obj.Responses[code]
+		if !ok {
+			resp = &Response{Description: http.StatusText(err.Code), Content: jsonContent(&Schema{Ref: schemaRef(errorResponse)})}
+			obj.Responses[code] = resp
+		}
+		if !slices.Contains(resp.Reasons, err.Reason) {
+			resp.Reasons = append(resp.Reasons, err.Reason)
+		}
+	}
+}
+
+// ref returns a reference to the component schema of the struct type t
+func (d *Document) ref(t reflect.Type) *Schema {
+	t = indirect(t)
+	name := typeName(t)
+	if _, ok := d.Components.Schemas[name]; !ok {
+		// Register the name first, so recursive types terminate
+		d.Components.Schemas[name] = &Schema{}
+		*d.Components.Schemas[name] = *d.schema(t, name)
+	}
+	return &Schema{Ref: schemaRef(name)}
+}
+
+// schema returns the schema of t, self is the component name when t is being registered
+func (d *Document) schema(t reflect.Type, self string) *Schema {
+	nullable := t.Kind() == reflect.Pointer
+	t = indirect(t)
+
+	var s *Schema
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && self == "" && typeName(t) != "":
+		return d.ref(t)
+	case t.Kind() == reflect.Struct:
+		s = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, field := range reflect.VisibleFields(t) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			if name, required := jsonField(field); name != "" {
+				s.Properties[name] = d.schema(field.Type, "")
+				if required {
+					s.Required = append(s.Required, name)
+				}
+			}
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s = &Schema{Type: "array", Items: d.schema(t.Elem(), "")}
+	case t.Kind() == reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: d.schema(t.Elem(), "")}
+	case t.Kind() == reflect.String:
+		s = &Schema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s = &Schema{Type: "integer", Format: "int64"}
+		if t.Kind() == reflect.Int32 || t.Kind() == reflect.Uint32 {
+			s.Format = "int32"
+		}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s = &Schema{Type: "number"}
+	default:
+		panic(fmt.Sprintf("openapi: unsupported type %s", t))
+	}
+
+	// The server treats null as an absent value
+	s.Nullable = nullable && s.Ref == "" && s.Type != "object" && s.Type != "array"
+	return s
+}
+
+// jsonField returns the JSON name of a field and whether it is required
+func jsonField(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	optional := field.Type.Kind() == reflect.Pointer || strings.Contains(opts, "omitempty")
+	return name, !optional
+}
+
+// tagName returns the name of a binding tag
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+func schemaRef(name string) string {
+	return "#/components/schemas/" + name
+}
+
+func typeName(t reflect.Type) string {
+	return indirect(t).Name()
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
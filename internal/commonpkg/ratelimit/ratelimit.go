@@ -0,0 +1,105 @@
+// Package ratelimit limits the rate of requests with token buckets.
+//
+// Every key, such as a client IP or a user ID, owns a bucket holding up to Burst tokens,
+// refilled with Requests tokens per Period. A request takes a token, and is rejected
+// when the bucket is empty. Buckets are kept by a Store: MemoryStore limits the requests
+// of a single process, a shared Store limits the requests of all the replicas.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit is the token bucket limiting the requests of a key
+type Limit struct {
+	// Requests is the number of requests allowed per Period
+	Requests int
+	// Period is the duration over which Requests are allowed
+	Period time.Duration
+	// Burst is the number of requests allowed at once, Requests if not positive
+	Burst int
+}
+
+// Enabled reports whether the limit allows a finite number of requests
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Capacity returns the number of tokens of a full bucket
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate returns the number of tokens refilled per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the state of the bucket of a key after a request took a token
+type Result struct {
+	// Allowed reports whether the request is allowed
+	Allowed bool
+	// Limit is the capacity of the bucket
+	Limit int
+	// Remaining is the number of requests still allowed right away
+	Remaining int
+	// ResetAfter is the time until the bucket is full again
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, zero if allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets of the keys
+// Implementations sharing the buckets between replicas, e.g. in Redis, must take the token atomically.
+type Store interface {
+	// Take takes a token from the bucket of key, which is created full
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Bucket is the state of a token bucket, it is exported for the Store implementations
+type Bucket struct {
+	// Tokens is the number of tokens left at Updated
+	Tokens float64
+	// Updated is the time the tokens were last counted
+	Updated time.Time
+}
+
+// NewBucket returns a full bucket
+func NewBucket(limit Limit, now time.Time) Bucket {
+	return Bucket{Tokens: float64(limit.Capacity()), Updated: now}
+}
+
+// Take refills the bucket up to now and takes a token from it if there is one
+func (b *Bucket) Take(limit Limit, now time.Time) Result {
+	capacity, rate := float64(limit.Capacity()), limit.rate()
+
+	if elapsed := now.Sub(b.Updated).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*rate)
+		b.Updated = now
+	}
+
+	result := Result{Limit: limit.Capacity()}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / rate)
+	}
+	result.Remaining = int(b.Tokens)
+	result.ResetAfter = seconds((capacity - b.Tokens) / rate)
+	return result
+}
+
+// Full reports whether the bucket would be full at now, so that it can be forgotten
+func (b *Bucket) Full(limit Limit, now time.Time) bool {
+	return b.Tokens+now.Sub(b.Updated).Seconds()*limit.rate() >= float64(limit.Capacity())
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimit(t *testing.T) {
+	assert.True(t, Limit{Requests: 1, Period: time.Second}.Enabled())
+	assert.False(t, Limit{Requests: 0, Period: time.Second}.Enabled())
+	assert.False(t, Limit{Requests: 1}.Enabled())
+
+	assert.Equal(t, 10, Limit{Requests: 10, Period: time.Minute}.Capacity())
+	assert.Equal(t, 3, Limit{Requests: 10, Period: time.Minute, Burst: 3}.Capacity())
+}
+
+func TestBucket(t *testing.T) {
+	// 1 token per second, 3 at once
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+	now := time.Now()
+	b := NewBucket(limit, now)
+
+	for remaining := 2; remaining >= 0; remaining-- {
+		r := b.Take(limit, now)
+		require.True(t, r.Allowed)
+		assert.Equal(t, 3, r.Limit)
+		assert.Equal(t, remaining, r.Remaining)
+		assert.Zero(t, r.RetryAfter)
+	}
+
+	r := b.Take(limit, now)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, time.Second, r.RetryAfter)
+	assert.Equal(t, 3*time.Second, r.ResetAfter)
+
+	// The bucket refills at the rate of the limit
+	now = now.Add(1500 * time.Millisecond)
+	r = b.Take(limit, now)
+	require.True(t, r.Allowed)
+	assert.Zero(t, r.Remaining)
+	assert.Equal(t, 2500*time.Millisecond, r.ResetAfter)
+
+	r = b.Take(limit, now)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, 500*time.Millisecond, r.RetryAfter)
+
+	// It does not hold more than its capacity
+	now = now.Add(time.Hour)
+	assert.True(t, b.Full(limit, now))
+	r = b.Take(limit, now)
+	require.True(t, r.Allowed)
+	assert.Equal(t, 2, r.Remaining)
+	assert.False(t, b.Full(limit, now))
+
+	// Going back in time does not refill it
+	r = b.Take(limit, now.Add(-time.Minute))
+	require.True(t, r.Allowed)
+	assert.Equal(t, 1, r.Remaining)
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Period: time.Hour}
+	store := NewMemoryStore()
+
+	for _, allowed := range []bool{true, true, false} {
+		r, err := store.Take(ctx, "ip:192.0.2.1", limit)
+		require.NoError(t, err)
+		assert.Equal(t, allowed, r.Allowed)
+	}
+
+	// Every key has its own bucket
+	r, err := store.Take(ctx, "ip:192.0.2.2", limit)
+	require.NoError(t, err)
+	assert.True(t, r.Allowed)
+	assert.Equal(t, 1, r.Remaining)
+
+	// The full buckets are swept, the others are kept
+	store.buckets["ip:192.0.2.3"] = &memoryBucket{Bucket: NewBucket(limit, time.Now()), limit: limit}
+	store.sweep(time.Now().Add(sweepInterval))
+	assert.NotContains(t, store.buckets, "ip:192.0.2.3")
+	assert.Contains(t, store.buckets, "ip:192.0.2.1")
+}
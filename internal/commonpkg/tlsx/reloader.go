@@ -0,0 +1,138 @@
+// Package tlsx serves TLS certificates which are reloaded when they change on disk, so
+// renewed certificates are picked up without restarting the server.
+package tlsx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkInterval bounds how often the files are checked for changes
+const checkInterval = time.Second
+
+// Reloader holds a certificate and an optional client CA bundle loaded from PEM files
+// The files are checked for changes during the TLS handshakes, at most once per checkInterval.
+// When reloading fails, e.g. while the files are half written, the previous version stays in use.
+type Reloader struct {
+	certFile, keyFile, caFile string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  []time.Time
+	checkedAt time.Time
+}
+
+// NewReloader loads the certificate and its key, and the client CA bundle if caFile is not empty
+func NewReloader(certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config returns a copy of base which serves the current certificate and client CA bundle
+func (r *Reloader) Config(base *tls.Config) *tls.Config {
+	cfg := base.Clone()
+	cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.maybeReload()
+
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		current := base.Clone()
+		current.Certificates = []tls.Certificate{*r.cert}
+		current.ClientCAs = r.clientCAs
+		return current, nil
+	}
+	return cfg
+}
+
+// files returns the files the reloader watches
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+// modified returns the modification times of the files
+func (r *Reloader) modified() ([]time.Time, error) {
+	files := r.files()
+	times := make([]time.Time, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		times = append(times, info.ModTime())
+	}
+	return times, nil
+}
+
+// load reads the files
+func (r *Reloader) load() error {
+	modTimes, err := r.modified()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load tls certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificate found in the client CA bundle %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTimes = modTimes
+	return nil
+}
+
+// maybeReload reloads the files when they changed since they were loaded
+func (r *Reloader) maybeReload() {
+	r.mu.Lock()
+	if time.Since(r.checkedAt) < checkInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.checkedAt = time.Now()
+	loaded := r.modTimes
+	r.mu.Unlock()
+
+	modTimes, err := r.modified()
+	if err != nil {
+		slog.Error("Failed to check the tls certificate files", "err", err)
+		return
+	}
+	for i := range modTimes {
+		if !modTimes[i].Equal(loaded[i]) {
+			if err := r.load(); err != nil {
+				slog.Error("Failed to reload the tls certificate, keeping the previous one", "err", err)
+				return
+			}
+			slog.Info("Reloaded the tls certificate", "cert", r.certFile)
+			return
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a self-signed certificate with serial to dir as name.crt and name.key,
+// modified at modTime
+func writeCert(t *testing.T, dir, name string, serial int64, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	for file, block := range map[string]*pem.Block{
+		name + ".crt": {Type: "CERTIFICATE", Bytes: der},
+		name + ".key": {Type: "PRIVATE KEY", Bytes: keyDER},
+	} {
+		path := filepath.Join(dir, file)
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0o600))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+// served returns the serial number of the certificate served by cfg
+func served(t *testing.T, cfg *tls.Config) int64 {
+	t.Helper()
+
+	current, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Len(t, current.Certificates, 1)
+	cert, err := x509.ParseCertificate(current.Certificates[0].Certificate[0])
+	require.NoError(t, err)
+	return cert.SerialNumber.Int64()
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+	modTime := time.Now().Add(-time.Hour)
+	writeCert(t, dir, "server", 1, modTime)
+	writeCert(t, dir, "ca", 100, modTime)
+
+	r, err := NewReloader(certFile, keyFile, caFile)
+	require.NoError(t, err)
+	cfg := r.Config(&tls.Config{MinVersion: tls.VersionTLS13})
+	assert.Equal(t, int64(1), served(t, cfg))
+
+	current, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), current.MinVersion)
+	assert.NotNil(t, current.ClientCAs)
+
+	// The renewed certificate is served once the check interval elapsed
+	writeCert(t, dir, "server", 2, modTime.Add(time.Minute))
+	assert.Equal(t, int64(1), served(t, cfg))
+	r.checkedAt = time.Time{}
+	assert.Equal(t, int64(2), served(t, cfg))
+
+	// Files which cannot be loaded keep the previous certificate
+	require.NoError(t, os.WriteFile(keyFile, []byte("half written"), 0o600))
+	r.checkedAt = time.Time{}
+	assert.Equal(t, int64(2), served(t, cfg))
+
+	require.NoError(t, os.Remove(caFile))
+	r.checkedAt = time.Time{}
+	assert.Equal(t, int64(2), served(t, cfg))
+}
+
+func TestNewReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	writeCert(t, dir, "server", 1, time.Now())
+
+	r, err := NewReloader(certFile, keyFile, "")
+	require.NoError(t, err)
+	current, err := r.Config(&tls.Config{}).GetConfigForClient(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	assert.Nil(t, current.ClientCAs)
+
+	_, err = NewReloader(filepath.Join(dir, "missing.crt"), keyFile, "")
+	assert.Error(t, err)
+	_, err = NewReloader(keyFile, certFile, "")
+	assert.Error(t, err)
+
+	emptyCA := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(emptyCA, []byte("no certificate"), 0o600))
+	_, err = NewReloader(certFile, keyFile, emptyCA)
+	assert.ErrorContains(t, err, "no certificate found")
+}
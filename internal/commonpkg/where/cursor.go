@@ -0,0 +1,45 @@
+package where
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// Cursor is the position after the last record of a page in a list ordered by descending id.
+// It is handed to clients as an opaque token.
+type Cursor struct {
+	// ID is the id of the last record of the page.
+	ID int64 `json:"id"`
+}
+
+// ErrInvalidCursor is returned when a cursor token cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// String encodes the cursor into an opaque token.
+func (c *Cursor) String() string {
+	if c == nil {
+		return ""
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor decodes a token created by Cursor.String, an empty token yields a nil cursor.
+func ParseCursor(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,36 @@
+package where
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor(t *testing.T) {
+	token := (&Cursor{ID: 42}).String()
+	assert.NotContains(t, token, "42", "the token is opaque")
+
+	cursor, err := ParseCursor(token)
+	require.NoError(t, err)
+	assert.Equal(t, &Cursor{ID: 42}, cursor)
+
+	// No cursor is an empty token, which starts from the first record
+	var none *Cursor
+	assert.Empty(t, none.String())
+	cursor, err = ParseCursor("")
+	require.NoError(t, err)
+	assert.Nil(t, cursor)
+
+	for _, invalid := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"id":0}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"id":-1}`)),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"id":"1"}`)),
+	} {
+		_, err := ParseCursor(invalid)
+		assert.ErrorIs(t, err, ErrInvalidCursor, invalid)
+	}
+}
@@ -0,0 +1,169 @@
+package where
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Operator is a comparison operator of the filter language.
+type Operator string
+
+const (
+	OpEq   Operator = "="
+	OpNe   Operator = "!="
+	OpGt   Operator = ">"
+	OpGte  Operator = ">="
+	OpLt   Operator = "<"
+	OpLte  Operator = "<="
+	OpLike Operator = "~"
+)
+
+var (
+	// EqualityOps are the operators of fields which are only matched exactly.
+	EqualityOps = []Operator{OpEq, OpNe}
+	// TextOps are the operators of free text fields.
+	TextOps = []Operator{OpEq, OpNe, OpLike}
+	// RangeOps are the operators of ordered fields, such as numbers and times.
+	RangeOps = []Operator{OpEq, OpNe, OpGt, OpGte, OpLt, OpLte}
+)
+
+// termRegexp splits a filter term into its field, operator and value.
+var termRegexp = regexp.MustCompile(`^([a-z][a-z0-9_]*)(>=|<=|!=|=|>|<|~)(.*)$`)
+
+// Value parses the raw value of a filter term into the value passed to the database.
+type Value func(raw string) (any, error)
+
+// String keeps the raw value of a filter term.
+func String(raw string) (any, error) {
+	return raw, nil
+}
+
+// Int parses the raw value of a filter term as an integer.
+func Int(raw string) (any, error) {
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+// Time parses the raw value of a filter term as a RFC 3339 timestamp or a date.
+func Time(raw string) (any, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, raw, time.Local)
+}
+
+// Field describes a field clients may filter or sort a list on.
+type Field struct {
+	// Column is the database column of the field.
+	Column string
+	// Operators are the operators allowed in filters, the field cannot be filtered on if empty.
+	Operators []Operator
+	// Value parses filter values, String is used if nil.
+	Value Value
+	// Sortable allows sorting on the field.
+	Sortable bool
+}
+
+// Schema whitelists the fields of a resource by their API name.
+//
+// Filters are comma separated terms such as `created_at>=2025-01-01,title~go`, which are
+// combined with AND. Sorts are comma separated field names, prefixed with `-` for the
+// descending order, such as `-updated_at,title`.
+type Schema map[string]Field
+
+// Filter parses a filter expression into query conditions.
+func (s Schema) Filter(expr string) ([]Query, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	var queries []Query
+	for _, term := range strings.Split(expr, ",") {
+		matches := termRegexp.FindStringSubmatch(strings.TrimSpace(term))
+		if matches == nil {
+			return nil, fmt.Errorf("invalid filter term %q", term)
+		}
+		name, op, raw := matches[1], Operator(matches[2]), matches[3]
+
+		field, ok := s[name]
+		if !ok || len(field.Operators) == 0 {
+			return nil, fmt.Errorf("cannot filter on field %q", name)
+		}
+		if !slices.Contains(field.Operators, op) {
+			return nil, fmt.Errorf("operator %q is not supported by field %q", op, name)
+		}
+
+		if op == OpLike {
+			// The column name comes from the schema, never from the client. The escape
+			// character is not a backslash, which MySQL reads as escaping the closing quote.
+			queries = append(queries, Query{
+				Query: field.Column + " LIKE ? ESCAPE '" + likeEscape + "'",
+				Args:  []any{"%" + escapeLike(raw) + "%"},
+			})
+			continue
+		}
+
+		parse := field.Value
+		if parse == nil {
+			parse = String
+		}
+		value, err := parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q of field %q", raw, name)
+		}
+		queries = append(queries, Query{Query: fmt.Sprintf("%s %s ?", field.Column, op), Args: []any{value}})
+	}
+
+	return queries, nil
+}
+
+// Sort parses a sort expression into the columns to order by.
+func (s Schema) Sort(expr string) ([]clause.OrderByColumn, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	var columns []clause.OrderByColumn
+	for _, name := range strings.Split(expr, ",") {
+		name = strings.TrimSpace(name)
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+
+		field, ok := s[name]
+		if !ok || !field.Sortable {
+			return nil, fmt.Errorf("cannot sort on field %q", name)
+		}
+		columns = append(columns, clause.OrderByColumn{Column: clause.Column{Name: field.Column}, Desc: desc})
+	}
+
+	return columns, nil
+}
+
+// Apply parses the filter and the sort expressions and adds them to the options.
+func (s Schema) Apply(whr *Options, filter, sort string) error {
+	queries, err := s.Filter(filter)
+	if err != nil {
+		return err
+	}
+	columns, err := s.Sort(sort)
+	if err != nil {
+		return err
+	}
+
+	whr.Queries = append(whr.Queries, queries...)
+	whr.Sort = append(whr.Sort, columns...)
+	return nil
+}
+
+// likeEscape is the escape character of the LIKE patterns.
+const likeEscape = "!"
+
+// escapeLike escapes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_").Replace(s)
+}
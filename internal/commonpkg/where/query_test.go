@@ -0,0 +1,88 @@
+package where
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
+)
+
+var testSchema = Schema{
+	"title":      {Column: "title", Operators: TextOps, Sortable: true},
+	"user_id":    {Column: "userID", Operators: EqualityOps},
+	"views":      {Column: "views", Operators: RangeOps, Value: Int},
+	"created_at": {Column: "createdAt", Operators: RangeOps, Value: Time, Sortable: true},
+	"content":    {Column: "content"},
+}
+
+func TestFilter(t *testing.T) {
+	queries, err := testSchema.Filter("")
+	require.NoError(t, err)
+	assert.Nil(t, queries)
+
+	queries, err = testSchema.Filter("title~50%_off!, user_id!=user-1,views>=10,created_at<2025-01-02T03:04:05Z")
+	require.NoError(t, err)
+	assert.Equal(t, []Query{
+		{Query: "title LIKE ? ESCAPE '!'", Args: []any{"%50!%!_off!!%"}},
+		{Query: "userID != ?", Args: []any{"user-1"}},
+		{Query: "views >= ?", Args: []any{int64(10)}},
+		{Query: "createdAt < ?", Args: []any{time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}},
+	}, queries)
+
+	// Dates are days of the local time zone
+	queries, err = testSchema.Filter("created_at>=2025-01-02")
+	require.NoError(t, err)
+	assert.Equal(t, []any{time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local)}, queries[0].Args)
+
+	// Values may contain the operators
+	queries, err = testSchema.Filter("title=a<=b")
+	require.NoError(t, err)
+	assert.Equal(t, []Query{{Query: "title = ?", Args: []any{"a<=b"}}}, queries)
+
+	for _, invalid := range []string{
+		"title",
+		"Title=go",
+		"unknown=1",
+		"content=go",
+		"user_id~user",
+		"views>ten",
+		"created_at>yesterday",
+		"title=go,",
+	} {
+		_, err := testSchema.Filter(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestSort(t *testing.T) {
+	columns, err := testSchema.Sort("")
+	require.NoError(t, err)
+	assert.Nil(t, columns)
+
+	columns, err = testSchema.Sort("-created_at, title")
+	require.NoError(t, err)
+	assert.Equal(t, []clause.OrderByColumn{
+		{Column: clause.Column{Name: "createdAt"}, Desc: true},
+		{Column: clause.Column{Name: "title"}},
+	}, columns)
+
+	for _, invalid := range []string{"views", "unknown", "-", "title,"} {
+		_, err := testSchema.Sort(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestApply(t *testing.T) {
+	whr := NewWhere().Q("userID = ?", "user-1")
+	require.NoError(t, testSchema.Apply(whr, "views>1", "title"))
+	assert.Len(t, whr.Queries, 2)
+	assert.Len(t, whr.Sort, 1)
+
+	// Invalid expressions leave the options untouched
+	whr = NewWhere()
+	assert.Error(t, testSchema.Apply(whr, "views>1", "views"))
+	assert.Empty(t, whr.Queries)
+	assert.Empty(t, whr.Sort)
+}
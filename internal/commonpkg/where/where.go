@@ -2,6 +2,7 @@ package where
 
 import (
 	"context"
+	"slices"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -56,6 +57,20 @@ type Options struct {
 
 	// Queries contains a list of queries to be executed.
 	Queries []Query
+
+	// Cursor resumes a list after the last record of the previous page, Offset is ignored when set.
+	// Records must be ordered by descending id.
+	// +optional
+	Cursor *Cursor
+
+	// Sort contains the columns to order by, ahead of the default order of the list.
+	// Cursors require the default order.
+	// +optional
+	Sort []clause.OrderByColumn
+
+	// SkipCount tells list queries not to count the total number of matching records.
+	// +optional
+	SkipCount bool
 }
 
 // tenant holds the registered tenant instance.
@@ -97,6 +112,27 @@ func WithPage(page, pageSize int) Option {
 	}
 }
 
+// WithCursor initializes the Cursor field in Options with the given cursor.
+func WithCursor(cursor *Cursor) Option {
+	return func(whr *Options) {
+		whr.Cursor = cursor
+	}
+}
+
+// WithSort appends columns to the Sort field in Options.
+func WithSort(columns ...clause.OrderByColumn) Option {
+	return func(whr *Options) {
+		whr.Sort = append(whr.Sort, columns...)
+	}
+}
+
+// WithoutCount sets the SkipCount field in Options.
+func WithoutCount() Option {
+	return func(whr *Options) {
+		whr.SkipCount = true
+	}
+}
+
 // WithFilters initializes the Filters field in Options with the given filters.
 func WithFilter(filter map[any]any) Option {
 	return func(whr *Options) {
@@ -147,7 +183,7 @@ func (whr *Options) O(offset int) *Options {
 
 // L sets the limit for the query.
 func (whr *Options) L(limit int) *Options {
-	if limit < 0 {
+	if limit <= 0 {
 		limit = defaultLimit // Ensure defaultLimit is defined elsewhere
 	}
 	whr.Limit = limit
@@ -169,6 +205,41 @@ func (whr *Options) P(page, pageSize int) *Options {
 	return whr
 }
 
+// After resumes the query after the position of the cursor, a nil cursor starts from the first record.
+func (whr *Options) After(cursor *Cursor) *Options {
+	whr.Cursor = cursor
+	return whr
+}
+
+// NoCount tells list queries not to count the total number of matching records.
+func (whr *Options) NoCount() *Options {
+	whr.SkipCount = true
+	return whr
+}
+
+// Unpaged returns a copy of the options without offset, limit and cursor, which selects every
+// matching record. It is used to count the records of a paged query.
+func (whr *Options) Unpaged() *Options {
+	unpaged := *whr
+	unpaged.Offset = 0
+	unpaged.Limit = defaultLimit
+	unpaged.Cursor = nil
+	unpaged.Sort = nil
+	unpaged.Clauses = slices.Clone(whr.Clauses)
+	unpaged.Queries = slices.Clone(whr.Queries)
+	return &unpaged
+}
+
+// Next returns the cursor of the page following a page of n records whose last record has the
+// given id. It returns nil when the page is the last one or the query is not limited, and when
+// the query is sorted by other columns, since the cursor only follows the default order.
+func (whr *Options) Next(n int, lastID int64) *Cursor {
+	if whr.Limit <= 0 || n < whr.Limit || len(whr.Sort) > 0 {
+		return nil
+	}
+	return &Cursor{ID: lastID}
+}
+
 // C adds conditions to the query.
 func (whr *Options) C(conds ...clause.Expression) *Options {
 	whr.Clauses = append(whr.Clauses, conds...)
@@ -212,7 +283,16 @@ func (whr *Options) Where(db *gorm.DB) *gorm.DB {
 		conds := db.Statement.BuildCondition(query.Query, query.Args...)
 		whr.Clauses = append(whr.Clauses, conds...)
 	}
-	return db.Where(whr.Filters).Clauses(whr.Clauses...).Offset(whr.Offset).Limit(whr.Limit)
+
+	db = db.Where(whr.Filters).Clauses(whr.Clauses...)
+	if len(whr.Sort) > 0 {
+		db = db.Order(clause.OrderBy{Columns: whr.Sort})
+	}
+	if whr.Cursor != nil {
+		// Keyset pagination, the index on id makes deep pages as cheap as the first one
+		return db.Where("id < ?", whr.Cursor.ID).Limit(whr.Limit)
+	}
+	return db.Offset(whr.Offset).Limit(whr.Limit)
 }
 
 // O is a convenience function to create a new Options with offset.
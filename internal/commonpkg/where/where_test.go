@@ -0,0 +1,131 @@
+package where
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type record struct {
+	ID     int64
+	UserID string `gorm:"column:userID"`
+	Title  string
+}
+
+// toSQL returns the statement listing the records matching whr
+func toSQL(t *testing.T, whr *Options) string {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{DryRun: true})
+	require.NoError(t, err)
+	return listSQL(db, whr)
+}
+
+// toMySQL returns the statement listing the records matching whr in the MySQL dialect
+func toMySQL(t *testing.T, whr *Options) string {
+	t.Helper()
+
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "fastgo@tcp(127.0.0.1:3306)/fastgo", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	return listSQL(db, whr)
+}
+
+func listSQL(db *gorm.DB, whr *Options) string {
+	return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return whr.Where(tx.Model(&record{})).Order("id DESC").Find(&[]record{})
+	})
+}
+
+func TestPaging(t *testing.T) {
+	assert.Equal(t, &Options{Offset: 20, Limit: 10, Filters: map[any]any{}, Clauses: []clause.Expression{}}, P(3, 10))
+	assert.Equal(t, 0, P(0, 10).Offset)
+	assert.Equal(t, defaultLimit, P(2, 0).Limit)
+	assert.Equal(t, 0, O(-1).Offset)
+	assert.Equal(t, defaultLimit, L(0).Limit)
+
+	whr := NewWhere(WithPage(2, 5), WithoutCount())
+	assert.Equal(t, 5, whr.Offset)
+	assert.Equal(t, 5, whr.Limit)
+	assert.True(t, whr.SkipCount)
+	assert.Equal(t, defaultLimit, NewWhere(WithLimit(-3)).Limit)
+	assert.Equal(t, 0, NewWhere(WithOffset(-3)).Offset)
+}
+
+func TestNext(t *testing.T) {
+	// Full pages of a limited query have a next page
+	assert.Equal(t, &Cursor{ID: 7}, L(2).Next(2, 7))
+	assert.Nil(t, L(2).Next(1, 7), "the last page is not full")
+	assert.Nil(t, NewWhere().Next(2, 7), "unlimited queries have one page")
+
+	// The cursor only follows the default order
+	sorted := L(2)
+	sorted.Sort = []clause.OrderByColumn{{Column: clause.Column{Name: "title"}}}
+	assert.Nil(t, sorted.Next(2, 7))
+}
+
+func TestUnpaged(t *testing.T) {
+	whr := L(10).O(20).After(&Cursor{ID: 7}).Q("title = ?", "go")
+	whr.Sort = []clause.OrderByColumn{{Column: clause.Column{Name: "title"}}}
+
+	unpaged := whr.Unpaged()
+	assert.Equal(t, 0, unpaged.Offset)
+	assert.Equal(t, defaultLimit, unpaged.Limit)
+	assert.Nil(t, unpaged.Cursor)
+	assert.Nil(t, unpaged.Sort)
+	assert.Equal(t, whr.Queries, unpaged.Queries)
+
+	// The paged query is not modified by the unpaged one
+	unpaged.Q("userID = ?", "user-1")
+	assert.Len(t, whr.Queries, 1)
+	assert.Equal(t, 10, whr.Limit)
+}
+
+func TestWhere(t *testing.T) {
+	sql := toSQL(t, F("userID", "user-1").Q("title LIKE ?", "%go%").O(20).L(10))
+	assert.Contains(t, sql, "`userID` = \"user-1\"")
+	assert.Contains(t, sql, "title LIKE \"%go%\"")
+	assert.Contains(t, sql, "LIMIT 10 OFFSET 20")
+
+	// Cursors replace the offset
+	sql = toSQL(t, L(10).O(20).After(&Cursor{ID: 7}))
+	assert.Contains(t, sql, "id < 7")
+	assert.Contains(t, sql, "LIMIT 10")
+	assert.NotContains(t, sql, "OFFSET")
+
+	// The sort precedes the default order
+	whr := L(10)
+	whr.Sort = []clause.OrderByColumn{{Column: clause.Column{Name: "title"}, Desc: true}}
+	assert.Contains(t, toSQL(t, whr), "ORDER BY `title` DESC,id DESC")
+}
+
+func TestLike(t *testing.T) {
+	queries, err := Schema{"title": {Column: "title", Operators: TextOps}}.Filter("title~50%_off!")
+	require.NoError(t, err)
+	whr := NewWhere()
+	whr.Queries = queries
+
+	// MySQL reads a backslash as escaping the closing quote of the escape character
+	assert.Contains(t, toMySQL(t, whr), "title LIKE '%50!%!_off!!%' ESCAPE '!'")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&record{}))
+	for _, title := range []string{"50%_off!", "get 50%_off! now", "50% off!", "50a_off!", "50%_off"} {
+		require.NoError(t, db.Create(&record{Title: title}).Error)
+	}
+
+	// The wildcards of the value are matched literally
+	var matched []record
+	require.NoError(t, whr.Where(db.Model(&record{})).Order("id").Find(&matched).Error)
+	titles := make([]string, 0, len(matched))
+	for _, r := range matched {
+		titles = append(titles, r.Title)
+	}
+	assert.Equal(t, []string{"50%_off!", "get 50%_off! now"}, titles)
+}
@@ -0,0 +1,289 @@
+// Package authn implements the token authenticator of the apiserver.
+//
+// A successful login opens a session which is persisted through the store. The session
+// hands out a short-lived JWT access token, bound to the session by its token ID (jti),
+// and an opaque refresh token which rotates on every refresh. Revoking the session
+// invalidates both tokens immediately.
+package authn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/model"
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/authn"
+	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/pkg/token"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const (
+	// tokenType is the authentication scheme of the issued access tokens
+	tokenType = "Bearer"
+
+	// purgeInterval is how often expired sessions are removed from the store
+	purgeInterval = time.Hour
+)
+
+// tokenPair is the token handed out by the Authenticator
+type tokenPair struct {
+	AccessToken     string `json:"token"`
+	TokenType       string `json:"token_type"`
+	ExpireAt        int64  `json:"expire_at"`
+	RefreshToken    string `json:"refresh_token"`
+	RefreshExpireAt int64  `json:"refresh_expire_at"`
+}
+
+var _ authn.IToken = (*tokenPair)(nil)
+
+func (t *tokenPair) GetToken() string              { return t.AccessToken }
+func (t *tokenPair) GetTokenType() string          { return t.TokenType }
+func (t *tokenPair) GetExpireAt() int64            { return t.ExpireAt }
+func (t *tokenPair) GetRefreshToken() string       { return t.RefreshToken }
+func (t *tokenPair) GetRefreshExpireAt() int64     { return t.RefreshExpireAt }
+func (t *tokenPair) EncodeToJSON() ([]byte, error) { return json.Marshal(t) }
+
+// Authenticator issues access/refresh token pairs backed by sessions in the store
+type Authenticator struct {
+	store store.IStore
+
+	// accessExpiration is the lifetime of access tokens
+	accessExpiration time.Duration
+	// refreshExpiration is the lifetime of refresh tokens, and therefore of idle sessions
+	refreshExpiration time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	// done is closed once the background purge returned
+	done chan struct{}
+}
+
+var _ authn.AuthenTicator = (*Authenticator)(nil)
+
+// New creates an Authenticator and starts purging expired sessions in the background
+// Release must be called to stop the background purge
+func New(store store.IStore, accessExpiration, refreshExpiration time.Duration) *Authenticator {
+	a := &Authenticator{
+		store:             store,
+		accessExpiration:  accessExpiration,
+		refreshExpiration: refreshExpiration,
+		stop:              make(chan struct{}),
+		done:              make(chan struct{}),
+	}
+
+	go a.purge()
+
+	return a
+}
+
+// Sign opens a new session for the user and returns its first token pair
+func (a *Authenticator) Sign(ctx context.Context, userID string) (authn.IToken, error) {
+	userM, err := a.store.User().Get(ctx, where.F("userID", userID))
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, errorx.ErrSignToken.WithMessage("%v", err)
+	}
+
+	sessionM := &model.Session{
+		SessionID:        uuid.New().String(),
+		UserID:           userM.UserID,
+		RefreshTokenHash: hashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(a.refreshExpiration),
+	}
+	if err := a.store.Session().Create(ctx, sessionM); err != nil {
+		return nil, err
+	}
+
+	return a.issue(userM, sessionM, refreshToken)
+}
+
+// Refresh rotates the refresh token of a session and returns a new token pair
+// Presenting a refresh token which was already rotated, or which is being rotated by a
+// concurrent refresh, revokes the session, since either the client or an attacker holds a
+// stolen copy
+func (a *Authenticator) Refresh(ctx context.Context, refreshToken string) (authn.IToken, error) {
+	hash := hashToken(refreshToken)
+
+	sessionM, err := a.store.Session().Get(ctx, where.F("refreshTokenHash", hash))
+	if err != nil {
+		if errors.Is(err, errorx.ErrSessionNotFound) {
+			a.detectReuse(ctx, hash)
+			return nil, errorx.ErrTokenInvalid
+		}
+		return nil, err
+	}
+
+	if sessionM.RevokedAt != nil {
+		return nil, errorx.ErrTokenRevoked
+	}
+	if time.Now().After(sessionM.ExpiresAt) {
+		return nil, errorx.ErrTokenExpired
+	}
+
+	userM, err := a.store.User().Get(ctx, where.F("userID", sessionM.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	newToken, err := newRefreshToken()
+	if err != nil {
+		return nil, errorx.ErrSignToken.WithMessage("%v", err)
+	}
+
+	// The rotation only succeeds if the refresh token was not rotated concurrently, a refresh
+	// token is used once even when it is presented twice at the same time
+	sessionM.PreviousTokenHash = hash
+	sessionM.RefreshTokenHash = hashToken(newToken)
+	sessionM.ExpiresAt = time.Now().Add(a.refreshExpiration)
+	rotated, err := a.store.Session().Rotate(ctx, sessionM, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		a.revokeReused(ctx, sessionM)
+		return nil, errorx.ErrTokenInvalid
+	}
+
+	return a.issue(userM, sessionM, newToken)
+}
+
+// Destroy revokes the session of the access token
+func (a *Authenticator) Destroy(ctx context.Context, accessToken string) error {
+	claims, err := a.ParseClaims(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return a.store.Session().Revoke(ctx, where.F("sessionID", claims.ID))
+}
+
+// DestroyAll revokes every session of the user
+func (a *Authenticator) DestroyAll(ctx context.Context, userID string) error {
+	return a.store.Session().Revoke(ctx, where.F("userID", userID))
+}
+
+// ParseClaims parses the access token and makes sure its session is still active
+func (a *Authenticator) ParseClaims(ctx context.Context, accessToken string) (*token.Claims, error) {
+	claims, err := token.Parse(accessToken)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, errorx.ErrTokenExpired
+		}
+		return nil, errorx.ErrTokenInvalid.WithMessage("%v", err)
+	}
+
+	sessionM, err := a.store.Session().Get(ctx, where.F("sessionID", claims.ID))
+	if err != nil {
+		if errors.Is(err, errorx.ErrSessionNotFound) {
+			return nil, errorx.ErrTokenRevoked
+		}
+		return nil, err
+	}
+	if sessionM.RevokedAt != nil {
+		return nil, errorx.ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// Release stops the background purge of expired sessions and waits for it to return,
+// so that the store may be closed afterwards
+func (a *Authenticator) Release() error {
+	a.stopOnce.Do(func() {
+		close(a.stop)
+	})
+	<-a.done
+	return nil
+}
+
+// issue signs an access token bound to the session
+func (a *Authenticator) issue(userM *model.User, sessionM *model.Session, refreshToken string) (*tokenPair, error) {
+	accessToken, expireAt, err := token.SignClaims(&token.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userM.UserID,
+			ID:        sessionM.SessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(a.accessExpiration)),
+		},
+		Username: userM.Username,
+		Roles:    []string{userM.Role},
+	})
+	if err != nil {
+		return nil, errorx.ErrSignToken.WithMessage("%v", err)
+	}
+
+	return &tokenPair{
+		AccessToken:     accessToken,
+		TokenType:       tokenType,
+		ExpireAt:        expireAt.Unix(),
+		RefreshToken:    refreshToken,
+		RefreshExpireAt: sessionM.ExpiresAt.Unix(),
+	}, nil
+}
+
+// detectReuse revokes the session whose previous refresh token is presented again
+func (a *Authenticator) detectReuse(ctx context.Context, hash string) {
+	sessionM, err := a.store.Session().Get(ctx, where.F("previousTokenHash", hash))
+	if err != nil {
+		return
+	}
+
+	a.revokeReused(ctx, sessionM)
+}
+
+// revokeReused revokes the session whose refresh token was presented more than once
+func (a *Authenticator) revokeReused(ctx context.Context, sessionM *model.Session) {
+	slog.WarnContext(ctx, "Refresh token reused, revoking the session", "sessionID", sessionM.SessionID, "userID", sessionM.UserID)
+	if err := a.store.Session().Revoke(ctx, where.F("sessionID", sessionM.SessionID)); err != nil {
+		slog.ErrorContext(ctx, "Failed to revoke the session", "sessionID", sessionM.SessionID, "err", err)
+	}
+}
+
+// purge periodically deletes the sessions whose refresh token expired
+func (a *Authenticator) purge() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			return
+		case <-ticker.C:
+			whr := where.NewWhere().Q("expiresAt < ?", time.Now())
+			if err := a.store.Session().Delete(context.Background(), whr); err != nil {
+				slog.Error("Failed to purge expired sessions", "err", err)
+			}
+		}
+	}
+}
+
+// newRefreshToken generates a random opaque refresh token
+func newRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 of a refresh token, only hashes are persisted
+func hashToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,81 @@
+package authn
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MortalSC/FastGO/configs"
+	"github.com/MortalSC/FastGO/internal/apiserver/model"
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/migrate"
+	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/pkg/token"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// racingStore runs race once, right after the first session lookup
+type racingStore struct {
+	store.IStore
+	sessions racingSessions
+}
+
+func (s *racingStore) Session() store.SessionStore {
+	return &s.sessions
+}
+
+type racingSessions struct {
+	store.SessionStore
+	race func()
+}
+
+func (s *racingSessions) Get(ctx context.Context, opts *where.Options) (*model.Session, error) {
+	sessionM, err := s.SessionStore.Get(ctx, opts)
+	if race := s.race; race != nil {
+		s.race = nil
+		race()
+	}
+	return sessionM, err
+}
+
+func TestRefreshRace(t *testing.T) {
+	token.Init("test-key", time.Minute)
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	require.NoError(t, err)
+	fsys, err := configs.Migrations("sqlite")
+	require.NoError(t, err)
+	migrator, err := migrate.New(db, fsys)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+	userM := &model.User{Username: "alice", Password: "hash", Role: "user"}
+	require.NoError(t, db.Create(userM).Error)
+
+	s := &racingStore{IStore: store.NewStore(db)}
+	s.sessions.SessionStore = s.IStore.Session()
+	a := New(s, time.Minute, time.Hour)
+	t.Cleanup(func() { _ = a.Release() })
+
+	ctx := context.Background()
+	first, err := a.Sign(ctx, userM.UserID)
+	require.NoError(t, err)
+
+	// Another refresh rotates the token between the lookup and the rotation of this one
+	var raced string
+	s.sessions.race = func() {
+		pair, err := a.Refresh(ctx, first.GetRefreshToken())
+		require.NoError(t, err)
+		raced = pair.GetToken()
+	}
+	_, err = a.Refresh(ctx, first.GetRefreshToken())
+	assert.ErrorIs(t, err, errorx.ErrTokenInvalid)
+
+	// The token was used twice, the session is revoked for both
+	_, err = a.ParseClaims(ctx, raced)
+	assert.ErrorIs(t, err, errorx.ErrTokenRevoked)
+}
@@ -0,0 +1,121 @@
+package authn
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/lockout"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+)
+
+// LoginGuard locks the accounts and the client IPs whose logins fail repeatedly
+// Accounts are keyed by username whether the user exists or not, so that a lockout does not
+// reveal which usernames exist. The usernames are case insensitive as in the database, so that
+// the variants of a username share the failures of its account.
+type LoginGuard struct {
+	store lockout.Store
+	// account locks a username after failed logins from any client
+	account lockout.Policy
+	// client locks a client IP after failed logins on any account
+	client lockout.Policy
+}
+
+// NewLoginGuard creates a LoginGuard keeping the failures in store
+func NewLoginGuard(store lockout.Store, account, client lockout.Policy) *LoginGuard {
+	return &LoginGuard{store: store, account: account, client: client}
+}
+
+// guardKey is a key of the store along with its policy
+type guardKey struct {
+	key    string
+	policy lockout.Policy
+}
+
+// keys returns the keys of the login of username from clientIP
+func (g *LoginGuard) keys(username, clientIP string) []guardKey {
+	keys := []guardKey{{key: accountKey(username), policy: g.account}}
+	if clientIP != "" {
+		keys = append(keys, guardKey{key: "ip:" + clientIP, policy: g.client})
+	}
+	return keys
+}
+
+func accountKey(username string) string {
+	return "account:" + strings.ToLower(username)
+}
+
+// Attempt records a login of username from clientIP before it is verified, which ends with
+// Fail, Succeed or Abort. It returns errorx.ErrLoginLocked if the account or the client IP is
+// locked, or if the logins already pending would lock them if they failed.
+// The failures of the store are logged and ignored, so that an outage does not prevent logins.
+func (g *LoginGuard) Attempt(ctx context.Context, username, clientIP string) error {
+	now := time.Now()
+	keys := g.keys(username, clientIP)
+	for i, k := range keys {
+		state, ok, err := g.store.Attempt(ctx, k.key, k.policy)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record the login attempt", "key", k.key, "err", err)
+			continue
+		}
+		if !ok {
+			// The attempt is refused, end the attempts recorded for the other keys
+			g.release(ctx, keys[:i])
+			retryAfter := max(int64(math.Ceil(state.RetryAfter(now).Seconds())), 1)
+			return errorx.ErrLoginLocked.KV("retry_after", strconv.FormatInt(retryAfter, 10))
+		}
+	}
+	return nil
+}
+
+// Fail records a failed login of username from clientIP
+func (g *LoginGuard) Fail(ctx context.Context, username, clientIP string) {
+	now := time.Now()
+	for _, k := range g.keys(username, clientIP) {
+		state, err := g.store.Fail(ctx, k.key, k.policy)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record the login failure", "key", k.key, "err", err)
+			continue
+		}
+		if state.Locked(now) {
+			slog.WarnContext(ctx, "Locked the logins after repeated failures", "key", k.key, "failures", state.Failures, "until", state.LockedUntil)
+		}
+	}
+}
+
+// Succeed forgets the failed logins of username and ends the login from clientIP
+// The failures of the client IP are kept, logging in to an account of the attacker must not
+// reset them.
+func (g *LoginGuard) Succeed(ctx context.Context, username, clientIP string) {
+	if err := g.store.Reset(ctx, accountKey(username)); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset the login failures", "username", username, "err", err)
+	}
+	g.release(ctx, g.keys(username, clientIP)[1:])
+}
+
+// Abort ends a login of username from clientIP which could not be verified
+func (g *LoginGuard) Abort(ctx context.Context, username, clientIP string) {
+	g.release(ctx, g.keys(username, clientIP))
+}
+
+// release ends the pending logins of keys
+func (g *LoginGuard) release(ctx context.Context, keys []guardKey) {
+	for _, k := range keys {
+		if err := g.store.Release(ctx, k.key, k.policy); err != nil {
+			slog.ErrorContext(ctx, "Failed to end the login attempt", "key", k.key, "err", err)
+		}
+	}
+}
+
+// State returns the failed logins of username
+func (g *LoginGuard) State(ctx context.Context, username string) (lockout.State, error) {
+	return g.store.Get(ctx, accountKey(username), g.account)
+}
+
+// Unlock forgets the failed logins of username, which unlocks the account
+func (g *LoginGuard) Unlock(ctx context.Context, username string) error {
+	return g.store.Reset(ctx, accountKey(username))
+}
@@ -0,0 +1,139 @@
+// Package authz decides whether the authenticated user may read or modify a resource.
+//
+// Every resource type is guarded by a Policy, which receives the caller, the action and
+// the owner of the requested object. Collections (list and create) have no owner.
+package authz
+
+import (
+	"context"
+	"slices"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/known"
+)
+
+// Action is an operation performed on a resource
+type Action string
+
+const (
+	ActionList   Action = "list"
+	ActionCreate Action = "create"
+	ActionGet    Action = "get"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+const (
+	// ResourceUser identifies user accounts
+	ResourceUser = "user"
+	// ResourcePost identifies blog posts
+	ResourcePost = "post"
+	// ResourceLogLevel identifies the log level of the running server
+	ResourceLogLevel = "loglevel"
+	// ResourceUserLock identifies the login lockout of user accounts
+	ResourceUserLock = "userlock"
+	// ResourceSession identifies the session of the caller
+	ResourceSession = "session"
+)
+
+// Subject is the authenticated caller of a request
+type Subject struct {
+	UserID string
+	Roles  []string
+}
+
+// HasRole reports whether the subject has the given role
+func (s Subject) HasRole(role string) bool {
+	return slices.Contains(s.Roles, role)
+}
+
+// IsAdmin reports whether the subject is an administrator
+func (s Subject) IsAdmin() bool {
+	return s.HasRole(known.RoleAdmin)
+}
+
+// SubjectFromContext returns the caller established by the authentication middleware
+func SubjectFromContext(ctx context.Context) Subject {
+	return Subject{
+		UserID: contextx.UserID(ctx),
+		Roles:  contextx.Roles(ctx),
+	}
+}
+
+// IsAdmin reports whether the caller in ctx is an administrator
+func IsAdmin(ctx context.Context) bool {
+	return SubjectFromContext(ctx).IsAdmin()
+}
+
+// Policy decides whether sub may perform action on an object owned by ownerID
+// ownerID is empty when the action targets a collection
+type Policy func(sub Subject, action Action, ownerID string) bool
+
+// Authenticated allows every authenticated user
+func Authenticated(sub Subject, _ Action, _ string) bool {
+	return sub.UserID != ""
+}
+
+// AdminOnly allows administrators only
+func AdminOnly(sub Subject, _ Action, _ string) bool {
+	return sub.IsAdmin()
+}
+
+// OwnerOrAdmin allows administrators and the owner of the object
+// Collections are reserved for administrators
+func OwnerOrAdmin(sub Subject, _ Action, ownerID string) bool {
+	if sub.IsAdmin() {
+		return true
+	}
+	return ownerID != "" && ownerID == sub.UserID
+}
+
+// OwnedCollection allows every authenticated user to list and create objects,
+// which the business layer scopes to the caller, and falls back to OwnerOrAdmin otherwise
+func OwnedCollection(sub Subject, action Action, ownerID string) bool {
+	if action == ActionList || action == ActionCreate {
+		return sub.UserID != ""
+	}
+	return OwnerOrAdmin(sub, action, ownerID)
+}
+
+// Authorizer holds the policy of every resource type
+// Resources without a registered policy are denied
+type Authorizer struct {
+	policies map[string]Policy
+}
+
+// NewAuthorizer creates an Authorizer with the default fastgo policies:
+// users are managed by themselves or by administrators, and only administrators
+// may list users; posts are private to their owner and visible to administrators;
+// the log level and the login lockouts are reserved for administrators; every user manages
+// its own session
+func NewAuthorizer() *Authorizer {
+	return &Authorizer{
+		policies: map[string]Policy{
+			ResourceUser:     OwnerOrAdmin,
+			ResourcePost:     OwnedCollection,
+			ResourceLogLevel: AdminOnly,
+			ResourceUserLock: AdminOnly,
+			ResourceSession:  Authenticated,
+		},
+	}
+}
+
+// Register sets the policy of a resource type, replacing any existing one
+func (a *Authorizer) Register(resource string, policy Policy) *Authorizer {
+	a.policies[resource] = policy
+	return a
+}
+
+// Authorize returns errorx.ErrPermissionDenied unless the caller in ctx may perform action on
+// the object of resource owned by ownerID
+func (a *Authorizer) Authorize(ctx context.Context, resource string, action Action, ownerID string) error {
+	policy, ok := a.policies[resource]
+	if ok && policy(SubjectFromContext(ctx), action, ownerID) {
+		return nil
+	}
+
+	return errorx.ErrPermissionDenied
+}
@@ -1,6 +1,11 @@
 package contextx
 
-import "context"
+import (
+	"context"
+
+	"github.com/MortalSC/FastGO/pkg/token"
+	"go.opentelemetry.io/otel/trace"
+)
 
 type (
 	// requestIDKey defines the context key of the requestID.
@@ -11,6 +16,18 @@ type (
 
 	// userNameKey defines the context key of the userName.
 	userNameKey struct{}
+
+	// rolesKey defines the context key of the user roles.
+	rolesKey struct{}
+
+	// accessTokenKey defines the context key of the access token.
+	accessTokenKey struct{}
+
+	// claimsKey defines the context key of the access token claims.
+	claimsKey struct{}
+
+	// clientIPKey defines the context key of the client IP.
+	clientIPKey struct{}
 )
 
 // WithRequestID sets the request ID in the context
@@ -45,3 +62,64 @@ func UserName(ctx context.Context) string {
 	userName, _ := ctx.Value(userNameKey{}).(string)
 	return userName
 }
+
+// WithRoles sets the roles of the user in the context
+func WithRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// Roles gets the roles of the user from the context
+func Roles(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// WithAccessToken sets the access token of the request in the context
+func WithAccessToken(ctx context.Context, accessToken string) context.Context {
+	return context.WithValue(ctx, accessTokenKey{}, accessToken)
+}
+
+// AccessToken gets the access token of the request from the context
+func AccessToken(ctx context.Context) string {
+	accessToken, _ := ctx.Value(accessTokenKey{}).(string)
+	return accessToken
+}
+
+// WithClaims sets the claims of the access token in the context
+func WithClaims(ctx context.Context, claims *token.Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// Claims gets the claims of the access token from the context, nil for unauthenticated requests
+func Claims(ctx context.Context) *token.Claims {
+	claims, _ := ctx.Value(claimsKey{}).(*token.Claims)
+	return claims
+}
+
+// WithClientIP sets the IP of the client sending the request in the context
+func WithClientIP(ctx context.Context, clientIP string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, clientIP)
+}
+
+// ClientIP gets the IP of the client sending the request from the context
+func ClientIP(ctx context.Context) string {
+	clientIP, _ := ctx.Value(clientIPKey{}).(string)
+	return clientIP
+}
+
+// TraceID gets the ID of the trace of the span in the context, empty if the request is not traced
+// The span is set by the tracing middleware, so there is no WithTraceID
+func TraceID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
+
+// SpanID gets the ID of the span in the context, empty if the request is not traced
+func SpanID(ctx context.Context) string {
+	if sc := trace.SpanContextFromContext(ctx); sc.HasSpanID() {
+		return sc.SpanID().String()
+	}
+	return ""
+}
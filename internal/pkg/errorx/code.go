@@ -1,24 +1,35 @@
 package errorx
 
-import "net/http"
+import (
+	"net/http"
 
-var (
-	OK          = &ErrorX{Code: http.StatusOK, Reason: "OK", Message: "ok"}
-	ErrInternal = &ErrorX{Code: http.StatusInternalServerError, Reason: "InternalServerError", Message: "internal server error"}
-	ErrNotFound = &ErrorX{Code: http.StatusNotFound, Reason: "NotFound", Message: "not found"}
+	commonerrorx "github.com/MortalSC/FastGO/internal/commonpkg/errorx"
+)
 
-	ErrDBWrite = &ErrorX{Code: http.StatusInternalServerError, Reason: "DBWrite", Message: "database write error"}
-	ErrDBRead  = &ErrorX{Code: http.StatusInternalServerError, Reason: "DBRead", Message: "database read error"}
+// The generic errors are shared with internal/commonpkg/errorx, so they compare equal
+// whichever package they are referenced from
+var (
+	OK                  = commonerrorx.OK
+	ErrInternal         = commonerrorx.ErrInternal
+	ErrNotFound         = commonerrorx.ErrNotFound
+	ErrBind             = commonerrorx.ErrBind
+	ErrInvalidArgument  = commonerrorx.ErrInvalidArgument
+	ErrUnauthenticated  = commonerrorx.ErrUnauthenticated
+	ErrPermissionDenied = commonerrorx.ErrPermissionDenied
+	ErrOperationFailed  = commonerrorx.ErrOperationFailed
+)
 
-	ErrBind            = &ErrorX{Code: http.StatusBadRequest, Reason: "BindError", Message: "Error occurred while binding the request body to the struct."}
-	ErrInvalidArgument = &ErrorX{Code: http.StatusBadRequest, Reason: "InvalidArgument", Message: "Argument verification failed."}
+var (
+	ErrDBWrite = &ErrorX{Code: http.StatusInternalServerError, Reason: "InternalError.DBWrite", Message: "Database write error."}
+	ErrDBRead  = &ErrorX{Code: http.StatusInternalServerError, Reason: "InternalError.DBRead", Message: "Database read error."}
 
-	ErrTokenInvalid = &ErrorX{Code: http.StatusUnauthorized, Reason: "TokenInvalid", Message: "invalid token"}
-	ErrTokenExpired = &ErrorX{Code: http.StatusUnauthorized, Reason: "TokenExpired", Message: "token expired"}
+	ErrTokenInvalid = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TokenInvalid", Message: "Invalid token."}
+	ErrTokenExpired = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TokenExpired", Message: "Token expired."}
+	ErrTokenRevoked = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TokenRevoked", Message: "Token has been revoked."}
 	ErrSignToken    = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.SignToken", Message: "Error occurred while signing the JSON web token."}
 
-	ErrInvalidPassword = &ErrorX{Code: http.StatusUnauthorized, Reason: "InvalidPassword", Message: "invalid password"}
-	// ErrBadRequest   = &ErrorX{Code: http.StatusBadRequest, Reason: "BadRequest", Message: "bad request"}
-	// ErrUnauthorized = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthorized", Message: "unauthorized"}
-	// ErrForbidden    = &ErrorX{Code: http.StatusForbidden, Reason: "Forbidden", Message: "forbidden"}
+	ErrInvalidPassword = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.InvalidPassword", Message: "Invalid password."}
 )
+
+// ErrTooManyRequests rejects the requests exceeding a rate limit
+var ErrTooManyRequests = &ErrorX{Code: http.StatusTooManyRequests, Reason: "ResourceExhausted.TooManyRequests", Message: "Too many requests, please retry later."}
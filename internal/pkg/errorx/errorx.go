@@ -1,39 +1,18 @@
+// Package errorx is the catalog of the errors returned by the apiserver.
+//
+// It does not define an error model of its own: every error is a *ErrorX of
+// internal/commonpkg/errorx, so reasons, metadata and the HTTP and gRPC mapping are the
+// same whichever layer created the error.
 package errorx
 
-import (
-	"errors"
-	"fmt"
-)
-
-type ErrorX struct {
-	Code    int    `json:"code,omitempty"`
-	Reason  string `json:"reason,omitempty"`
-	Message string `json:"message,omitempty"`
-}
-
-func New(code int, reason string, format string, args ...any) *ErrorX {
-	return &ErrorX{
-		Code:    code,
-		Reason:  reason,
-		Message: fmt.Sprintf(format, args...),
-	}
-}
+import commonerrorx "github.com/MortalSC/FastGO/internal/commonpkg/errorx"
 
-func (e *ErrorX) Error() string {
-	return fmt.Sprintf("code: %d, reason: %s, message: %s", e.Code, e.Reason, e.Message)
-}
+// ErrorX is the error model of internal/commonpkg/errorx
+type ErrorX = commonerrorx.ErrorX
 
-func (e *ErrorX) WithMessage(format string, args ...any) *ErrorX {
-	e.Message = fmt.Sprintf(format, args...)
-	return e
-}
-
-func FromError(err error) *ErrorX {
-	if err == nil {
-		return nil
-	}
-	if errx := new(ErrorX); errors.As(err, &errx) {
-		return errx
-	}
-	return New(ErrInternal.Code, ErrInternal.Reason, err.Error())
-}
+var (
+	// New creates an error with the given code and reason
+	New = commonerrorx.New
+	// FromError converts any error to an *ErrorX, unknown errors become ErrInternal
+	FromError = commonerrorx.FromError
+)
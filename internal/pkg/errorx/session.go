@@ -0,0 +1,5 @@
+package errorx
+
+import "net/http"
+
+var ErrSessionNotFound = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.SessionNotFound", Message: "Session not found."}
@@ -17,4 +17,9 @@ var (
 
 	ErrUserAlreadyExists = &ErrorX{Code: http.StatusBadRequest, Reason: "AlreadyExist.UserAlreadyExists", Message: "User already exists."}
 	ErrUserNotFound      = &ErrorX{Code: http.StatusNotFound, Reason: "NotFound.UserNotFound", Message: "User not found."}
+
+	// ErrInvalidCredentials rejects a login, whether the user does not exist or the password is wrong
+	ErrInvalidCredentials = &ErrorX{Code: http.StatusUnauthorized, Reason: "Unauthenticated.InvalidCredentials", Message: "Invalid username or password."}
+	// ErrLoginLocked rejects the logins of an account or a client IP locked after repeated failures
+	ErrLoginLocked = &ErrorX{Code: http.StatusTooManyRequests, Reason: "ResourceExhausted.LoginLocked", Message: "Too many failed login attempts, please retry later."}
 )
@@ -0,0 +1,56 @@
+package interceptor
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/authn"
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Authn authenticates the bearer access token of the 'authorization' metadata
+// The methods listed in public, identified by their full method name, are not authenticated
+func Authn(authenticator authn.AuthenTicator, public ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if slices.Contains(public, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		accessToken, err := fromMetadata(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		claims, err := authenticator.ParseClaims(ctx, accessToken)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx = contextx.WithUserID(ctx, claims.Subject)
+		ctx = contextx.WithUserName(ctx, claims.Username)
+		ctx = contextx.WithRoles(ctx, claims.Roles)
+		ctx = contextx.WithClaims(ctx, claims)
+		ctx = contextx.WithAccessToken(ctx, accessToken)
+
+		return handler(ctx, req)
+	}
+}
+
+// fromMetadata returns the bearer token of the 'authorization' metadata
+func fromMetadata(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", errorx.ErrTokenInvalid.WithMessage("the `authorization` metadata is missing")
+	}
+
+	accessToken, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || accessToken == "" {
+		return "", errorx.ErrTokenInvalid.WithMessage("the `authorization` metadata is not a bearer token")
+	}
+	return accessToken, nil
+}
@@ -0,0 +1,54 @@
+package interceptor
+
+import (
+	"context"
+
+	"github.com/MortalSC/FastGO/internal/pkg/authz"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"google.golang.org/grpc"
+)
+
+// OwnerFunc resolves the owner user ID of the object addressed by the request
+type OwnerFunc func(ctx context.Context, req any) (string, error)
+
+// Rule is the resource and the action a method performs
+// Owner is nil for methods addressing a collection. Public methods, such as the login, are
+// not authorized and have no resource.
+type Rule struct {
+	Resource string
+	Action   authz.Action
+	Owner    OwnerFunc
+	Public   bool
+}
+
+// Authz authorizes the calls against the policy of the resource of their method, it must
+// run after Authn. rules are keyed by full method name, methods without a rule are denied so
+// that a method added without a rule is never left unprotected.
+func Authz(az *authz.Authorizer, rules map[string]Rule) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		rule, ok := rules[info.FullMethod]
+		if !ok {
+			return nil, errorx.ErrPermissionDenied
+		}
+		if rule.Public {
+			return handler(ctx, req)
+		}
+
+		// The caller is authorized before the error of the owner lookup is returned, so that
+		// the callers who may not access the object are denied alike whether it exists or not
+		var ownerID string
+		var lookupErr error
+		if rule.Owner != nil {
+			ownerID, lookupErr = rule.Owner(ctx, req)
+		}
+
+		if err := az.Authorize(ctx, rule.Resource, rule.Action, ownerID); err != nil {
+			return nil, err
+		}
+		if lookupErr != nil {
+			return nil, lookupErr
+		}
+
+		return handler(ctx, req)
+	}
+}
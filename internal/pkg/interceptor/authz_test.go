@@ -0,0 +1,55 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MortalSC/FastGO/internal/pkg/authz"
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/known"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthz(t *testing.T) {
+	missing := func(context.Context, any) (string, error) { return "", errorx.ErrUserNotFound }
+	rules := map[string]Rule{
+		"/svc/Login":   {Public: true},
+		"/svc/Logout":  {Resource: authz.ResourceSession, Action: authz.ActionDelete},
+		"/svc/GetUser": {Resource: authz.ResourceUser, Action: authz.ActionGet, Owner: missing},
+	}
+	intercept := Authz(authz.NewAuthorizer(), rules)
+
+	anonymous := context.Background()
+	user := contextx.WithRoles(contextx.WithUserID(anonymous, "user-1"), []string{known.RoleUser})
+	admin := contextx.WithRoles(contextx.WithUserID(anonymous, "user-2"), []string{known.RoleAdmin})
+
+	for _, tc := range []struct {
+		name   string
+		ctx    context.Context
+		method string
+		code   codes.Code
+	}{
+		{"methods without a rule are denied", user, "/svc/Unknown", codes.PermissionDenied},
+		{"public methods are not authorized", anonymous, "/svc/Login", codes.OK},
+		{"the session belongs to its user", user, "/svc/Logout", codes.OK},
+		{"anonymous callers have no session", anonymous, "/svc/Logout", codes.PermissionDenied},
+		{"missing objects are denied to users", user, "/svc/GetUser", codes.PermissionDenied},
+		{"missing objects are reported to administrators", admin, "/svc/GetUser", codes.NotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var called bool
+			handler := func(context.Context, any) (any, error) {
+				called = true
+				return nil, nil
+			}
+
+			_, err := intercept(tc.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			assert.Equal(t, tc.code, status.Code(err))
+			assert.Equal(t, tc.code == codes.OK, called)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package interceptor
+
+import (
+	"context"
+	"net"
+
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientIP stores the IP of the peer of the call in the context
+func ClientIP() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+			ip := p.Addr.String()
+			if host, _, err := net.SplitHostPort(ip); err == nil {
+				ip = host
+			}
+			ctx = contextx.WithClientIP(ctx, ip)
+		}
+		return handler(ctx, req)
+	}
+}
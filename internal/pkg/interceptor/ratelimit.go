@@ -0,0 +1,85 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/ratelimit"
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// KeyFunc returns the key whose bucket limits the call
+type KeyFunc func(ctx context.Context, info *grpc.UnaryServerInfo) string
+
+// ByClientIP limits the calls of every client IP, it must run after ClientIP
+// The keys are those of the REST API, so that a client shares its buckets over both servers.
+func ByClientIP(ctx context.Context, _ *grpc.UnaryServerInfo) string {
+	return "ip:" + contextx.ClientIP(ctx)
+}
+
+// ByUser limits the calls of every authenticated user, it must run after Authn
+// Unauthenticated calls are limited by client IP.
+func ByUser(ctx context.Context, info *grpc.UnaryServerInfo) string {
+	if userID := contextx.UserID(ctx); userID != "" {
+		return "user:" + userID
+	}
+	return ByClientIP(ctx, info)
+}
+
+// ByMethod limits the calls of every method, whoever sends them
+func ByMethod(_ context.Context, info *grpc.UnaryServerInfo) string {
+	return "method:" + info.FullMethod
+}
+
+// Limit is the rate limit of a method
+type Limit struct {
+	// Name keeps the buckets of the limit apart from the buckets of the other limits of the store
+	Name  string
+	Limit ratelimit.Limit
+	Key   KeyFunc
+}
+
+// RateLimit rejects the calls exceeding the limit of their method with ResourceExhausted
+// limits are keyed by full method name, methods without a limit are not limited. The
+// responses carry the ratelimit-limit, ratelimit-remaining and ratelimit-reset header
+// metadata, and retry-after when rejected. Calls are allowed when the store fails.
+func RateLimit(store ratelimit.Store, limits map[string]Limit) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		limit, ok := limits[info.FullMethod]
+		if !ok || !limit.Limit.Enabled() {
+			return handler(ctx, req)
+		}
+
+		result, err := store.Take(ctx, limit.Name+":"+limit.Key(ctx, info), limit.Limit)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to take a rate limit token", "limit", limit.Name, "err", err)
+			return handler(ctx, req)
+		}
+
+		md := metadata.Pairs(
+			"ratelimit-limit", strconv.Itoa(result.Limit),
+			"ratelimit-remaining", strconv.Itoa(result.Remaining),
+			"ratelimit-reset", ceilSeconds(result.ResetAfter),
+		)
+		if !result.Allowed {
+			md.Set("retry-after", ceilSeconds(result.RetryAfter))
+		}
+		_ = grpc.SetHeader(ctx, md)
+
+		if !result.Allowed {
+			return nil, errorx.ErrTooManyRequests
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ceilSeconds formats d as a number of seconds, rounded up so that clients do not retry too early
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,57 @@
+package interceptor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/ratelimit"
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	twice := ratelimit.Limit{Requests: 2, Period: time.Hour}
+	intercept := RateLimit(store, map[string]Limit{
+		"/svc/Login":   {Name: "auth", Limit: twice, Key: ByClientIP},
+		"/svc/GetUser": {Name: "api", Limit: twice, Key: ByUser},
+		"/svc/Health":  {Name: "health", Key: ByMethod},
+	})
+
+	call := func(ctx context.Context, method string) codes.Code {
+		handler := func(context.Context, any) (any, error) { return nil, nil }
+		_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return status.Code(err)
+	}
+
+	client := contextx.WithClientIP(context.Background(), "192.0.2.1")
+	other := contextx.WithClientIP(context.Background(), "192.0.2.2")
+	for range 2 {
+		assert.Equal(t, codes.OK, call(client, "/svc/Login"))
+	}
+	assert.Equal(t, codes.ResourceExhausted, call(client, "/svc/Login"))
+	assert.Equal(t, codes.OK, call(other, "/svc/Login"))
+
+	// The limits of the users are apart from those of their client IP
+	alice, bob := contextx.WithUserID(client, "user-1"), contextx.WithUserID(client, "user-2")
+	for range 2 {
+		assert.Equal(t, codes.OK, call(alice, "/svc/GetUser"))
+	}
+	assert.Equal(t, codes.ResourceExhausted, call(alice, "/svc/GetUser"))
+	assert.Equal(t, codes.OK, call(bob, "/svc/GetUser"))
+
+	// Methods without a limit, or with a disabled one, are not limited
+	for range 3 {
+		assert.Equal(t, codes.OK, call(client, "/svc/Health"))
+		assert.Equal(t, codes.OK, call(client, "/svc/Unknown"))
+	}
+
+	// The buckets are keyed like those of the REST API
+	assert.Equal(t, "ip:192.0.2.1", ByClientIP(client, nil))
+	assert.Equal(t, "user:user-1", ByUser(alice, nil))
+	assert.Equal(t, "method:/svc/Login", ByMethod(client, &grpc.UnaryServerInfo{FullMethod: "/svc/Login"}))
+}
@@ -0,0 +1,24 @@
+package interceptor
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"google.golang.org/grpc"
+)
+
+// Recovery turns panics of the handlers into internal errors
+func Recovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("Recovered from panic", "method", info.FullMethod, "panic", r, "stack", string(debug.Stack()))
+				err = errorx.ErrInternal
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,39 @@
+// Package interceptor holds the gRPC interceptors of the apiserver, which are the gRPC
+// counterparts of the Gin middlewares in internal/pkg/middleware.
+package interceptor
+
+import (
+	"context"
+
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/known"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestID reads the 'x-request-id' metadata of the call, or generates a new one, stores it
+// in the context, returns it in the response header and adds it to the metadata of errors
+func RequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var requestID string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(known.XRequestID); len(values) > 0 {
+				requestID = values[0]
+			}
+		}
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		ctx = contextx.WithRequestID(ctx, requestID)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(known.XRequestID, requestID))
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, errorx.FromError(err).WithRequestID(requestID)
+		}
+		return resp, nil
+	}
+}
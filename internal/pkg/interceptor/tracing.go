@@ -0,0 +1,64 @@
+package interceptor
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataCarrier adapts the metadata of a call to the propagation.TextMapCarrier interface
+type metadataCarrier metadata.MD
+
+func (mc metadataCarrier) Get(key string) string {
+	if values := metadata.MD(mc).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (mc metadataCarrier) Set(key, value string) {
+	metadata.MD(mc).Set(key, value)
+}
+
+func (mc metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(mc))
+	for key := range mc {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Tracing starts the span of the call, continuing the trace of the 'traceparent' metadata
+// It must run after RequestID, which is recorded on the span
+func Tracing() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		}
+
+		ctx, span := tracing.StartServer(ctx, info.FullMethod,
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", info.FullMethod),
+			attribute.String("request.id", contextx.RequestID(ctx)),
+		)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			errx := errorx.FromError(err)
+			span.SetAttributes(attribute.String("error.reason", errx.Reason))
+			// Like the HTTP spans, only server errors fail the span
+			if errx.Code >= http.StatusInternalServerError {
+				tracing.RecordError(span, err)
+			}
+		}
+		return resp, err
+	}
+}
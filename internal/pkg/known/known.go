@@ -8,6 +8,14 @@ const (
 	XUserID = "x-user-id"
 )
 
+const (
+	// RoleAdmin is the role of administrators, who may read and modify every resource
+	RoleAdmin = "admin"
+
+	// RoleUser is the default role, which may only read and modify owned resources
+	RoleUser = "user"
+)
+
 const (
 	// MaxErrGroupConcurrency is the maximum concurrency for errgroup
 	// It is used to limit the number of goroutines running simultaneously in the errgroup, thereby preventing resource exhaustion and enhancing the stability of the program.
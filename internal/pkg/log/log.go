@@ -0,0 +1,190 @@
+// Package log configures the default slog logger of fastgo.
+//
+// The records logged with a context, e.g. slog.InfoContext(ctx, ...), carry the request ID,
+// the user ID and the trace of the request found in the context, so the logs of concurrent
+// requests can be told apart. The level can be changed while the server runs.
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Formats of Options.Format
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures the default logger
+type Options struct {
+	// Format is text or json
+	Format string `json:"format" mapstructure:"format"`
+	// Level is the minimum level logged: debug, info, warn or error
+	Level string `json:"level" mapstructure:"level"`
+	// Output is stdout, stderr or the path of a log file, which is rotated
+	Output string `json:"output" mapstructure:"output"`
+	// MaxSize is the size in megabytes at which the log file is rotated
+	MaxSize int `json:"max-size" mapstructure:"max-size"`
+	// MaxBackups is the number of rotated files kept, 0 keeps them all
+	MaxBackups int `json:"max-backups" mapstructure:"max-backups"`
+	// MaxAge is the number of days the rotated files are kept, 0 keeps them forever
+	MaxAge int `json:"max-age" mapstructure:"max-age"`
+	// Compress gzips the rotated files
+	Compress bool `json:"compress" mapstructure:"compress"`
+}
+
+// NewOptions creates an Options instance with default values
+func NewOptions() *Options {
+	return &Options{
+		Format:     FormatText,
+		Level:      "info",
+		Output:     "stdout",
+		MaxSize:    100,
+		MaxBackups: 10,
+		MaxAge:     30,
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *Options) Validate() error {
+	if o.Format != FormatText && o.Format != FormatJSON {
+		return fmt.Errorf("invalid log format '%s', must be text or json", o.Format)
+	}
+	if _, err := ParseLevel(o.Level); err != nil {
+		return err
+	}
+	if o.MaxSize < 0 || o.MaxBackups < 0 || o.MaxAge < 0 {
+		return fmt.Errorf("log max-size, max-backups and max-age must not be negative")
+	}
+	return nil
+}
+
+// File returns the path of the log file, or an empty string when logging to stdout or stderr
+func (o *Options) File() string {
+	if o == nil {
+		return ""
+	}
+	switch o.Output {
+	case "", "stdout", "stderr":
+		return ""
+	default:
+		return o.Output
+	}
+}
+
+// level is the level of the default logger, shared by Init and SetLevel
+var level = new(slog.LevelVar)
+
+// Init replaces the default slog logger with a logger configured by opts
+func Init(opts *Options) error {
+	lvl, err := ParseLevel(opts.Level)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+
+	var w io.Writer
+	switch opts.Output {
+	case "", "stdout":
+		w = os.Stdout
+	case "stderr":
+		w = os.Stderr
+	default:
+		w = &lumberjack.Logger{
+			Filename:   opts.File(),
+			MaxSize:    opts.MaxSize,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAge,
+			Compress:   opts.Compress,
+			LocalTime:  true,
+		}
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch opts.Format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	default:
+		handler = slog.NewTextHandler(w, handlerOpts)
+	}
+
+	slog.SetDefault(slog.New(NewHandler(handler)))
+	return nil
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var lvl slog.Level
+	switch strings.ToLower(name) {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "info", "":
+		lvl = slog.LevelInfo
+	case "warn":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return lvl, fmt.Errorf("invalid log level '%s', must be one of debug, info, warn or error", name)
+	}
+	return lvl, nil
+}
+
+// Level returns the name of the current level
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// SetLevel changes the level of the default logger
+func SetLevel(name string) error {
+	lvl, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(lvl)
+	return nil
+}
+
+// contextHandler adds the request fields of the context to the records
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewHandler wraps h so that the records logged with a context carry the request_id,
+// user_id, trace_id and span_id found in the context
+func NewHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h}
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID := contextx.RequestID(ctx); requestID != "" {
+		r.AddAttrs(slog.String("request_id", requestID))
+	}
+	if userID := contextx.UserID(ctx); userID != "" {
+		r.AddAttrs(slog.String("user_id", userID))
+	}
+	if traceID := contextx.TraceID(ctx); traceID != "" {
+		r.AddAttrs(slog.String("trace_id", traceID), slog.String("span_id", contextx.SpanID(ctx)))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
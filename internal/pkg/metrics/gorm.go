@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// startTimeKey is the key of the statement start time in the gorm instance
+const startTimeKey = "fastgo:metrics:start"
+
+// gormPlugin records the duration and the failures of every statement run by gorm
+type gormPlugin struct{}
+
+// NewGormPlugin creates the gorm plugin recording DBQueryDuration and DBQueryErrors
+func NewGormPlugin() gorm.Plugin {
+	return gormPlugin{}
+}
+
+// Name implements gorm.Plugin
+func (gormPlugin) Name() string {
+	return "fastgo:metrics"
+}
+
+// Initialize implements gorm.Plugin, it wraps the callbacks of every operation
+func (p gormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	type register func(name string, fn func(*gorm.DB)) error
+	for _, op := range []struct {
+		name          string
+		before, after register
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	} {
+		if err := op.before(p.Name()+":before_"+op.name, before); err != nil {
+			return err
+		}
+		if err := op.after(p.Name()+":after_"+op.name, after(op.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before records the start time of a statement
+func before(db *gorm.DB) {
+	db.InstanceSet(startTimeKey, time.Now())
+}
+
+// after records the duration of a statement, and its failure
+func after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(startTimeKey)
+		if !ok {
+			return
+		}
+		start, _ := v.(time.Time)
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		DBQueryDuration.WithLabelValues(table, operation).Observe(time.Since(start).Seconds())
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			DBQueryErrors.WithLabelValues(table, operation).Inc()
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package metrics defines the Prometheus metrics of fastgo and the registry serving them.
+//
+// The collectors are process wide, so that every component records into the same series
+// without threading a registry through the constructors.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/MortalSC/FastGO/pkg/version"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes the names of all fastgo metrics
+const namespace = "fastgo"
+
+// Registry holds the fastgo metrics together with the Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts the handled HTTP requests by method, route and status code
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of handled HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "code"})
+
+	// HTTPRequestDuration observes the latency of the HTTP requests by method and route
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of the HTTP requests by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// HTTPRequestsInFlight is the number of HTTP requests being handled
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests being handled.",
+	})
+
+	// DBQueryDuration observes the duration of the database statements by table and operation
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of the database statements by table and operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"table", "operation"})
+
+	// DBQueryErrors counts the failed database statements by table and operation,
+	// a record not found is not a failure
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Number of failed database statements by table and operation.",
+	}, []string{"table", "operation"})
+
+	// LoginAttempts counts the login attempts by result, which is success or failure
+	LoginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "login_attempts_total",
+		Help:      "Number of login attempts by result.",
+	}, []string{"result"})
+
+	// buildInfo exposes the version of the running binary in its labels
+	buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Version information of the running binary, the value is always 1.",
+	}, []string{"git_version", "git_commit", "git_tree_state", "build_date", "go_version", "platform"})
+)
+
+// Results of LoginAttempts
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+func init() {
+	info := version.Get()
+	buildInfo.WithLabelValues(info.GitVersion, info.GitCommit, info.GitTreeState, info.BuildDate, info.GoVersion, info.Platform).Set(1)
+
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		DBQueryDuration,
+		DBQueryErrors,
+		LoginAttempts,
+		buildInfo,
+	)
+}
+
+// Handler serves the metrics of Registry in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDBStats exposes the connection pool statistics of db labelled with dbName,
+// the pool registered before under the same name is replaced
+func RegisterDBStats(db *sql.DB, dbName string) {
+	c := collectors.NewDBStatsCollector(db, dbName)
+	// Unregister removes the collector describing the same metrics, i.e. of the same name
+	Registry.Unregister(c)
+	Registry.MustRegister(c)
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"github.com/MortalSC/FastGO/internal/commonpkg/authn"
 	"github.com/MortalSC/FastGO/internal/commonpkg/core"
 	"github.com/MortalSC/FastGO/internal/pkg/contextx"
 	"github.com/MortalSC/FastGO/internal/pkg/errorx"
@@ -8,16 +9,29 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Authn() gin.HandlerFunc {
+// Authn authenticates the bearer access token of the request
+// Tokens whose session was revoked are rejected even though their signature is valid
+func Authn(authenticator authn.AuthenTicator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		userID, err := token.ParseRequest(c)
+		accessToken, err := token.FromRequest(c)
 		if err != nil {
-			core.WriteResponse(c, nil, errorx.ErrTokenInvalid.WithMessage(err.Error()))
+			core.WriteResponse(c, nil, errorx.ErrTokenInvalid.WithMessage("%v", err))
 			c.Abort()
 			return
 		}
 
-		ctx := contextx.WithUserID(c.Request.Context(), userID)
+		claims, err := authenticator.ParseClaims(c.Request.Context(), accessToken)
+		if err != nil {
+			core.WriteResponse(c, nil, err)
+			c.Abort()
+			return
+		}
+
+		ctx := contextx.WithUserID(c.Request.Context(), claims.Subject)
+		ctx = contextx.WithUserName(ctx, claims.Username)
+		ctx = contextx.WithRoles(ctx, claims.Roles)
+		ctx = contextx.WithClaims(ctx, claims)
+		ctx = contextx.WithAccessToken(ctx, accessToken)
 		c.Request = c.Request.WithContext(ctx)
 
 		c.Next()
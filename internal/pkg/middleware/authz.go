@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/MortalSC/FastGO/internal/commonpkg/core"
+	"github.com/MortalSC/FastGO/internal/pkg/authz"
+	"github.com/gin-gonic/gin"
+)
+
+// OwnerFunc resolves the owner user ID of the object addressed by the request
+type OwnerFunc func(c *gin.Context) (string, error)
+
+// Authz authorizes the request against the policy of resource, it must run after Authn
+// owner is nil for routes addressing a collection. The caller is authorized before the error
+// of the owner lookup is written, so that the callers who may not access the object are
+// denied alike whether it exists or not.
+func Authz(az *authz.Authorizer, resource string, action authz.Action, owner OwnerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ownerID string
+		var lookupErr error
+		if owner != nil {
+			ownerID, lookupErr = owner(c)
+		}
+
+		if err := az.Authorize(c.Request.Context(), resource, action, ownerID); err != nil {
+			core.WriteResponse(c, nil, err)
+			c.Abort()
+			return
+		}
+		if lookupErr != nil {
+			core.WriteResponse(c, nil, lookupErr)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
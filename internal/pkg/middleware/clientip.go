@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/gin-gonic/gin"
+)
+
+// ClientIP stores the IP of the client in the context, as resolved by Gin from the trusted proxies
+func ClientIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(contextx.WithClientIP(c.Request.Context(), c.ClientIP()))
+		c.Next()
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/commonpkg/core"
+	"github.com/MortalSC/FastGO/internal/commonpkg/ratelimit"
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyFunc returns the key whose bucket limits the request
+type KeyFunc func(c *gin.Context) string
+
+// ByClientIP limits the requests of every client IP
+func ByClientIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser limits the requests of every authenticated user, it must run after Authn
+// Unauthenticated requests are limited by client IP.
+func ByUser(c *gin.Context) string {
+	if userID := contextx.UserID(c.Request.Context()); userID != "" {
+		return "user:" + userID
+	}
+	return ByClientIP(c)
+}
+
+// ByRoute limits the requests of every route, whoever sends them
+func ByRoute(c *gin.Context) string {
+	return "route:" + c.Request.Method + " " + c.FullPath()
+}
+
+// RateLimit rejects the requests exceeding limit with 429 Too Many Requests
+// The buckets of name are kept apart from the buckets of the other limits of the store. The
+// responses carry the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and
+// Retry-After when rejected. Requests are allowed when the store fails.
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit, key KeyFunc) gin.HandlerFunc {
+	if !limit.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		result, err := store.Take(ctx, name+":"+key(c), limit)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to take a rate limit token", "limit", name, "err", err)
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", ceilSeconds(result.ResetAfter))
+
+		if !result.Allowed {
+			header.Set("Retry-After", ceilSeconds(result.RetryAfter))
+			core.WriteResponse(c, nil, errorx.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds formats d as a number of seconds, rounded up so that clients do not retry too early
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,41 @@
+// Package tracing starts the OpenTelemetry spans of fastgo.
+//
+// The spans are recorded by the global tracer provider, which records nothing until the
+// apiserver installs the provider of its TracingOptions.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the fastgo spans
+const tracerName = "github.com/MortalSC/FastGO"
+
+// otelTracer returns the tracer of the global tracer provider, which may be replaced at startup
+func otelTracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start starts a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otelTracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartServer starts the span of a request received by the server
+func StartServer(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otelTracer().Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+// RecordError marks span as failed by err, nil errors are ignored
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
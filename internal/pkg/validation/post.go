@@ -7,11 +7,17 @@ import (
 )
 
 func (v *Validator) ValidateCreatePostRequest(ctx context.Context, req *v1.CreatePostRequest) error {
-	return nil
+	var errs FieldErrors
+	errs.Check("title", req.Title, Required, Length(1, maxTitleLength))
+	errs.Check("content", req.Content, MaxBytes(maxContentLength))
+	return errs.Err()
 }
 
 func (v *Validator) ValidateUpdatePostRequest(ctx context.Context, req *v1.UpdatePostRequest) error {
-	return nil
+	var errs FieldErrors
+	errs.CheckOptional("title", req.Title, Required, Length(1, maxTitleLength))
+	errs.CheckOptional("content", req.Content, MaxBytes(maxContentLength))
+	return errs.Err()
 }
 
 func (v *Validator) ValidateDeletePostRequest(ctx context.Context, req *v1.DeletePostRequest) error {
@@ -23,5 +29,7 @@ func (v *Validator) ValidateGetPostRequest(ctx context.Context, req *v1.GetPostR
 }
 
 func (v *Validator) ValidateListPostRequest(ctx context.Context, req *v1.ListPostRequest) error {
-	return nil
+	var errs FieldErrors
+	validatePage(&errs, req.Offset, req.Cursor, req.Sort)
+	return errs.Err()
 }
@@ -0,0 +1,168 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+)
+
+// Limits of the request fields, matching the columns of configs/migrations
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 20
+	maxNicknameLength = 30
+	maxEmailLength    = 256
+	maxTitleLength    = 256
+	// maxContentLength bounds post contents, the longtext column itself allows up to 4 GiB
+	maxContentLength = 1 << 20
+)
+
+var (
+	usernameRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+	// phoneRegexp accepts E.164 numbers, with or without the leading +
+	phoneRegexp = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+)
+
+// Rule checks a value and returns why it is invalid, or an empty string when it is valid
+type Rule func(value string) string
+
+// Required rejects empty and blank values
+func Required(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "is required"
+	}
+	return ""
+}
+
+// Length rejects values with fewer than min or more than max characters
+func Length(min, max int) Rule {
+	return func(value string) string {
+		if n := utf8.RuneCountInString(value); n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d characters long", min, max)
+		}
+		return ""
+	}
+}
+
+// MaxBytes rejects values larger than max bytes
+func MaxBytes(max int) Rule {
+	return func(value string) string {
+		if len(value) > max {
+			return fmt.Sprintf("must not exceed %d bytes", max)
+		}
+		return ""
+	}
+}
+
+// Match rejects values which do not match re
+func Match(re *regexp.Regexp, message string) Rule {
+	return func(value string) string {
+		if !re.MatchString(value) {
+			return message
+		}
+		return ""
+	}
+}
+
+// Email rejects malformed email addresses
+func Email(value string) string {
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Address != value {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+// Phone rejects malformed phone numbers
+func Phone(value string) string {
+	return Match(phoneRegexp, "must be a phone number of 7 to 15 digits")(value)
+}
+
+// Username enforces the username policy of errorx.ErrUserNameInvalid
+func Username(value string) string {
+	if msg := Length(minUsernameLength, maxUsernameLength)(value); msg != "" {
+		return msg
+	}
+	return Match(usernameRegexp, "must consist of letters, digits and underscores only")(value)
+}
+
+// FieldErrors collects the validation failures of a request, keeping the first failure of each field
+type FieldErrors struct {
+	fields   []string
+	messages map[string]string
+	// reasons are the typed errors of the failed fields, errorx.ErrInvalidArgument if unset
+	reasons map[string]*errorx.ErrorX
+}
+
+// Check applies the rules to the value of field, up to the first failing rule
+func (e *FieldErrors) Check(field, value string, rules ...Rule) {
+	for _, rule := range rules {
+		if msg := rule(value); msg != "" {
+			e.Add(field, msg)
+			return
+		}
+	}
+}
+
+// CheckOptional applies the rules to the value of field when it is set
+func (e *FieldErrors) CheckOptional(field string, value *string, rules ...Rule) {
+	if value != nil {
+		e.Check(field, *value, rules...)
+	}
+}
+
+// Add records a failure of field, unless the field already failed
+func (e *FieldErrors) Add(field, message string) {
+	if e.messages == nil {
+		e.messages = make(map[string]string)
+	}
+	if _, ok := e.messages[field]; ok {
+		return
+	}
+	e.fields = append(e.fields, field)
+	e.messages[field] = message
+}
+
+// Reason reports the failure of field, if it failed, with reason instead of errorx.ErrInvalidArgument
+func (e *FieldErrors) Reason(field string, reason *errorx.ErrorX) {
+	if !e.Failed(field) {
+		return
+	}
+	if e.reasons == nil {
+		e.reasons = make(map[string]*errorx.ErrorX)
+	}
+	e.reasons[field] = reason
+}
+
+// Failed reports whether field already failed
+func (e *FieldErrors) Failed(field string) bool {
+	_, ok := e.messages[field]
+	return ok
+}
+
+// Err returns nil if every field is valid, otherwise the typed error of the first failed field
+// with a Reason, or errorx.ErrInvalidArgument, whose metadata maps every failed field to the
+// reason of its failure
+func (e *FieldErrors) Err() error {
+	if len(e.fields) == 0 {
+		return nil
+	}
+
+	var reason *errorx.ErrorX
+	parts := make([]string, 0, len(e.fields))
+	for _, field := range e.fields {
+		parts = append(parts, field+" "+e.messages[field])
+		if reason == nil {
+			reason = e.reasons[field]
+		}
+	}
+	if reason == nil {
+		reason = errorx.ErrInvalidArgument
+	}
+
+	return reason.WithMessage("%s", strings.Join(parts, "; ")).WithMetadata(e.messages)
+}
@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	for _, tc := range []struct {
+		rule  Rule
+		value string
+		valid bool
+	}{
+		{Required, "go", true},
+		{Required, "", false},
+		{Required, " \t", false},
+		{Length(2, 3), "日本", true},
+		{Length(2, 3), "日本語!", false},
+		{Length(2, 3), "a", false},
+		{MaxBytes(4), "abcd", true},
+		{MaxBytes(4), "日本", false},
+		{Match(regexp.MustCompile(`^a+$`), "only a"), "aaa", true},
+		{Match(regexp.MustCompile(`^a+$`), "only a"), "ab", false},
+		{Email, "alice@example.com", true},
+		{Email, "Alice <alice@example.com>", false},
+		{Email, "alice", false},
+		{Phone, "+8618800000000", true},
+		{Phone, "18800000000", true},
+		{Phone, "188-0000-0000", false},
+		{Phone, "123456", false},
+		{Username, "alice_01", true},
+		{Username, "al", false},
+		{Username, strings.Repeat("a", 21), false},
+		{Username, "alice-01", false},
+	} {
+		msg := tc.rule(tc.value)
+		assert.Equal(t, tc.valid, msg == "", "%q: %s", tc.value, msg)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	var errs FieldErrors
+	assert.NoError(t, errs.Err())
+
+	// The first failing rule of a field is reported, and the first failure of a field is kept
+	errs.Check("username", "", Required, Username)
+	errs.Check("username", "al", Username)
+	errs.Add("username", "is already taken")
+	errs.Check("email", "alice@example.com", Required, Email)
+	errs.CheckOptional("nickname", nil, Required)
+	long := strings.Repeat("n", 31)
+	errs.CheckOptional("nickname", &long, Length(0, maxNicknameLength))
+	assert.True(t, errs.Failed("username"))
+	assert.False(t, errs.Failed("email"))
+
+	err := errs.Err()
+	assert.ErrorIs(t, err, errorx.ErrInvalidArgument)
+	var errx *errorx.ErrorX
+	require.ErrorAs(t, err, &errx)
+	assert.Equal(t, "username is required; nickname must be between 0 and 30 characters long", errx.Message)
+	assert.Equal(t, map[string]string{
+		"username": "is required",
+		"nickname": "must be between 0 and 30 characters long",
+	}, errx.Metadata)
+
+	// The typed reason of the first failed field having one is reported
+	errs.Reason("email", errorx.ErrUserAlreadyExists)
+	errs.Reason("nickname", errorx.ErrUserAlreadyExists)
+	assert.ErrorIs(t, errs.Err(), errorx.ErrUserAlreadyExists)
+	errs.Reason("username", errorx.ErrUserNameInvalid)
+	err = errs.Err()
+	assert.ErrorIs(t, err, errorx.ErrUserNameInvalid)
+	require.ErrorAs(t, err, &errx)
+	assert.Len(t, errx.Metadata, 2)
+}
@@ -2,16 +2,71 @@ package validation
 
 import (
 	"context"
+	"slices"
 
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/internal/pkg/known"
 	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
 )
 
 func (v *Validator) ValidateCreateUserRequest(ctx context.Context, req *v1.CreateUserRequest) error {
-	return nil
+	var errs FieldErrors
+	errs.Check("username", req.Username, Required, Username)
+	errs.Check("password", req.Password, Required, v.password)
+	errs.CheckOptional("nickname", req.Nickname, Length(0, maxNicknameLength))
+	errs.Check("email", req.Email, Required, Length(0, maxEmailLength), Email)
+	errs.Check("phone", req.Phone, Required, Phone)
+	errs.Reason("username", errorx.ErrUserNameInvalid)
+	errs.Reason("password", errorx.ErrPasswordInvalid)
+
+	if err := v.checkUnique(ctx, &errs, "username", "username", req.Username, ""); err != nil {
+		return err
+	}
+	if err := v.checkUnique(ctx, &errs, "phone", "phone", req.Phone, ""); err != nil {
+		return err
+	}
+
+	return errs.Err()
 }
 
 func (v *Validator) ValidateUpdateUserRequest(ctx context.Context, req *v1.UpdateUserRequest) error {
-	return nil
+	var errs FieldErrors
+	errs.CheckOptional("username", req.Username, Required, Username)
+	errs.CheckOptional("nickname", req.Nickname, Length(0, maxNicknameLength))
+	errs.CheckOptional("email", req.Email, Required, Length(0, maxEmailLength), Email)
+	errs.CheckOptional("phone", req.Phone, Required, Phone)
+	if req.Role != nil && !slices.Contains([]string{known.RoleAdmin, known.RoleUser}, *req.Role) {
+		errs.Add("role", "must be one of: "+known.RoleAdmin+", "+known.RoleUser)
+	}
+	errs.Reason("username", errorx.ErrUserNameInvalid)
+
+	if req.Username == nil && req.Phone == nil {
+		return errs.Err()
+	}
+
+	// The updated user keeps its own username and phone
+	idOrName := req.UserID
+	if idOrName == "" {
+		idOrName = contextx.UserID(ctx)
+	}
+	user, err := v.store.User().GetByIDOrName(ctx, idOrName)
+	if err != nil {
+		// The business layer reports the missing user
+		return errs.Err()
+	}
+	if req.Username != nil {
+		if err := v.checkUnique(ctx, &errs, "username", "username", *req.Username, user.UserID); err != nil {
+			return err
+		}
+	}
+	if req.Phone != nil {
+		if err := v.checkUnique(ctx, &errs, "phone", "phone", *req.Phone, user.UserID); err != nil {
+			return err
+		}
+	}
+
+	return errs.Err()
 }
 
 func (v *Validator) ValidateDeleteUserRequest(ctx context.Context, req *v1.DeleteUserRequest) error {
@@ -22,19 +77,44 @@ func (v *Validator) ValidateGetUserRequest(ctx context.Context, req *v1.GetUserR
 	return nil
 }
 
-func (v *Validator) ValidateListUserRequest(ctx context.Context, req *v1.ListUserRequest) error {
+func (v *Validator) ValidateGetUserLockRequest(ctx context.Context, req *v1.GetUserLockRequest) error {
+	return nil
+}
+
+func (v *Validator) ValidateUnlockUserRequest(ctx context.Context, req *v1.UnlockUserRequest) error {
 	return nil
 }
 
+func (v *Validator) ValidateListUserRequest(ctx context.Context, req *v1.ListUserRequest) error {
+	var errs FieldErrors
+	validatePage(&errs, req.Offset, req.Cursor, req.Sort)
+	return errs.Err()
+}
+
 // ======= login with token ========
 func (v *Validator) ValidateLoginRequest(ctx context.Context, req *v1.LoginRequest) error {
-	return nil
+	var errs FieldErrors
+	errs.Check("username", req.Username, Required)
+	errs.Check("password", req.Password, Required)
+	return errs.Err()
 }
 
 func (v *Validator) ValidateRefreshTokenRequest(ctx context.Context, req *v1.RefreshTokenRequest) error {
+	var errs FieldErrors
+	errs.Check("refresh_token", req.RefreshToken, Required)
+	return errs.Err()
+}
+
+func (v *Validator) ValidateLogoutRequest(ctx context.Context, req *v1.LogoutRequest) error {
 	return nil
 }
 
 func (v *Validator) ValidateChangePasswordRequest(ctx context.Context, req *v1.ChangePasswordRequest) error {
-	return nil
+	var errs FieldErrors
+	errs.Check("new_password", req.NewPassword, Required, v.password)
+	if req.NewPassword == req.OldPassword {
+		errs.Add("new_password", "must differ from the old password")
+	}
+	errs.Reason("new_password", errorx.ErrPasswordInvalid)
+	return errs.Err()
 }
@@ -1,13 +1,68 @@
 package validation
 
-import "github.com/MortalSC/FastGO/internal/apiserver/store"
+import (
+	"context"
+	"errors"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	"github.com/MortalSC/FastGO/pkg/auth"
+)
 
 type Validator struct {
 	store store.IStore
+	// passwordPolicy vets the passwords chosen by the users
+	passwordPolicy auth.Policy
 }
 
-func NewValidation(store store.IStore) *Validator {
+// NewValidation creates a Validator enforcing passwordPolicy on new passwords, or
+// auth.DefaultPolicy if nil
+func NewValidation(store store.IStore, passwordPolicy auth.Policy) *Validator {
+	if passwordPolicy == nil {
+		passwordPolicy = auth.DefaultPolicy
+	}
 	return &Validator{
-		store: store,
+		store:          store,
+		passwordPolicy: passwordPolicy,
+	}
+}
+
+// password is the Rule of the password policy
+func (v *Validator) password(value string) string {
+	return v.passwordPolicy.Check(value)
+}
+
+// validatePage checks the pagination parameters of list requests
+func validatePage(errs *FieldErrors, offset int64, cursor, sort string) {
+	if offset != 0 && cursor != "" {
+		errs.Add("cursor", "cannot be combined with offset")
+	}
+	if sort != "" && cursor != "" {
+		errs.Add("cursor", "requires the default sort")
+	}
+	if _, err := where.ParseCursor(cursor); err != nil {
+		errs.Add("cursor", "is invalid")
+	}
+}
+
+// checkUnique records an errorx.ErrUserAlreadyExists failure of field when a user other than
+// userID already has value in column
+func (v *Validator) checkUnique(ctx context.Context, errs *FieldErrors, field, column, value, userID string) error {
+	if errs.Failed(field) {
+		return nil
+	}
+
+	user, err := v.store.User().Get(ctx, where.F(column, value))
+	if err != nil {
+		if errors.Is(err, errorx.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.UserID != userID {
+		errs.Add(field, "is already taken")
+		errs.Reason(field, errorx.ErrUserAlreadyExists)
 	}
+	return nil
 }
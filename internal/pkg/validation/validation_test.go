@@ -0,0 +1,206 @@
+package validation
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/MortalSC/FastGO/internal/apiserver/model"
+	"github.com/MortalSC/FastGO/internal/apiserver/store"
+	"github.com/MortalSC/FastGO/internal/commonpkg/where"
+	"github.com/MortalSC/FastGO/internal/pkg/contextx"
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/MortalSC/FastGO/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore holds the users looked up by the uniqueness checks
+type fakeStore struct {
+	store.IStore
+	users fakeUserStore
+}
+
+func (s *fakeStore) User() store.UserStore {
+	return &s.users
+}
+
+type fakeUserStore struct {
+	store.UserStore
+	users []*model.User
+	// err fails every lookup
+	err error
+}
+
+func (s *fakeUserStore) Get(_ context.Context, opts *where.Options) (*model.User, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	for _, user := range s.users {
+		if opts.Filters["username"] == user.Username || opts.Filters["phone"] == user.Phone {
+			return user, nil
+		}
+	}
+	return nil, errorx.ErrUserNotFound
+}
+
+func (s *fakeUserStore) GetByIDOrName(_ context.Context, idOrName string) (*model.User, error) {
+	for _, user := range s.users {
+		if user.UserID == idOrName || user.Username == idOrName {
+			return user, nil
+		}
+	}
+	return nil, errorx.ErrUserNotFound
+}
+
+func newTestValidator() (*Validator, *fakeStore) {
+	s := &fakeStore{users: fakeUserStore{users: []*model.User{
+		{UserID: "user-1", Username: "alice", Phone: "18800000001"},
+		{UserID: "user-2", Username: "bob", Phone: "18800000002"},
+	}}}
+	return NewValidation(s, nil), s
+}
+
+// failedFields returns the fields reported by the error of a validation, sorted
+func failedFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+
+	var errx *errorx.ErrorX
+	require.ErrorAs(t, err, &errx)
+	require.Equal(t, http.StatusBadRequest, errx.Code, err)
+	fields := make([]string, 0, len(errx.Metadata))
+	for field := range errx.Metadata {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestValidateCreateUserRequest(t *testing.T) {
+	v, s := newTestValidator()
+	ctx := context.Background()
+	valid := v1.CreateUserRequest{Username: "carol", Password: "fastgo1234", Email: "carol@example.com", Phone: "18800000003"}
+
+	assert.NoError(t, v.ValidateCreateUserRequest(ctx, &valid))
+
+	for _, tc := range []struct {
+		name   string
+		mutate func(*v1.CreateUserRequest)
+		reason *errorx.ErrorX
+		fields []string
+	}{
+		{"everything is missing", func(r *v1.CreateUserRequest) { *r = v1.CreateUserRequest{} }, errorx.ErrUserNameInvalid, []string{"email", "password", "phone", "username"}},
+		{"invalid username", func(r *v1.CreateUserRequest) { r.Username = "carol!" }, errorx.ErrUserNameInvalid, []string{"username"}},
+		{"weak password", func(r *v1.CreateUserRequest) { r.Password = "fastgo" }, errorx.ErrPasswordInvalid, []string{"password"}},
+		{"long nickname", func(r *v1.CreateUserRequest) { r.Nickname = ptr(strings.Repeat("n", 31)) }, errorx.ErrInvalidArgument, []string{"nickname"}},
+		{"taken username", func(r *v1.CreateUserRequest) { r.Username = "alice" }, errorx.ErrUserAlreadyExists, []string{"username"}},
+		{"taken phone", func(r *v1.CreateUserRequest) { r.Phone = "18800000002" }, errorx.ErrUserAlreadyExists, []string{"phone"}},
+		{"taken phone and long nickname", func(r *v1.CreateUserRequest) { r.Phone, r.Nickname = "18800000002", ptr(strings.Repeat("n", 31)) },
+			errorx.ErrUserAlreadyExists, []string{"nickname", "phone"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid
+			tc.mutate(&req)
+			err := v.ValidateCreateUserRequest(ctx, &req)
+			assert.ErrorIs(t, err, tc.reason)
+			assert.Equal(t, tc.fields, failedFields(t, err))
+		})
+	}
+
+	// The failures of the store are not validation failures
+	s.users.err = errorx.ErrInternal
+	assert.ErrorIs(t, v.ValidateCreateUserRequest(ctx, &valid), errorx.ErrInternal)
+}
+
+func TestValidateUpdateUserRequest(t *testing.T) {
+	v, _ := newTestValidator()
+	ctx := contextx.WithUserID(context.Background(), "user-1")
+
+	for _, tc := range []struct {
+		name   string
+		req    v1.UpdateUserRequest
+		reason *errorx.ErrorX
+		fields []string
+	}{
+		{"nothing changes", v1.UpdateUserRequest{}, nil, nil},
+		{"own username and phone", v1.UpdateUserRequest{Username: ptr("alice"), Phone: ptr("18800000001")}, nil, nil},
+		{"username of another user", v1.UpdateUserRequest{UserID: "bob", Username: ptr("alice")}, errorx.ErrUserAlreadyExists, []string{"username"}},
+		{"phone of another user", v1.UpdateUserRequest{Phone: ptr("18800000002")}, errorx.ErrUserAlreadyExists, []string{"phone"}},
+		{"invalid fields", v1.UpdateUserRequest{Username: ptr(""), Email: ptr("alice"), Role: ptr("root")}, errorx.ErrUserNameInvalid, []string{"email", "role", "username"}},
+		{"invalid email", v1.UpdateUserRequest{Email: ptr("alice")}, errorx.ErrInvalidArgument, []string{"email"}},
+		{"missing user", v1.UpdateUserRequest{UserID: "nobody", Username: ptr("carol")}, nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.ValidateUpdateUserRequest(ctx, &tc.req)
+			if tc.reason == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.reason)
+			}
+			assert.Equal(t, tc.fields, failedFields(t, err))
+		})
+	}
+}
+
+func TestValidateChangePasswordRequest(t *testing.T) {
+	v := NewValidation(nil, nil)
+	ctx := context.Background()
+
+	assert.NoError(t, v.ValidateChangePasswordRequest(ctx, &v1.ChangePasswordRequest{OldPassword: "fastgo1234", NewPassword: "fastgo5678"}))
+	assert.Equal(t, []string{"new_password"}, failedFields(t, v.ValidateChangePasswordRequest(ctx, &v1.ChangePasswordRequest{OldPassword: "fastgo1234", NewPassword: "fastgo1234"})))
+	err := v.ValidateChangePasswordRequest(ctx, &v1.ChangePasswordRequest{NewPassword: "password"})
+	assert.ErrorIs(t, err, errorx.ErrPasswordInvalid)
+	assert.Equal(t, []string{"new_password"}, failedFields(t, err))
+
+	// The configured policy replaces the default one
+	v = NewValidation(nil, auth.PolicyFunc(func(string) string { return "is rejected" }))
+	err = v.ValidateChangePasswordRequest(ctx, &v1.ChangePasswordRequest{NewPassword: "fastgo5678"})
+	var errx *errorx.ErrorX
+	require.ErrorAs(t, err, &errx)
+	assert.Equal(t, "is rejected", errx.Metadata["new_password"])
+}
+
+func TestValidatePostRequests(t *testing.T) {
+	v := NewValidation(nil, nil)
+	ctx := context.Background()
+
+	assert.NoError(t, v.ValidateCreatePostRequest(ctx, &v1.CreatePostRequest{Title: "title"}))
+	assert.Equal(t, []string{"content", "title"}, failedFields(t, v.ValidateCreatePostRequest(ctx, &v1.CreatePostRequest{
+		Title: " ", Content: strings.Repeat("c", maxContentLength+1),
+	})))
+
+	assert.NoError(t, v.ValidateUpdatePostRequest(ctx, &v1.UpdatePostRequest{}))
+	assert.Equal(t, []string{"title"}, failedFields(t, v.ValidateUpdatePostRequest(ctx, &v1.UpdatePostRequest{Title: ptr(strings.Repeat("t", maxTitleLength+1))})))
+}
+
+func TestValidatePage(t *testing.T) {
+	v := NewValidation(nil, nil)
+	ctx := context.Background()
+	cursor := (&where.Cursor{ID: 7}).String()
+
+	for _, tc := range []struct {
+		name   string
+		req    v1.ListPostRequest
+		fields []string
+	}{
+		{"offset", v1.ListPostRequest{Offset: 10, Sort: "title"}, nil},
+		{"cursor", v1.ListPostRequest{Cursor: cursor}, nil},
+		{"cursor and offset", v1.ListPostRequest{Cursor: cursor, Offset: 10}, []string{"cursor"}},
+		{"cursor and sort", v1.ListPostRequest{Cursor: cursor, Sort: "title"}, []string{"cursor"}},
+		{"invalid cursor", v1.ListPostRequest{Cursor: "invalid!"}, []string{"cursor"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.fields, failedFields(t, v.ValidateListPostRequest(ctx, &tc.req)))
+		})
+	}
+}
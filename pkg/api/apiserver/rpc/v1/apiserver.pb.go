@@ -0,0 +1,147 @@
+// gRPC services of fg-apiserver. They share the business layer, the validation and the
+// authorization policies of the REST API, errors carry their reason and metadata in an
+// ErrorInfo detail.
+//
+// Calls other than Login, RefreshToken and CreateUser require an
+// `authorization: Bearer <token>` metadata entry.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: apiserver/rpc/v1/apiserver.proto
+
+package rpcv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_apiserver_rpc_v1_apiserver_proto protoreflect.FileDescriptor
+
+const file_apiserver_rpc_v1_apiserver_proto_rawDesc = "" +
+	"\n" +
+	" apiserver/rpc/v1/apiserver.proto\x12\x13fastgo.apiserver.v1\x1a\x1bapiserver/rpc/v1/post.proto\x1a\x1bapiserver/rpc/v1/user.proto2\xcd\x06\n" +
+	"\vUserService\x12N\n" +
+	"\x05Login\x12!.fastgo.apiserver.v1.LoginRequest\x1a\".fastgo.apiserver.v1.LoginResponse\x12c\n" +
+	"\fRefreshToken\x12(.fastgo.apiserver.v1.RefreshTokenRequest\x1a).fastgo.apiserver.v1.RefreshTokenResponse\x12Q\n" +
+	"\x06Logout\x12\".fastgo.apiserver.v1.LogoutRequest\x1a#.fastgo.apiserver.v1.LogoutResponse\x12i\n" +
+	"\x0eChangePassword\x12*.fastgo.apiserver.v1.ChangePasswordRequest\x1a+.fastgo.apiserver.v1.ChangePasswordResponse\x12]\n" +
+	"\n" +
+	"CreateUser\x12&.fastgo.apiserver.v1.CreateUserRequest\x1a'.fastgo.apiserver.v1.CreateUserResponse\x12]\n" +
+	"\n" +
+	"UpdateUser\x12&.fastgo.apiserver.v1.UpdateUserRequest\x1a'.fastgo.apiserver.v1.UpdateUserResponse\x12]\n" +
+	"\n" +
+	"DeleteUser\x12&.fastgo.apiserver.v1.DeleteUserRequest\x1a'.fastgo.apiserver.v1.DeleteUserResponse\x12T\n" +
+	"\aGetUser\x12#.fastgo.apiserver.v1.GetUserRequest\x1a$.fastgo.apiserver.v1.GetUserResponse\x12X\n" +
+	"\tListUsers\x12$.fastgo.apiserver.v1.ListUserRequest\x1a%.fastgo.apiserver.v1.ListUserResponse2\xda\x03\n" +
+	"\vPostService\x12]\n" +
+	"\n" +
+	"CreatePost\x12&.fastgo.apiserver.v1.CreatePostRequest\x1a'.fastgo.apiserver.v1.CreatePostResponse\x12]\n" +
+	"\n" +
+	"UpdatePost\x12&.fastgo.apiserver.v1.UpdatePostRequest\x1a'.fastgo.apiserver.v1.UpdatePostResponse\x12]\n" +
+	"\n" +
+	"DeletePost\x12&.fastgo.apiserver.v1.DeletePostRequest\x1a'.fastgo.apiserver.v1.DeletePostResponse\x12T\n" +
+	"\aGetPost\x12#.fastgo.apiserver.v1.GetPostRequest\x1a$.fastgo.apiserver.v1.GetPostResponse\x12X\n" +
+	"\tListPosts\x12$.fastgo.apiserver.v1.ListPostRequest\x1a%.fastgo.apiserver.v1.ListPostResponseB;Z9github.com/MortalSC/FastGO/pkg/api/apiserver/rpc/v1;rpcv1b\x06proto3"
+
+var file_apiserver_rpc_v1_apiserver_proto_goTypes = []any{
+	(*LoginRequest)(nil),           // 0: fastgo.apiserver.v1.LoginRequest
+	(*RefreshTokenRequest)(nil),    // 1: fastgo.apiserver.v1.RefreshTokenRequest
+	(*LogoutRequest)(nil),          // 2: fastgo.apiserver.v1.LogoutRequest
+	(*ChangePasswordRequest)(nil),  // 3: fastgo.apiserver.v1.ChangePasswordRequest
+	(*CreateUserRequest)(nil),      // 4: fastgo.apiserver.v1.CreateUserRequest
+	(*UpdateUserRequest)(nil),      // 5: fastgo.apiserver.v1.UpdateUserRequest
+	(*DeleteUserRequest)(nil),      // 6: fastgo.apiserver.v1.DeleteUserRequest
+	(*GetUserRequest)(nil),         // 7: fastgo.apiserver.v1.GetUserRequest
+	(*ListUserRequest)(nil),        // 8: fastgo.apiserver.v1.ListUserRequest
+	(*CreatePostRequest)(nil),      // 9: fastgo.apiserver.v1.CreatePostRequest
+	(*UpdatePostRequest)(nil),      // 10: fastgo.apiserver.v1.UpdatePostRequest
+	(*DeletePostRequest)(nil),      // 11: fastgo.apiserver.v1.DeletePostRequest
+	(*GetPostRequest)(nil),         // 12: fastgo.apiserver.v1.GetPostRequest
+	(*ListPostRequest)(nil),        // 13: fastgo.apiserver.v1.ListPostRequest
+	(*LoginResponse)(nil),          // 14: fastgo.apiserver.v1.LoginResponse
+	(*RefreshTokenResponse)(nil),   // 15: fastgo.apiserver.v1.RefreshTokenResponse
+	(*LogoutResponse)(nil),         // 16: fastgo.apiserver.v1.LogoutResponse
+	(*ChangePasswordResponse)(nil), // 17: fastgo.apiserver.v1.ChangePasswordResponse
+	(*CreateUserResponse)(nil),     // 18: fastgo.apiserver.v1.CreateUserResponse
+	(*UpdateUserResponse)(nil),     // 19: fastgo.apiserver.v1.UpdateUserResponse
+	(*DeleteUserResponse)(nil),     // 20: fastgo.apiserver.v1.DeleteUserResponse
+	(*GetUserResponse)(nil),        // 21: fastgo.apiserver.v1.GetUserResponse
+	(*ListUserResponse)(nil),       // 22: fastgo.apiserver.v1.ListUserResponse
+	(*CreatePostResponse)(nil),     // 23: fastgo.apiserver.v1.CreatePostResponse
+	(*UpdatePostResponse)(nil),     // 24: fastgo.apiserver.v1.UpdatePostResponse
+	(*DeletePostResponse)(nil),     // 25: fastgo.apiserver.v1.DeletePostResponse
+	(*GetPostResponse)(nil),        // 26: fastgo.apiserver.v1.GetPostResponse
+	(*ListPostResponse)(nil),       // 27: fastgo.apiserver.v1.ListPostResponse
+}
+var file_apiserver_rpc_v1_apiserver_proto_depIdxs = []int32{
+	0,  // 0: fastgo.apiserver.v1.UserService.Login:input_type -> fastgo.apiserver.v1.LoginRequest
+	1,  // 1: fastgo.apiserver.v1.UserService.RefreshToken:input_type -> fastgo.apiserver.v1.RefreshTokenRequest
+	2,  // 2: fastgo.apiserver.v1.UserService.Logout:input_type -> fastgo.apiserver.v1.LogoutRequest
+	3,  // 3: fastgo.apiserver.v1.UserService.ChangePassword:input_type -> fastgo.apiserver.v1.ChangePasswordRequest
+	4,  // 4: fastgo.apiserver.v1.UserService.CreateUser:input_type -> fastgo.apiserver.v1.CreateUserRequest
+	5,  // 5: fastgo.apiserver.v1.UserService.UpdateUser:input_type -> fastgo.apiserver.v1.UpdateUserRequest
+	6,  // 6: fastgo.apiserver.v1.UserService.DeleteUser:input_type -> fastgo.apiserver.v1.DeleteUserRequest
+	7,  // 7: fastgo.apiserver.v1.UserService.GetUser:input_type -> fastgo.apiserver.v1.GetUserRequest
+	8,  // 8: fastgo.apiserver.v1.UserService.ListUsers:input_type -> fastgo.apiserver.v1.ListUserRequest
+	9,  // 9: fastgo.apiserver.v1.PostService.CreatePost:input_type -> fastgo.apiserver.v1.CreatePostRequest
+	10, // 10: fastgo.apiserver.v1.PostService.UpdatePost:input_type -> fastgo.apiserver.v1.UpdatePostRequest
+	11, // 11: fastgo.apiserver.v1.PostService.DeletePost:input_type -> fastgo.apiserver.v1.DeletePostRequest
+	12, // 12: fastgo.apiserver.v1.PostService.GetPost:input_type -> fastgo.apiserver.v1.GetPostRequest
+	13, // 13: fastgo.apiserver.v1.PostService.ListPosts:input_type -> fastgo.apiserver.v1.ListPostRequest
+	14, // 14: fastgo.apiserver.v1.UserService.Login:output_type -> fastgo.apiserver.v1.LoginResponse
+	15, // 15: fastgo.apiserver.v1.UserService.RefreshToken:output_type -> fastgo.apiserver.v1.RefreshTokenResponse
+	16, // 16: fastgo.apiserver.v1.UserService.Logout:output_type -> fastgo.apiserver.v1.LogoutResponse
+	17, // 17: fastgo.apiserver.v1.UserService.ChangePassword:output_type -> fastgo.apiserver.v1.ChangePasswordResponse
+	18, // 18: fastgo.apiserver.v1.UserService.CreateUser:output_type -> fastgo.apiserver.v1.CreateUserResponse
+	19, // 19: fastgo.apiserver.v1.UserService.UpdateUser:output_type -> fastgo.apiserver.v1.UpdateUserResponse
+	20, // 20: fastgo.apiserver.v1.UserService.DeleteUser:output_type -> fastgo.apiserver.v1.DeleteUserResponse
+	21, // 21: fastgo.apiserver.v1.UserService.GetUser:output_type -> fastgo.apiserver.v1.GetUserResponse
+	22, // 22: fastgo.apiserver.v1.UserService.ListUsers:output_type -> fastgo.apiserver.v1.ListUserResponse
+	23, // 23: fastgo.apiserver.v1.PostService.CreatePost:output_type -> fastgo.apiserver.v1.CreatePostResponse
+	24, // 24: fastgo.apiserver.v1.PostService.UpdatePost:output_type -> fastgo.apiserver.v1.UpdatePostResponse
+	25, // 25: fastgo.apiserver.v1.PostService.DeletePost:output_type -> fastgo.apiserver.v1.DeletePostResponse
+	26, // 26: fastgo.apiserver.v1.PostService.GetPost:output_type -> fastgo.apiserver.v1.GetPostResponse
+	27, // 27: fastgo.apiserver.v1.PostService.ListPosts:output_type -> fastgo.apiserver.v1.ListPostResponse
+	14, // [14:28] is the sub-list for method output_type
+	0,  // [0:14] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_apiserver_rpc_v1_apiserver_proto_init() }
+func file_apiserver_rpc_v1_apiserver_proto_init() {
+	if File_apiserver_rpc_v1_apiserver_proto != nil {
+		return
+	}
+	file_apiserver_rpc_v1_post_proto_init()
+	file_apiserver_rpc_v1_user_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_apiserver_rpc_v1_apiserver_proto_rawDesc), len(file_apiserver_rpc_v1_apiserver_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_apiserver_rpc_v1_apiserver_proto_goTypes,
+		DependencyIndexes: file_apiserver_rpc_v1_apiserver_proto_depIdxs,
+	}.Build()
+	File_apiserver_rpc_v1_apiserver_proto = out.File
+	file_apiserver_rpc_v1_apiserver_proto_goTypes = nil
+	file_apiserver_rpc_v1_apiserver_proto_depIdxs = nil
+}
@@ -0,0 +1,18 @@
+package v1
+
+type GetLogLevelRequest struct{}
+
+type GetLogLevelResponse struct {
+	// Level is the current log level: debug, info, warn or error
+	Level string `json:"level"`
+}
+
+type UpdateLogLevelRequest struct {
+	// Level is the new log level: debug, info, warn or error
+	Level string `json:"level"`
+}
+
+type UpdateLogLevelResponse struct {
+	// Level is the log level in effect
+	Level string `json:"level"`
+}
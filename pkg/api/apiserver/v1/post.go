@@ -21,7 +21,7 @@ type CreatePostResponse struct {
 }
 
 type UpdatePostRequest struct {
-	PostID  string  `json:"post_id"`
+	PostID  string  `json:"post_id" uri:"post_id"`
 	Title   *string `json:"title"`
 	Content *string `json:"content"`
 }
@@ -29,7 +29,8 @@ type UpdatePostRequest struct {
 type UpdatePostResponse struct{}
 
 type DeletePostRequest struct {
-	PostID []string `json:"post_id"`
+	// PostID is taken from the path when deleting a single post
+	PostID []string `json:"post_id" uri:"post_id"`
 }
 
 type DeletePostResponse struct{}
@@ -43,12 +44,26 @@ type GetPostResponse struct {
 }
 
 type ListPostRequest struct {
-	Limit  int64   `json:"limit"`
-	Offset int64   `json:"offset"`
-	Title  *string `json:"title"`
+	Limit  int64 `json:"limit" form:"limit"`
+	Offset int64 `json:"offset" form:"offset"`
+	// Cursor is the next_cursor of the previous page, it cannot be combined with Offset
+	Cursor string `json:"cursor" form:"cursor"`
+	// SkipCount omits the total number of posts, which is expensive on large tables
+	SkipCount bool `json:"skip_count" form:"skip_count"`
+	// Filter is a comma separated list of conditions such as `created_at>=2025-01-01,title~go`
+	// Fields: post_id, title, content, created_at, updated_at
+	Filter string `json:"filter" form:"filter"`
+	// Sort is a comma separated list of fields, prefixed with `-` for the descending order
+	// Fields: title, created_at, updated_at
+	Sort  string  `json:"sort" form:"sort"`
+	Title *string `json:"title" form:"title"`
 }
 
 type ListPostResponse struct {
-	Total int64   `json:"total"`
+	// Total is omitted when the request sets skip_count
+	Total *int64  `json:"total,omitempty"`
 	Posts []*Post `json:"posts"`
+	// NextCursor fetches the next page, it is empty on the last page and for sorted lists,
+	// which are paged with Offset
+	NextCursor string `json:"next_cursor,omitempty"`
 }
@@ -8,6 +8,7 @@ type User struct {
 	Nickname  string    `json:"nickname"`
 	Email     string    `json:"email"`
 	Phone     string    `json:"phone"`
+	Role      string    `json:"role"`
 	PostCount int64     `json:"post_count"`
 	CreateAt  time.Time `json:"create_at"`
 	UpdateAt  time.Time `json:"update_at"`
@@ -26,20 +27,28 @@ type CreateUserResponse struct {
 }
 
 type UpdateUserRequest struct {
+	// UserID is the userID or username of the user to update
+	UserID   string  `json:"-" uri:"user_id"`
 	Username *string `json:"username"`
 	Nickname *string `json:"nickname"`
 	Email    *string `json:"email"`
 	Phone    *string `json:"phone"`
+	// Role can only be changed by administrators
+	Role *string `json:"role"`
 }
 
 type UpdateUserResponse struct{}
 
-type DeleteUserRequest struct{}
+type DeleteUserRequest struct {
+	// UserID is the userID or username of the user to delete
+	UserID string `json:"-" uri:"user_id"`
+}
 
 type DeleteUserResponse struct{}
 
 type GetUserRequest struct {
-	UserID string `json:"user_id"`
+	// UserID is the userID or username of the user to get
+	UserID string `json:"user_id" uri:"user_id"`
 }
 
 type GetUserResponse struct {
@@ -51,11 +60,25 @@ type ListUserRequest struct {
 	// Offset int64 `json:"offset"`
 	Limit  int64 `form:"limit"`
 	Offset int64 `form:"offset"`
+	// Cursor is the next_cursor of the previous page, it cannot be combined with Offset
+	Cursor string `form:"cursor"`
+	// SkipCount omits the total number of users
+	SkipCount bool `form:"skip_count"`
+	// Filter is a comma separated list of conditions such as `created_at>=2025-01-01,username~go`
+	// Fields: user_id, username, nickname, email, phone, role, created_at, updated_at
+	Filter string `form:"filter"`
+	// Sort is a comma separated list of fields, prefixed with `-` for the descending order
+	// Fields: username, nickname, created_at, updated_at
+	Sort string `form:"sort"`
 }
 
 type ListUserResponse struct {
-	Total int64   `json:"total"`
+	// Total is omitted when the request sets skip_count
+	Total *int64  `json:"total,omitempty"`
 	Users []*User `json:"users"`
+	// NextCursor fetches the next page, it is empty on the last page and for sorted lists,
+	// which are paged with Offset
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // ====== login with token ========
@@ -66,20 +89,55 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token    string    `json:"token"`
-	ExpireAt time.Time `json:"expire_at"`
+	Token           string    `json:"token"`
+	ExpireAt        time.Time `json:"expire_at"`
+	RefreshToken    string    `json:"refresh_token"`
+	RefreshExpireAt time.Time `json:"refresh_expire_at"`
 }
 
-type RefreshTokenRequest struct{}
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
 
 type RefreshTokenResponse struct {
-	Token    string    `json:"token"`
-	ExpireAt time.Time `json:"expire_at"`
+	Token           string    `json:"token"`
+	ExpireAt        time.Time `json:"expire_at"`
+	RefreshToken    string    `json:"refresh_token"`
+	RefreshExpireAt time.Time `json:"refresh_expire_at"`
 }
 
+type LogoutRequest struct{}
+
+type LogoutResponse struct{}
+
 type ChangePasswordRequest struct {
+	// UserID is the userID or username of the user whose password is changed
+	UserID      string `json:"-" uri:"user_id"`
 	OldPassword string `json:"old_password"`
 	NewPassword string `json:"new_password"`
 }
 
 type ChangePasswordResponse struct{}
+
+type GetUserLockRequest struct {
+	// UserID is the userID or username of the user
+	UserID string `json:"-" uri:"user_id"`
+}
+
+type GetUserLockResponse struct {
+	// Locked reports whether the logins of the user are locked
+	Locked bool `json:"locked"`
+	// FailedLogins is the number of consecutive failed logins
+	FailedLogins int64 `json:"failed_logins"`
+	// LastFailedLoginAt is the time of the last failed login
+	LastFailedLoginAt *time.Time `json:"last_failed_login_at,omitempty"`
+	// LockedUntil is the end of the lockout
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+}
+
+type UnlockUserRequest struct {
+	// UserID is the userID or username of the user to unlock
+	UserID string `json:"-" uri:"user_id"`
+}
+
+type UnlockUserResponse struct{}
@@ -1,12 +1,78 @@
+// Package auth hashes, verifies and vets passwords.
+//
+// Hashes are encoded with the identifier of their algorithm and their parameters, e.g.
+// $2a$10$... for bcrypt and $argon2id$v=19$m=65536,t=3,p=2$... for argon2id, so that the
+// hashes of every supported algorithm keep working when the configured algorithm or its
+// parameters change. NeedsRehash tells which hashes should be replaced on the next login.
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"errors"
+	"sync"
+)
 
-func Encrypt(password string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(hashedBytes), err
+// ErrMismatch is returned when the password does not match the hash
+var ErrMismatch = errors.New("the password does not match the hash")
+
+// ErrUnknownAlgorithm is returned for hashes of an unsupported algorithm
+var ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+
+// Algorithm hashes passwords with one algorithm and one set of parameters
+type Algorithm interface {
+	// Identify reports whether encoded is a hash of the algorithm
+	Identify(encoded string) bool
+	// Hash returns the encoded hash of password, with a random salt
+	Hash(password string) (string, error)
+	// Compare returns nil if password matches encoded, whatever parameters it was hashed with
+	Compare(encoded, password string) error
+	// Outdated reports whether encoded was hashed with other parameters than the algorithm
+	Outdated(encoded string) bool
+}
+
+// Hasher hashes the passwords with its algorithm and verifies the hashes of every supported algorithm
+type Hasher struct {
+	current Algorithm
+	// supported verify the hashes of the other algorithms
+	supported []Algorithm
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+// NewHasher creates a Hasher hashing the new passwords with current
+func NewHasher(current Algorithm) *Hasher {
+	return &Hasher{
+		current:   current,
+		supported: []Algorithm{current, NewBcrypt(DefaultBcryptCost), NewArgon2id(DefaultArgon2idParams)},
+	}
+}
+
+// Hash returns the encoded hash of password
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.current.Hash(password)
+}
+
+// Compare returns nil if password matches encoded, ErrMismatch otherwise
+func (h *Hasher) Compare(encoded, password string) error {
+	for _, alg := range h.supported {
+		if alg.Identify(encoded) {
+			return alg.Compare(encoded, password)
+		}
+	}
+	return ErrUnknownAlgorithm
+}
+
+// NeedsRehash reports whether encoded was not hashed by the algorithm of the hasher, or with
+// other parameters, in which case the password should be hashed again once verified
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	return !h.current.Identify(encoded) || h.current.Outdated(encoded)
 }
 
-func Compare(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// CompareDummy compares password with the hash of a dummy password, which takes as long as
+// Compare, e.g. to verify the logins of unknown users in constant time
+func (h *Hasher) CompareDummy(password string) {
+	h.dummyOnce.Do(func() {
+		h.dummy, _ = h.current.Hash("fastgo-dummy-password")
+	})
+	_ = h.Compare(h.dummy, password)
 }
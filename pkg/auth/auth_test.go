@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams keep the tests fast
+var testArgon2idParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id(t *testing.T) {
+	a := NewArgon2id(testArgon2idParams)
+
+	encoded, err := a.Hash("fastgo1234")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$"))
+	assert.True(t, a.Identify(encoded))
+	assert.NoError(t, a.Compare(encoded, "fastgo1234"))
+	assert.ErrorIs(t, a.Compare(encoded, "fastgo5678"), ErrMismatch)
+
+	// Every hash has its own salt
+	other, err := a.Hash("fastgo1234")
+	require.NoError(t, err)
+	assert.NotEqual(t, encoded, other)
+
+	// The parameters are read from the hash
+	params, salt, key, err := decodeArgon2id(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, testArgon2idParams, params)
+	assert.Len(t, salt, 16)
+	assert.Len(t, key, 32)
+
+	for _, invalid := range []string{
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!$a2V5",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+	} {
+		assert.Error(t, a.Compare(invalid, "fastgo1234"), invalid)
+		assert.True(t, a.Outdated(invalid), invalid)
+	}
+}
+
+func TestBcrypt(t *testing.T) {
+	b := NewBcrypt(bcrypt.MinCost)
+
+	encoded, err := b.Hash("fastgo1234")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$2a$04$"))
+	assert.True(t, b.Identify(encoded))
+	assert.NoError(t, b.Compare(encoded, "fastgo1234"))
+	assert.ErrorIs(t, b.Compare(encoded, "fastgo5678"), ErrMismatch)
+
+	// Passwords longer than BcryptMaxBytes are not truncated
+	_, err = b.Hash(strings.Repeat("a", BcryptMaxBytes))
+	require.NoError(t, err)
+	_, err = b.Hash(strings.Repeat("a", BcryptMaxBytes+1))
+	assert.ErrorIs(t, err, bcrypt.ErrPasswordTooLong)
+
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		assert.True(t, b.Identify(prefix+"10$"), prefix)
+	}
+	assert.False(t, b.Identify("$argon2id$v=19$"))
+}
+
+func TestOutdated(t *testing.T) {
+	bcrypt4, err := NewBcrypt(4).Hash("fastgo1234")
+	require.NoError(t, err)
+	assert.False(t, NewBcrypt(4).Outdated(bcrypt4))
+	assert.True(t, NewBcrypt(5).Outdated(bcrypt4))
+	assert.True(t, NewBcrypt(4).Outdated("$2a$invalid"))
+
+	argon2id, err := NewArgon2id(testArgon2idParams).Hash("fastgo1234")
+	require.NoError(t, err)
+	assert.False(t, NewArgon2id(testArgon2idParams).Outdated(argon2id))
+	for _, mutate := range []func(*Argon2idParams){
+		func(p *Argon2idParams) { p.Memory = 128 },
+		func(p *Argon2idParams) { p.Iterations = 2 },
+		func(p *Argon2idParams) { p.Parallelism = 2 },
+		func(p *Argon2idParams) { p.SaltLength = 8 },
+		func(p *Argon2idParams) { p.KeyLength = 16 },
+	} {
+		params := testArgon2idParams
+		mutate(&params)
+		assert.True(t, NewArgon2id(params).Outdated(argon2id), "%+v", params)
+	}
+}
+
+func TestHasher(t *testing.T) {
+	bcryptHash, err := NewBcrypt(bcrypt.MinCost).Hash("fastgo1234")
+	require.NoError(t, err)
+	argon2idHash, err := NewArgon2id(testArgon2idParams).Hash("fastgo1234")
+	require.NoError(t, err)
+
+	// The hashes of every algorithm are verified
+	h := NewHasher(NewArgon2id(testArgon2idParams))
+	for _, encoded := range []string{bcryptHash, argon2idHash} {
+		assert.NoError(t, h.Compare(encoded, "fastgo1234"))
+		assert.ErrorIs(t, h.Compare(encoded, "fastgo5678"), ErrMismatch)
+	}
+	assert.ErrorIs(t, h.Compare("$1$md5", "fastgo1234"), ErrUnknownAlgorithm)
+	h.CompareDummy("fastgo1234")
+
+	encoded, err := h.Hash("fastgo1234")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$"))
+
+	// The hashes of other algorithms or parameters need to be rehashed
+	assert.False(t, h.NeedsRehash(argon2idHash))
+	assert.True(t, h.NeedsRehash(bcryptHash))
+	assert.True(t, NewHasher(NewArgon2id(DefaultArgon2idParams)).NeedsRehash(argon2idHash))
+	assert.False(t, NewHasher(NewBcrypt(bcrypt.MinCost)).NeedsRehash(bcryptHash))
+	assert.True(t, NewHasher(NewBcrypt(DefaultBcryptCost)).NeedsRehash(bcryptHash))
+}
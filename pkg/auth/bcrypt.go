@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is the cost of bcrypt.DefaultCost
+const DefaultBcryptCost = bcrypt.DefaultCost
+
+// BcryptMaxBytes is the length, in bytes, of the longest password bcrypt hashes
+const BcryptMaxBytes = 72
+
+// Bcrypt hashes passwords with bcrypt, whose hashes start with $2a$, $2b$ or $2y$
+// Hash and Compare return bcrypt.ErrPasswordTooLong for passwords longer than BcryptMaxBytes,
+// the policy of the new passwords must reject them with MaxBytesPolicy.
+type Bcrypt struct {
+	cost int
+}
+
+var _ Algorithm = (*Bcrypt)(nil)
+
+// NewBcrypt creates a Bcrypt hashing with cost, between bcrypt.MinCost and bcrypt.MaxCost
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+// Identify reports whether encoded is a bcrypt hash
+func (b *Bcrypt) Identify(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// Hash returns the bcrypt hash of password
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	return string(hash), err
+}
+
+// Compare returns nil if password matches the bcrypt hash encoded
+func (b *Bcrypt) Compare(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+	return err
+}
+
+// Outdated reports whether encoded was hashed with another cost
+func (b *Bcrypt) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.cost
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1" //nolint:gosec // the breach lists are indexed by SHA-1
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy decides whether a password may be chosen by a user
+type Policy interface {
+	// Check returns why password is rejected, or an empty string if it is accepted
+	Check(password string) string
+}
+
+// PolicyFunc adapts a function to a Policy
+type PolicyFunc func(password string) string
+
+// Check calls f
+func (f PolicyFunc) Check(password string) string {
+	return f(password)
+}
+
+// Policies returns a Policy rejecting the passwords rejected by any of policies
+func Policies(policies ...Policy) Policy {
+	return PolicyFunc(func(password string) string {
+		for _, policy := range policies {
+			if msg := policy.Check(password); msg != "" {
+				return msg
+			}
+		}
+		return ""
+	})
+}
+
+// LengthPolicy rejects passwords with fewer than min or more than max characters
+func LengthPolicy(min, max int) Policy {
+	return PolicyFunc(func(password string) string {
+		if n := utf8.RuneCountInString(password); n < min || n > max {
+			return fmt.Sprintf("must be between %d and %d characters long", min, max)
+		}
+		return ""
+	})
+}
+
+// MaxBytesPolicy rejects passwords longer than max bytes, e.g. BcryptMaxBytes
+// It complements LengthPolicy, which counts the characters of multi-byte encodings once.
+func MaxBytesPolicy(max int) Policy {
+	return PolicyFunc(func(password string) string {
+		if len(password) > max {
+			return fmt.Sprintf("must not be longer than %d bytes", max)
+		}
+		return ""
+	})
+}
+
+// LettersAndDigitsPolicy requires passwords to mix letters and digits
+var LettersAndDigitsPolicy Policy = PolicyFunc(func(password string) string {
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return "must contain both letters and digits"
+	}
+	return ""
+})
+
+// DefaultPolicy requires 8 to 64 characters mixing letters and digits
+var DefaultPolicy = Policies(LengthPolicy(8, 64), LettersAndDigitsPolicy)
+
+// BreachList rejects the passwords known to have leaked
+type BreachList struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+var _ Policy = (*BreachList)(nil)
+
+// LoadBreachList reads a breach list file, with one password per line
+// Lines may also hold the hex SHA-1 of a password, optionally followed by :<count> as in the
+// Have I Been Pwned downloads. Empty lines and lines starting with # are ignored.
+// The list is held in memory, at about 50 bytes per password: a list of the most common
+// leaked passwords, e.g. the first million, fits in about 50 MB, whereas the complete Have
+// I Been Pwned download, of almost a billion passwords, does not.
+func LoadBreachList(path string) (*BreachList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &BreachList{hashes: make(map[[sha1.Size]byte]struct{})}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if hash, ok := parseSHA1(line); ok {
+			list.hashes[hash] = struct{}{}
+			continue
+		}
+		list.hashes[sha1.Sum([]byte(line))] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breach list %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// parseSHA1 parses a hex SHA-1 followed by an optional :<count>
+func parseSHA1(line string) ([sha1.Size]byte, bool) {
+	var hash [sha1.Size]byte
+
+	hexHash, _, _ := strings.Cut(line, ":")
+	if len(hexHash) != hex.EncodedLen(sha1.Size) {
+		return hash, false
+	}
+	if _, err := hex.Decode(hash[:], []byte(hexHash)); err != nil {
+		return hash, false
+	}
+	return hash, true
+}
+
+// Len returns the number of passwords of the list
+func (l *BreachList) Len() int {
+	return len(l.hashes)
+}
+
+// Check rejects the passwords of the list
+func (l *BreachList) Check(password string) string {
+	if _, ok := l.hashes[sha1.Sum([]byte(password))]; ok {
+		return "has appeared in a data breach, choose another password"
+	}
+	return ""
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicies(t *testing.T) {
+	policy := Policies(LengthPolicy(8, 12), LettersAndDigitsPolicy, MaxBytesPolicy(16))
+
+	for password, rejected := range map[string]bool{
+		"fastgo12":      false,
+		"fastgo123456":  false,
+		"fastgo1":       true,
+		"fastgo1234567": true,
+		"fastgofastgo":  true,
+		"123456789":     true,
+		// 10 characters, 19 bytes
+		"ééééééééé1": true,
+		"éééé1234":   false,
+	} {
+		assert.Equal(t, rejected, policy.Check(password) != "", password)
+	}
+
+	assert.Empty(t, Policies().Check(""))
+	assert.Contains(t, LengthPolicy(8, 64).Check("短い1"), "between 8 and 64 characters")
+	assert.Contains(t, MaxBytesPolicy(BcryptMaxBytes).Check(strings.Repeat("é", 37)), "72 bytes")
+}
+
+func TestBreachList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join([]string{
+		"# leaked passwords",
+		"",
+		"fastgo5678",
+		"  qwerty123  ",
+		"70CCD9007338D6D81DD3B6271621B9CF9A97EA00:42", // SHA-1 of Password1
+		"cbfdac6008f9cab4083784cbd1874f76618d2a97",    // SHA-1 of password123
+	}, "\n")), 0o600))
+
+	list, err := LoadBreachList(path)
+	require.NoError(t, err)
+	assert.Equal(t, 4, list.Len())
+	for _, password := range []string{"fastgo5678", "qwerty123", "Password1", "password123"} {
+		assert.NotEmpty(t, list.Check(password), password)
+	}
+	assert.Empty(t, list.Check("fastgo1234"))
+
+	_, err = LoadBreachList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,312 @@
+// Package client is a Go SDK of the fg-apiserver REST API.
+//
+// A Client logs in once and then keeps its access token fresh: it is refreshed shortly before
+// it expires, and once more when the server rejects it as expired. Idempotent calls (GET,
+// PUT and DELETE) are retried on network errors and on 502, 503 and 504 responses. Every
+// call sends an X-Request-ID header, taken from the context if set with WithRequestID.
+//
+// Error responses are decoded into an *Error carrying the code, the reason and the
+// metadata of the server error, so callers can match reasons with errors.Is:
+//
+//	if errors.Is(err, client.ErrPostNotFound) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/google/uuid"
+)
+
+// requestIDHeader is the header carrying the request ID
+const requestIDHeader = "X-Request-ID"
+
+// refreshSkew is how long before its expiry the access token is refreshed
+const refreshSkew = 30 * time.Second
+
+type requestIDKey struct{}
+
+// WithRequestID sets the request ID sent by the calls made with ctx
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client sending the requests, http.DefaultClient is used by default
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithRetries sets how many times idempotent calls are retried, and the delay before the
+// first retry which doubles on every retry. The defaults are 3 retries and 100ms
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// WithTokens sets the tokens of a session established beforehand, e.g. restored from a cache
+func WithTokens(token string, expireAt time.Time, refreshToken string) Option {
+	return func(c *Client) {
+		c.token, c.expireAt, c.refreshToken = token, expireAt, refreshToken
+	}
+}
+
+// Client calls the REST API of fg-apiserver, it is safe for concurrent use
+type Client struct {
+	baseURL string
+	http    *http.Client
+	retries int
+	backoff time.Duration
+
+	// refreshMu serializes refreshes, so concurrent calls do not spend the same refresh token
+	refreshMu sync.Mutex
+	// mu guards the tokens of the session
+	mu           sync.Mutex
+	token        string
+	expireAt     time.Time
+	refreshToken string
+}
+
+// New creates a client of the server at baseURL, such as https://fastgo.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    http.DefaultClient,
+		retries: 3,
+		backoff: 100 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Users returns the client of the user endpoints
+func (c *Client) Users() *UserClient {
+	return &UserClient{c: c}
+}
+
+// Posts returns the client of the post endpoints
+func (c *Client) Posts() *PostClient {
+	return &PostClient{c: c}
+}
+
+// Token returns the current access token, its expiry and the refresh token
+func (c *Client) Token() (string, time.Time, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token, c.expireAt, c.refreshToken
+}
+
+// setTokens stores the tokens of a login or a refresh
+func (c *Client) setTokens(token string, expireAt time.Time, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token, c.expireAt, c.refreshToken = token, expireAt, refreshToken
+}
+
+// Login logs in and keeps the tokens for the following calls
+func (c *Client) Login(ctx context.Context, username, password string) (*v1.LoginResponse, error) {
+	var resp v1.LoginResponse
+	req := &v1.LoginRequest{Username: username, Password: password}
+	if err := c.do(ctx, http.MethodPost, "/login", nil, req, &resp, false); err != nil {
+		return nil, err
+	}
+
+	c.setTokens(resp.Token, resp.ExpireAt, resp.RefreshToken)
+	return &resp, nil
+}
+
+// RefreshToken exchanges the refresh token for new tokens
+func (c *Client) RefreshToken(ctx context.Context) (*v1.RefreshTokenResponse, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	_, _, refreshToken := c.Token()
+	return c.refresh(ctx, refreshToken)
+}
+
+// refresh exchanges refreshToken for new tokens, the caller must hold refreshMu
+func (c *Client) refresh(ctx context.Context, refreshToken string) (*v1.RefreshTokenResponse, error) {
+	if refreshToken == "" {
+		return nil, errors.New("no refresh token, log in first")
+	}
+
+	var resp v1.RefreshTokenResponse
+	req := &v1.RefreshTokenRequest{RefreshToken: refreshToken}
+	if err := c.do(ctx, http.MethodPost, "/refresh-token", nil, req, &resp, false); err != nil {
+		return nil, err
+	}
+
+	c.setTokens(resp.Token, resp.ExpireAt, resp.RefreshToken)
+	return &resp, nil
+}
+
+// Logout revokes the session and forgets its tokens
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodPost, "/logout", nil, &v1.LogoutRequest{}, nil, true); err != nil {
+		return err
+	}
+
+	c.setTokens("", time.Time{}, "")
+	return nil
+}
+
+// accessToken returns a valid access token, refreshing it if it is about to expire
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	token, expireAt, refreshToken := c.Token()
+	if token == "" || refreshToken == "" || time.Until(expireAt) > refreshSkew {
+		return token, nil
+	}
+
+	resp, err := c.refresh(ctx, refreshToken)
+	if err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// forceRefresh refreshes the access token after the server rejected token as expired,
+// unless another call already replaced it
+func (c *Client) forceRefresh(ctx context.Context, token string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	current, _, refreshToken := c.Token()
+	if current != token {
+		return nil
+	}
+	_, err := c.refresh(ctx, refreshToken)
+	return err
+}
+
+// do sends a request and decodes the JSON response into out, query may be nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out any, auth bool) error {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		var token string
+		if auth {
+			var err error
+			if token, err = c.accessToken(ctx); err != nil {
+				return err
+			}
+		}
+
+		status, err := c.send(ctx, method, target, requestID, token, body, out)
+		if err == nil {
+			return nil
+		}
+
+		// An access token which expired in flight is refreshed once
+		if auth && !refreshed && errors.Is(err, ErrTokenExpired) {
+			refreshed = true
+			if err := c.forceRefresh(ctx, token); err != nil {
+				return err
+			}
+			attempt--
+			continue
+		}
+
+		if !retryable(method, status) || attempt >= c.retries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.backoff << attempt):
+		}
+	}
+}
+
+// send sends a single request, it returns the status code of the response or 0 on network errors
+func (c *Client) send(ctx context.Context, method, target, requestID, token string, body []byte, out any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set(requestIDHeader, requestID)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.StatusCode, decodeError(resp, data)
+	}
+	if out == nil || len(data) == 0 {
+		return resp.StatusCode, nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("decode %s response: %w", req.URL.Path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+// decodeError decodes an error response, responses which are not JSON, e.g. of a proxy,
+// keep their status code with an empty reason
+func decodeError(resp *http.Response, data []byte) error {
+	var errx Error
+	if err := json.Unmarshal(data, &errx); err != nil || errx.Reason == "" {
+		return &Error{Code: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+
+	errx.Code = resp.StatusCode
+	return &errx
+}
+
+// retryable reports whether a failed call may be retried
+func retryable(method string, status int) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return status == 0 || status == http.StatusBadGateway || status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
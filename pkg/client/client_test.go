@@ -0,0 +1,220 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MortalSC/FastGO/internal/pkg/errorx"
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer issues the tokens t1, t2... and lets the tests script the responses of the API
+type fakeServer struct {
+	mu sync.Mutex
+	// ttl is the lifetime of the issued access tokens
+	ttl time.Duration
+	// issued is the number of issued access tokens
+	issued int
+	// calls counts the calls of every "METHOD path"
+	calls map[string]int
+	// tokens are the access tokens of the API calls, in order
+	tokens []string
+	// api answers the API calls, the nth call of the route is n
+	api func(w http.ResponseWriter, r *http.Request, n int)
+}
+
+func newFakeServer(t *testing.T, ttl time.Duration, api func(w http.ResponseWriter, r *http.Request, n int)) (*fakeServer, *Client) {
+	t.Helper()
+
+	f := &fakeServer{ttl: ttl, calls: make(map[string]int), api: api}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	c := New(srv.URL, WithRetries(3, time.Millisecond))
+	_, err := c.Login(context.Background(), "alice", "fastgo1234")
+	require.NoError(t, err)
+	return f, c
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	route := r.Method + " " + r.URL.Path
+	f.calls[route]++
+	n := f.calls[route]
+
+	if r.URL.Path == "/login" || r.URL.Path == "/refresh-token" {
+		f.issued++
+		resp := v1.LoginResponse{
+			Token:        fmt.Sprintf("t%d", f.issued),
+			ExpireAt:     time.Now().Add(f.ttl),
+			RefreshToken: fmt.Sprintf("r%d", f.issued),
+		}
+		f.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(resp)
+		return
+	}
+	f.tokens = append(f.tokens, r.Header.Get("Authorization"))
+	f.mu.Unlock()
+
+	f.api(w, r, n)
+}
+
+// count returns the number of calls of route
+func (f *fakeServer) count(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[route]
+}
+
+// writeError writes an error response like fg-apiserver
+func writeError(w http.ResponseWriter, errx *errorx.ErrorX) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(errx.Code)
+	_ = json.NewEncoder(w).Encode(errx)
+}
+
+func TestRefreshBeforeExpiry(t *testing.T) {
+	// Tokens expiring within the refresh skew are refreshed before the call
+	f, c := newFakeServer(t, 10*time.Second, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		_ = json.NewEncoder(w).Encode(v1.GetUserResponse{})
+	})
+
+	_, err := c.Users().Get(context.Background(), &v1.GetUserRequest{UserID: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.count("POST /refresh-token"))
+	assert.Equal(t, []string{"Bearer t2"}, f.tokens)
+
+	token, _, refreshToken := c.Token()
+	assert.Equal(t, "t2", token)
+	assert.Equal(t, "r2", refreshToken)
+}
+
+func TestRefreshOnExpiredToken(t *testing.T) {
+	// The server rejects t1 as expired although the client believes it is valid
+	f, c := newFakeServer(t, time.Hour, func(w http.ResponseWriter, r *http.Request, _ int) {
+		if r.Header.Get("Authorization") == "Bearer t1" {
+			writeError(w, errorx.ErrTokenExpired)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(v1.GetUserResponse{})
+	})
+
+	_, err := c.Users().Get(context.Background(), &v1.GetUserRequest{UserID: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.count("POST /refresh-token"))
+	assert.Equal(t, []string{"Bearer t1", "Bearer t2"}, f.tokens)
+
+	// The token is refreshed only once per call
+	f, c = newFakeServer(t, time.Hour, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		writeError(w, errorx.ErrTokenExpired)
+	})
+	_, err = c.Users().Get(context.Background(), &v1.GetUserRequest{UserID: "alice"})
+	assert.ErrorIs(t, err, ErrTokenExpired)
+	assert.Equal(t, 1, f.count("POST /refresh-token"))
+}
+
+func TestRetry(t *testing.T) {
+	unavailable := func(w http.ResponseWriter, _ *http.Request, n int) {
+		if n <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}
+
+	// Idempotent calls are retried
+	f, c := newFakeServer(t, time.Hour, unavailable)
+	_, err := c.Users().Get(context.Background(), &v1.GetUserRequest{UserID: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, f.count("GET /api/v1/user/alice"))
+
+	_, err = c.Posts().Delete(context.Background(), &v1.DeletePostRequest{PostID: []string{"post-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, f.count("DELETE /api/v1/post/post-1"))
+
+	// Other calls are not, they could have been applied before the failure
+	f, c = newFakeServer(t, time.Hour, unavailable)
+	_, err = c.Posts().Create(context.Background(), &v1.CreatePostRequest{Title: "title", Content: "content"})
+	require.Error(t, err)
+	assert.Equal(t, 1, f.count("POST /api/v1/post"))
+
+	// Idempotent calls give up after the configured retries
+	f, c = newFakeServer(t, time.Hour, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	_, err = c.Users().Get(context.Background(), &v1.GetUserRequest{UserID: "alice"})
+	require.Error(t, err)
+	assert.Equal(t, 4, f.count("GET /api/v1/user/alice"))
+
+	// Errors of the API are not retried
+	f, c = newFakeServer(t, time.Hour, func(w http.ResponseWriter, _ *http.Request, _ int) {
+		writeError(w, errorx.ErrUserNotFound)
+	})
+	_, err = c.Users().Get(context.Background(), &v1.GetUserRequest{UserID: "alice"})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+	assert.Equal(t, 1, f.count("GET /api/v1/user/alice"))
+}
+
+func TestErrorDecoding(t *testing.T) {
+	_, c := newFakeServer(t, time.Hour, func(w http.ResponseWriter, r *http.Request, _ int) {
+		switch r.URL.Path {
+		case "/api/v1/user/locked":
+			writeError(w, errorx.ErrLoginLocked.WithRequestID(r.Header.Get("X-Request-ID")).KV("retry_after", "60"))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("upstream unavailable\n"))
+		}
+	})
+	ctx := WithRequestID(context.Background(), "request-1")
+
+	_, err := c.Users().Get(ctx, &v1.GetUserRequest{UserID: "locked"})
+	assert.ErrorIs(t, err, ErrLoginLocked)
+	assert.NotErrorIs(t, err, ErrTooManyRequests)
+	var errx *Error
+	require.ErrorAs(t, err, &errx)
+	assert.Equal(t, http.StatusTooManyRequests, errx.Code)
+	assert.Equal(t, errorx.ErrLoginLocked.Message, errx.Message)
+	assert.Equal(t, map[string]string{"X-Request-ID": "request-1", "retry_after": "60"}, errx.Metadata)
+	assert.Equal(t, "ResourceExhausted.LoginLocked", Reason(err))
+
+	// Responses which are not fg-apiserver errors keep their status code and body
+	_, err = c.Users().Get(ctx, &v1.GetUserRequest{UserID: "other"})
+	require.ErrorAs(t, err, &errx)
+	assert.Equal(t, &Error{Code: http.StatusBadGateway, Message: "upstream unavailable"}, errx)
+	assert.Empty(t, Reason(err))
+	assert.Empty(t, Reason(context.Canceled))
+}
+
+func TestErrorsMatchServer(t *testing.T) {
+	for sentinel, server := range map[*Error]*errorx.ErrorX{
+		ErrInternal:           errorx.ErrInternal,
+		ErrNotFound:           errorx.ErrNotFound,
+		ErrBind:               errorx.ErrBind,
+		ErrInvalidArgument:    errorx.ErrInvalidArgument,
+		ErrPermissionDenied:   errorx.ErrPermissionDenied,
+		ErrTooManyRequests:    errorx.ErrTooManyRequests,
+		ErrTokenInvalid:       errorx.ErrTokenInvalid,
+		ErrTokenExpired:       errorx.ErrTokenExpired,
+		ErrTokenRevoked:       errorx.ErrTokenRevoked,
+		ErrSessionNotFound:    errorx.ErrSessionNotFound,
+		ErrInvalidCredentials: errorx.ErrInvalidCredentials,
+		ErrInvalidPassword:    errorx.ErrInvalidPassword,
+		ErrLoginLocked:        errorx.ErrLoginLocked,
+		ErrUserNotFound:       errorx.ErrUserNotFound,
+		ErrPostNotFound:       errorx.ErrPostNotFound,
+		ErrUserNameInvalid:    errorx.ErrUserNameInvalid,
+		ErrPasswordInvalid:    errorx.ErrPasswordInvalid,
+		ErrUserAlreadyExists:  errorx.ErrUserAlreadyExists,
+	} {
+		assert.Equal(t, server.Code, sentinel.Code, server.Reason)
+		assert.Equal(t, server.Reason, sentinel.Reason)
+	}
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Error is an error response of the server
+// Errors are compared by code and reason, like the errors of the server, so that the errors
+// returned by the client match the sentinels below with errors.Is.
+type Error struct {
+	// Code is the HTTP status code of the response
+	Code int `json:"code,omitempty"`
+	// Reason is a stable machine readable reason, empty for responses which are not
+	// fg-apiserver errors, e.g. of a proxy
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the error
+	Message string `json:"message,omitempty"`
+	// Metadata holds details of the error, such as the X-Request-ID of the call
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+func (err *Error) Error() string {
+	if err.Reason == "" {
+		return fmt.Sprintf("fg-apiserver: %d %s: %s", err.Code, http.StatusText(err.Code), err.Message)
+	}
+	return fmt.Sprintf("fg-apiserver: %s: %s", err.Reason, err.Message)
+}
+
+// Is reports whether target is an *Error with the same code and reason
+func (err *Error) Is(target error) bool {
+	if t := new(Error); errors.As(target, &t) {
+		return t.Code == err.Code && t.Reason == err.Reason
+	}
+	return false
+}
+
+// Reason returns the reason of an error returned by the client, or an empty string for
+// errors which are not error responses, such as network errors
+func Reason(err error) string {
+	if errx := new(Error); errors.As(err, &errx) {
+		return errx.Reason
+	}
+	return ""
+}
+
+// The errors returned by fg-apiserver, to be matched with errors.Is
+var (
+	ErrInternal         = &Error{Code: http.StatusInternalServerError, Reason: "InternalError"}
+	ErrNotFound         = &Error{Code: http.StatusNotFound, Reason: "NotFound"}
+	ErrBind             = &Error{Code: http.StatusBadRequest, Reason: "BindError"}
+	ErrInvalidArgument  = &Error{Code: http.StatusBadRequest, Reason: "InvalidArgument"}
+	ErrPermissionDenied = &Error{Code: http.StatusForbidden, Reason: "PermissionDenied"}
+	ErrTooManyRequests  = &Error{Code: http.StatusTooManyRequests, Reason: "ResourceExhausted.TooManyRequests"}
+
+	ErrTokenInvalid    = &Error{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TokenInvalid"}
+	ErrTokenExpired    = &Error{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TokenExpired"}
+	ErrTokenRevoked    = &Error{Code: http.StatusUnauthorized, Reason: "Unauthenticated.TokenRevoked"}
+	ErrSessionNotFound = &Error{Code: http.StatusUnauthorized, Reason: "Unauthenticated.SessionNotFound"}
+
+	ErrInvalidCredentials = &Error{Code: http.StatusUnauthorized, Reason: "Unauthenticated.InvalidCredentials"}
+	ErrInvalidPassword    = &Error{Code: http.StatusUnauthorized, Reason: "Unauthenticated.InvalidPassword"}
+	// ErrLoginLocked carries the seconds to wait in its retry_after metadata
+	ErrLoginLocked  = &Error{Code: http.StatusTooManyRequests, Reason: "ResourceExhausted.LoginLocked"}
+	ErrUserNotFound = &Error{Code: http.StatusNotFound, Reason: "NotFound.UserNotFound"}
+	ErrPostNotFound = &Error{Code: http.StatusNotFound, Reason: "NotFound.PostNotFound"}
+
+	// The invalid usernames and passwords, and the taken usernames and phones, are reported
+	// with these reasons instead of ErrInvalidArgument, the metadata holding the failed fields
+	ErrUserNameInvalid   = &Error{Code: http.StatusBadRequest, Reason: "InvalidArgument.UserNameInvalid"}
+	ErrPasswordInvalid   = &Error{Code: http.StatusBadRequest, Reason: "InvalidArgument.PasswordInvalid"}
+	ErrUserAlreadyExists = &Error{Code: http.StatusBadRequest, Reason: "AlreadyExist.UserAlreadyExists"}
+)
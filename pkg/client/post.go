@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+)
+
+// PostClient calls the post endpoints
+type PostClient struct {
+	c *Client
+}
+
+// Create creates a post owned by the logged in user
+func (p *PostClient) Create(ctx context.Context, req *v1.CreatePostRequest) (*v1.CreatePostResponse, error) {
+	var resp v1.CreatePostResponse
+	if err := p.c.do(ctx, http.MethodPost, "/api/v1/post", nil, req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Update updates the post req.PostID
+func (p *PostClient) Update(ctx context.Context, req *v1.UpdatePostRequest) (*v1.UpdatePostResponse, error) {
+	var resp v1.UpdatePostResponse
+	if err := p.c.do(ctx, http.MethodPut, "/api/v1/post/"+url.PathEscape(req.PostID), nil, req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Delete deletes the posts of req.PostID, one call per post
+func (p *PostClient) Delete(ctx context.Context, req *v1.DeletePostRequest) (*v1.DeletePostResponse, error) {
+	for _, postID := range req.PostID {
+		if err := p.c.do(ctx, http.MethodDelete, "/api/v1/post/"+url.PathEscape(postID), nil, nil, nil, true); err != nil {
+			return nil, err
+		}
+	}
+	return &v1.DeletePostResponse{}, nil
+}
+
+// Get gets the post req.PostID
+func (p *PostClient) Get(ctx context.Context, req *v1.GetPostRequest) (*v1.GetPostResponse, error) {
+	var resp v1.GetPostResponse
+	if err := p.c.do(ctx, http.MethodGet, "/api/v1/post/"+url.PathEscape(req.PostID), nil, nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// List lists the posts of the logged in user
+func (p *PostClient) List(ctx context.Context, req *v1.ListPostRequest) (*v1.ListPostResponse, error) {
+	var resp v1.ListPostResponse
+	query := pageQuery(req.Limit, req.Offset, req.Cursor, req.SkipCount, req.Filter, req.Sort)
+	if req.Title != nil {
+		query.Set("title", *req.Title)
+	}
+	if err := p.c.do(ctx, http.MethodGet, "/api/v1/post", query, nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
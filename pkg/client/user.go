@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	v1 "github.com/MortalSC/FastGO/pkg/api/apiserver/v1"
+)
+
+// UserClient calls the user endpoints
+type UserClient struct {
+	c *Client
+}
+
+// Create creates a user, it needs no login
+func (u *UserClient) Create(ctx context.Context, req *v1.CreateUserRequest) (*v1.CreateUserResponse, error) {
+	var resp v1.CreateUserResponse
+	if err := u.c.do(ctx, http.MethodPost, "/api/v1/user", nil, req, &resp, false); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Update updates the user req.UserID, which is a user ID or a username
+func (u *UserClient) Update(ctx context.Context, req *v1.UpdateUserRequest) (*v1.UpdateUserResponse, error) {
+	var resp v1.UpdateUserResponse
+	if err := u.c.do(ctx, http.MethodPut, "/api/v1/user/"+url.PathEscape(req.UserID), nil, req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Delete deletes the user req.UserID, which is a user ID or a username
+func (u *UserClient) Delete(ctx context.Context, req *v1.DeleteUserRequest) (*v1.DeleteUserResponse, error) {
+	var resp v1.DeleteUserResponse
+	if err := u.c.do(ctx, http.MethodDelete, "/api/v1/user/"+url.PathEscape(req.UserID), nil, nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Get gets the user req.UserID, which is a user ID or a username
+func (u *UserClient) Get(ctx context.Context, req *v1.GetUserRequest) (*v1.GetUserResponse, error) {
+	var resp v1.GetUserResponse
+	if err := u.c.do(ctx, http.MethodGet, "/api/v1/user/"+url.PathEscape(req.UserID), nil, nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// List lists users, which is reserved for administrators
+func (u *UserClient) List(ctx context.Context, req *v1.ListUserRequest) (*v1.ListUserResponse, error) {
+	var resp v1.ListUserResponse
+	query := pageQuery(req.Limit, req.Offset, req.Cursor, req.SkipCount, req.Filter, req.Sort)
+	if err := u.c.do(ctx, http.MethodGet, "/api/v1/user", query, nil, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ChangePassword changes the password of the user req.UserID, which revokes all its sessions
+func (u *UserClient) ChangePassword(ctx context.Context, req *v1.ChangePasswordRequest) (*v1.ChangePasswordResponse, error) {
+	var resp v1.ChangePasswordResponse
+	path := "/api/v1/user/" + url.PathEscape(req.UserID) + "/change-password"
+	if err := u.c.do(ctx, http.MethodPut, path, nil, req, &resp, true); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// pageQuery encodes the paging, filter and sort parameters of list requests
+func pageQuery(limit, offset int64, cursor string, skipCount bool, filter, sort string) url.Values {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.FormatInt(limit, 10))
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.FormatInt(offset, 10))
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if skipCount {
+		query.Set("skip_count", "true")
+	}
+	if filter != "" {
+		query.Set("filter", filter)
+	}
+	if sort != "" {
+		query.Set("sort", sort)
+	}
+	return query
+}
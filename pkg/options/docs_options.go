@@ -0,0 +1,34 @@
+package options
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// DocsOptions configures the API documentation page
+// The OpenAPI document itself is always served at /openapi.json
+type DocsOptions struct {
+	// UI serves Swagger UI at /docs
+	UI bool `json:"ui" mapstructure:"ui"`
+	// AssetsURL is the base URL of the swagger-ui-dist assets loaded by the page, the assets
+	// embedded in the server are served at /docs/assets when it is empty
+	AssetsURL string `json:"assets-url" mapstructure:"assets-url"`
+}
+
+// NewDocsOptions creates a DocsOptions instance with default values
+func NewDocsOptions() *DocsOptions {
+	return &DocsOptions{
+		UI: false,
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *DocsOptions) Validate() error {
+	if o == nil || o.AssetsURL == "" {
+		return nil
+	}
+	if u, err := url.Parse(o.AssetsURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("docs assets-url must be an http or https URL")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthOptions configures the checks of the /livez and /readyz probes
+type HealthOptions struct {
+	// Timeout bounds the duration of the checks of a probe
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+	// MinFreeDiskMB is the free disk space, in MiB, required next to the log file for the server to be ready
+	MinFreeDiskMB uint64 `json:"min-free-disk-mb" mapstructure:"min-free-disk-mb"`
+}
+
+// NewHealthOptions creates a HealthOptions instance with default values
+func NewHealthOptions() *HealthOptions {
+	return &HealthOptions{
+		Timeout:       3 * time.Second,
+		MinFreeDiskMB: 100,
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *HealthOptions) Validate() error {
+	if o.Timeout <= 0 {
+		return fmt.Errorf("health timeout must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/MortalSC/FastGO/pkg/token"
+)
+
+// JWTOptions configures the keys which sign and verify the issued JWT tokens, and how the
+// registered claims of parsed tokens are validated
+// When no key is configured, tokens are signed with HS256 using the top-level jwt_key
+type JWTOptions struct {
+	// Issuer is written to the `iss` claim and required from parsed tokens, empty disables the check
+	Issuer string `json:"issuer" mapstructure:"issuer"`
+	// Audience is written to the `aud` claim, parsed tokens must name one of its entries
+	Audience []string `json:"audience" mapstructure:"audience"`
+	// Leeway is the clock skew tolerated when checking exp, nbf and iat
+	Leeway time.Duration `json:"leeway" mapstructure:"leeway"`
+
+	// ActiveKeyID is the key ID of the key which signs new tokens
+	ActiveKeyID string `json:"active-key-id" mapstructure:"active-key-id"`
+	// Keys are the signing key and the retired keys which still verify the tokens they signed
+	Keys []JWTKeyOptions `json:"keys" mapstructure:"keys"`
+}
+
+// JWTKeyOptions describes a single key, identified by the `kid` header of the tokens
+type JWTKeyOptions struct {
+	// ID is the key ID
+	ID string `json:"id" mapstructure:"id"`
+	// Algorithm is one of HS256, RS256, ES256 or EdDSA
+	Algorithm string `json:"algorithm" mapstructure:"algorithm"`
+	// Secret is the shared secret of HS256 keys
+	Secret string `json:"secret" mapstructure:"secret"`
+	// PrivateKeyFile is the PEM private key of asymmetric keys, retired keys may omit it
+	PrivateKeyFile string `json:"private-key-file" mapstructure:"private-key-file"`
+	// PublicKeyFile is the PEM public key of asymmetric keys, derived from the private key if empty
+	PublicKeyFile string `json:"public-key-file" mapstructure:"public-key-file"`
+}
+
+// NewJWTOptions creates a JWTOptions instance with default values
+func NewJWTOptions() *JWTOptions {
+	return &JWTOptions{
+		Issuer:   "fg-apiserver",
+		Audience: []string{"fastgo"},
+		Leeway:   30 * time.Second,
+	}
+}
+
+// Enabled reports whether signing keys are configured
+func (o *JWTOptions) Enabled() bool {
+	return o != nil && len(o.Keys) > 0
+}
+
+// Validate checks the configuration options for validity
+func (o *JWTOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	if o.Leeway < 0 {
+		return fmt.Errorf("jwt leeway cannot be negative")
+	}
+
+	if !o.Enabled() {
+		return nil
+	}
+
+	if o.ActiveKeyID == "" {
+		return fmt.Errorf("jwt active-key-id is required when jwt keys are configured")
+	}
+
+	var active bool
+	for _, key := range o.Keys {
+		if key.ID == "" {
+			return fmt.Errorf("jwt key id cannot be empty")
+		}
+		if key.Algorithm == token.AlgHS256 && len(key.Secret) < 6 {
+			return fmt.Errorf("jwt key %q: secret must be at least 6 characters long", key.ID)
+		}
+		if key.ID == o.ActiveKeyID {
+			active = true
+		}
+	}
+	if !active {
+		return fmt.Errorf("jwt active key %q is not one of the configured keys", o.ActiveKeyID)
+	}
+
+	return nil
+}
+
+// TokenOptions returns the claim validation options of pkg/token
+func (o *JWTOptions) TokenOptions() []token.Option {
+	if o == nil {
+		return nil
+	}
+	return []token.Option{
+		token.WithIssuer(o.Issuer),
+		token.WithAudience(o.Audience...),
+		token.WithLeeway(o.Leeway),
+	}
+}
+
+// LoadKeys reads the configured keys
+func (o *JWTOptions) LoadKeys() ([]*token.Key, error) {
+	keys := make([]*token.Key, 0, len(o.Keys))
+	for _, opt := range o.Keys {
+		if opt.Algorithm == token.AlgHS256 {
+			keys = append(keys, token.NewHMACKey(opt.ID, opt.Secret))
+			continue
+		}
+
+		key, err := token.LoadKey(opt.ID, opt.Algorithm, opt.PrivateKeyFile, opt.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,51 @@
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// LockoutOptions configures the lockout of the accounts and the client IPs whose logins fail repeatedly
+type LockoutOptions struct {
+	// Enabled turns the lockout on
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// MaxFailures is the number of consecutive failed logins locking an account
+	MaxFailures int `json:"max-failures" mapstructure:"max-failures"`
+	// IPMaxFailures is the number of failed logins, on any account, locking a client IP
+	IPMaxFailures int `json:"ip-max-failures" mapstructure:"ip-max-failures"`
+	// Duration is the first lockout, which doubles with every further failure
+	Duration time.Duration `json:"duration" mapstructure:"duration"`
+	// MaxDuration caps the lockout
+	MaxDuration time.Duration `json:"max-duration" mapstructure:"max-duration"`
+	// Window is how long the failed logins are remembered after the last one or the end of the lockout
+	Window time.Duration `json:"window" mapstructure:"window"`
+}
+
+// NewLockoutOptions creates a LockoutOptions instance with default values
+func NewLockoutOptions() *LockoutOptions {
+	return &LockoutOptions{
+		Enabled:       true,
+		MaxFailures:   5,
+		IPMaxFailures: 20,
+		Duration:      time.Minute,
+		MaxDuration:   time.Hour,
+		Window:        15 * time.Minute,
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *LockoutOptions) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.MaxFailures < 0 || o.IPMaxFailures < 0 {
+		return fmt.Errorf("lockout max-failures and ip-max-failures must not be negative")
+	}
+	if o.Duration <= 0 || o.Window <= 0 {
+		return fmt.Errorf("lockout duration and window must be positive")
+	}
+	if o.MaxDuration < o.Duration {
+		return fmt.Errorf("lockout max-duration must not be shorter than duration")
+	}
+	return nil
+}
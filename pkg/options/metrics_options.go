@@ -0,0 +1,31 @@
+package options
+
+import (
+	"fmt"
+	"net"
+)
+
+// MetricsOptions configures the listener serving the Prometheus metrics at /metrics
+// The metrics are served apart from the API, on an address reachable by the scrapers only.
+type MetricsOptions struct {
+	// Addr is the address of the metrics listener, the metrics are not served if empty
+	Addr string `json:"addr" mapstructure:"addr"`
+}
+
+// NewMetricsOptions creates a MetricsOptions instance with default values
+func NewMetricsOptions() *MetricsOptions {
+	return &MetricsOptions{
+		Addr: "127.0.0.1:9090",
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *MetricsOptions) Validate() error {
+	if o.Addr == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(o.Addr); err != nil {
+		return fmt.Errorf("invalid metrics addr '%s': %w", o.Addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package options
+
+// MigrationOptions controls how the apiserver treats schema migrations on startup
+// Databases of the sqlite and memory drivers are always migrated automatically
+type MigrationOptions struct {
+	// AutoMigrate applies pending migrations before the server starts
+	AutoMigrate bool `json:"auto-migrate" mapstructure:"auto-migrate"`
+	// RequireUpToDate refuses to start the server when pending migrations exist
+	RequireUpToDate bool `json:"require-up-to-date" mapstructure:"require-up-to-date"`
+}
+
+// NewMigrationOptions creates a MigrationOptions instance with default values
+func NewMigrationOptions() *MigrationOptions {
+	return &MigrationOptions{
+		AutoMigrate:     false,
+		RequireUpToDate: false,
+	}
+}
+
+// Validate checks the configuration options for validity
+func (s *MigrationOptions) Validate() error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package options
+
+import (
+	"fmt"
+
+	"github.com/MortalSC/FastGO/pkg/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms of PasswordOptions.Algorithm
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// PasswordOptions configures how the passwords are hashed and which passwords users may choose
+// The hashes of the other algorithm, or with other parameters, are replaced on the next login.
+type PasswordOptions struct {
+	// Algorithm hashes the new passwords: bcrypt or argon2id
+	Algorithm string `json:"algorithm" mapstructure:"algorithm"`
+	// BcryptCost is the cost of bcrypt
+	BcryptCost int `json:"bcrypt-cost" mapstructure:"bcrypt-cost"`
+	// Argon2Memory is the memory used by an argon2id hash, in KiB
+	Argon2Memory uint32 `json:"argon2-memory" mapstructure:"argon2-memory"`
+	// Argon2Iterations is the number of passes of argon2id over the memory
+	Argon2Iterations uint32 `json:"argon2-iterations" mapstructure:"argon2-iterations"`
+	// Argon2Parallelism is the number of threads used by an argon2id hash
+	Argon2Parallelism uint8 `json:"argon2-parallelism" mapstructure:"argon2-parallelism"`
+	// MinLength and MaxLength bound the number of characters of the new passwords
+	// With bcrypt, MaxLength may not exceed auth.BcryptMaxBytes and the passwords are also
+	// limited to auth.BcryptMaxBytes bytes.
+	MinLength int `json:"min-length" mapstructure:"min-length"`
+	MaxLength int `json:"max-length" mapstructure:"max-length"`
+	// BreachListFile lists leaked passwords, which users may not choose
+	// It holds one password, or hex SHA-1 of a password, per line. It is loaded in memory, at
+	// about 50 bytes per password.
+	BreachListFile string `json:"breach-list-file" mapstructure:"breach-list-file"`
+}
+
+// NewPasswordOptions creates a PasswordOptions instance with default values
+func NewPasswordOptions() *PasswordOptions {
+	return &PasswordOptions{
+		Algorithm:         AlgorithmArgon2id,
+		BcryptCost:        auth.DefaultBcryptCost,
+		Argon2Memory:      auth.DefaultArgon2idParams.Memory,
+		Argon2Iterations:  auth.DefaultArgon2idParams.Iterations,
+		Argon2Parallelism: auth.DefaultArgon2idParams.Parallelism,
+		MinLength:         8,
+		MaxLength:         64,
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *PasswordOptions) Validate() error {
+	switch o.Algorithm {
+	case AlgorithmBcrypt:
+		if o.BcryptCost < bcrypt.MinCost || o.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("password bcrypt-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case AlgorithmArgon2id:
+		if o.Argon2Memory < 8*uint32(o.Argon2Parallelism) || o.Argon2Iterations < 1 || o.Argon2Parallelism < 1 {
+			return fmt.Errorf("password argon2-iterations and argon2-parallelism must be positive, and argon2-memory at least 8 KiB per thread")
+		}
+	default:
+		return fmt.Errorf("invalid password algorithm '%s', must be bcrypt or argon2id", o.Algorithm)
+	}
+
+	if o.MinLength < 1 || o.MaxLength < o.MinLength {
+		return fmt.Errorf("password min-length must be positive and not exceed max-length")
+	}
+	if o.Algorithm == AlgorithmBcrypt && o.MaxLength > auth.BcryptMaxBytes {
+		return fmt.Errorf("password max-length must not exceed %d with bcrypt, which hashes %d bytes at most", auth.BcryptMaxBytes, auth.BcryptMaxBytes)
+	}
+	return nil
+}
+
+// NewHasher creates the hasher of the configured algorithm
+func (o *PasswordOptions) NewHasher() *auth.Hasher {
+	if o.Algorithm == AlgorithmBcrypt {
+		return auth.NewHasher(auth.NewBcrypt(o.BcryptCost))
+	}
+
+	params := auth.DefaultArgon2idParams
+	params.Memory, params.Iterations, params.Parallelism = o.Argon2Memory, o.Argon2Iterations, o.Argon2Parallelism
+	return auth.NewHasher(auth.NewArgon2id(params))
+}
+
+// NewPolicy creates the policy of the new passwords: their length, a mix of letters and digits,
+// and their absence from the breach list
+func (o *PasswordOptions) NewPolicy() (auth.Policy, error) {
+	policies := []auth.Policy{auth.LengthPolicy(o.MinLength, o.MaxLength), auth.LettersAndDigitsPolicy}
+	if o.Algorithm == AlgorithmBcrypt {
+		policies = append(policies, auth.MaxBytesPolicy(auth.BcryptMaxBytes))
+	}
+
+	if o.BreachListFile != "" {
+		list, err := auth.LoadBreachList(o.BreachListFile)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, list)
+	}
+	return auth.Policies(policies...), nil
+}
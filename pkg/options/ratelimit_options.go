@@ -0,0 +1,80 @@
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// Keys of the buckets of RouteRateLimit.Key
+const (
+	// RateLimitKeyIP limits the requests of every client IP
+	RateLimitKeyIP = "ip"
+	// RateLimitKeyUser limits the requests of every authenticated user, and of every client IP otherwise
+	RateLimitKeyUser = "user"
+	// RateLimitKeyRoute limits the requests of every route, whoever sends them
+	RateLimitKeyRoute = "route"
+)
+
+// RouteRateLimit is the token bucket limiting the requests of a group of routes
+type RouteRateLimit struct {
+	// Requests is the number of requests allowed per Period, 0 disables the limit
+	Requests int `json:"requests" mapstructure:"requests"`
+	// Period is the duration over which Requests are allowed
+	Period time.Duration `json:"period" mapstructure:"period"`
+	// Burst is the number of requests allowed at once, Requests if 0
+	Burst int `json:"burst" mapstructure:"burst"`
+	// Key is what the requests are counted by: ip, user or route
+	Key string `json:"key" mapstructure:"key"`
+}
+
+// Validate checks the limit for validity, name identifies it in the errors
+func (l *RouteRateLimit) Validate(name string) error {
+	if l.Requests < 0 || l.Burst < 0 {
+		return fmt.Errorf("rate-limit %s requests and burst must not be negative", name)
+	}
+	if l.Requests > 0 && l.Period <= 0 {
+		return fmt.Errorf("rate-limit %s period must be positive", name)
+	}
+	switch l.Key {
+	case RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyRoute:
+	default:
+		return fmt.Errorf("invalid rate-limit %s key '%s', must be one of: ip, user, route", name, l.Key)
+	}
+	return nil
+}
+
+// RateLimitOptions configures the rate limits of the route groups, and of their gRPC methods
+type RateLimitOptions struct {
+	// Enabled turns the rate limits on
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Auth limits the logins and the token refreshes
+	Auth RouteRateLimit `json:"auth" mapstructure:"auth"`
+	// SignUp limits the creation of accounts
+	SignUp RouteRateLimit `json:"signup" mapstructure:"signup"`
+	// API limits the routes and the methods requiring authentication
+	API RouteRateLimit `json:"api" mapstructure:"api"`
+}
+
+// NewRateLimitOptions creates a RateLimitOptions instance with default values
+func NewRateLimitOptions() *RateLimitOptions {
+	return &RateLimitOptions{
+		Enabled: true,
+		Auth:    RouteRateLimit{Requests: 10, Period: time.Minute, Burst: 5, Key: RateLimitKeyIP},
+		SignUp:  RouteRateLimit{Requests: 10, Period: time.Hour, Burst: 5, Key: RateLimitKeyIP},
+		API:     RouteRateLimit{Requests: 600, Period: time.Minute, Burst: 100, Key: RateLimitKeyUser},
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *RateLimitOptions) Validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	if err := o.Auth.Validate("auth"); err != nil {
+		return err
+	}
+	if err := o.SignUp.Validate("signup"); err != nil {
+		return err
+	}
+	return o.API.Validate("api")
+}
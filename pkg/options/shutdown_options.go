@@ -0,0 +1,41 @@
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// ShutdownOptions configures the graceful shutdown of the server
+type ShutdownOptions struct {
+	// PreStopDelay is how long the server keeps serving after its readiness failed,
+	// which gives the load balancers the time to deregister it
+	PreStopDelay time.Duration `json:"pre-stop-delay" mapstructure:"pre-stop-delay"`
+	// DrainPeriod is how long the in-flight requests are given to complete before their
+	// connections are closed, the listeners being drained concurrently
+	DrainPeriod time.Duration `json:"drain-period" mapstructure:"drain-period"`
+	// Timeout bounds the whole shutdown, once the pre-stop delay elapsed
+	Timeout time.Duration `json:"timeout" mapstructure:"timeout"`
+}
+
+// NewShutdownOptions creates a ShutdownOptions instance with default values
+func NewShutdownOptions() *ShutdownOptions {
+	return &ShutdownOptions{
+		PreStopDelay: 0,
+		DrainPeriod:  10 * time.Second,
+		Timeout:      30 * time.Second,
+	}
+}
+
+// Validate checks the configuration options for validity
+func (o *ShutdownOptions) Validate() error {
+	if o.PreStopDelay < 0 || o.DrainPeriod < 0 {
+		return fmt.Errorf("shutdown pre-stop-delay and drain-period must not be negative")
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("shutdown timeout must be positive")
+	}
+	if o.DrainPeriod > o.Timeout {
+		return fmt.Errorf("shutdown drain-period %s must not exceed the timeout %s", o.DrainPeriod, o.Timeout)
+	}
+	return nil
+}
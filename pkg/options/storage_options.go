@@ -0,0 +1,97 @@
+package options
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	// DriverMySQL stores data in a MySQL server configured by MySQLOptions
+	DriverMySQL = "mysql"
+	// DriverSQLite stores data in a local SQLite database file
+	DriverSQLite = "sqlite"
+	// DriverMemory stores data in a SQLite database which lives in memory only
+	DriverMemory = "memory"
+)
+
+// memoryDBCounter makes every in-memory database name unique within the process
+var memoryDBCounter atomic.Int64
+
+// StorageOptions defines which storage backend the apiserver uses
+// Only the MySQL driver requires an external database service
+type StorageOptions struct {
+	// Driver is one of mysql, sqlite or memory
+	Driver string `json:"driver" mapstructure:"driver"`
+	// SQLitePath is the database file used by the sqlite driver
+	SQLitePath string `json:"sqlite-path" mapstructure:"sqlite-path"`
+}
+
+// NewStorageOptions creates a StorageOptions instance with default values
+func NewStorageOptions() *StorageOptions {
+	return &StorageOptions{
+		Driver:     DriverMySQL,
+		SQLitePath: "fastgo.db",
+	}
+}
+
+// Validate checks the configuration options for validity
+func (s *StorageOptions) Validate() error {
+	switch s.Driver {
+	case DriverMySQL, DriverMemory:
+	case DriverSQLite:
+		if s.SQLitePath == "" {
+			return fmt.Errorf("storage sqlite-path is required by the sqlite driver")
+		}
+	default:
+		return fmt.Errorf("unsupported storage driver '%s', must be one of: mysql, sqlite, memory", s.Driver)
+	}
+
+	return nil
+}
+
+// Dialect returns the SQL dialect spoken by the configured driver, mysql or sqlite
+func (s *StorageOptions) Dialect() string {
+	if s.Driver == DriverMySQL {
+		return DriverMySQL
+	}
+	return DriverSQLite
+}
+
+// DSN constructs the SQLite Data Source Name string
+// The memory driver gets a uniquely named shared-cache database so that every
+// connection in the pool sees the same data
+func (s *StorageOptions) DSN() string {
+	if s.Driver == DriverMemory {
+		return fmt.Sprintf("file:fastgo-%d?mode=memory&cache=shared&_pragma=busy_timeout(5000)", memoryDBCounter.Add(1))
+	}
+	return fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", s.SQLitePath)
+}
+
+// NewDB creates a GORM database instance for the sqlite and memory drivers
+// The schema is not created here, callers are expected to apply it
+func (s *StorageOptions) NewDB() (*gorm.DB, error) {
+	if s.Driver != DriverSQLite && s.Driver != DriverMemory {
+		return nil, fmt.Errorf("storage driver '%s' is not backed by sqlite", s.Driver)
+	}
+
+	db, err := gorm.Open(sqlite.Open(s.DSN()), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, and an in-memory database is dropped
+	// together with its last connection, so keep exactly one connection open
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	sqlDB.SetConnMaxLifetime(0)
+
+	return db, nil
+}
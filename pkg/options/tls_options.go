@@ -0,0 +1,126 @@
+package options
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+)
+
+// Client certificate policies of TLSOptions.ClientAuth
+const (
+	// ClientAuthRequire rejects clients without a certificate signed by the client CA
+	ClientAuthRequire = "require"
+	// ClientAuthVerifyIfGiven verifies the certificates clients present, but accepts clients without one
+	ClientAuthVerifyIfGiven = "verify-if-given"
+)
+
+// TLSOptions configures HTTPS and gRPC over TLS, and optionally mutual TLS
+// The certificate, the key and the client CA bundle are reloaded when they change on disk
+type TLSOptions struct {
+	// CertFile is the PEM certificate chain of the server, TLS is disabled if empty
+	CertFile string `json:"cert-file" mapstructure:"cert-file"`
+	// KeyFile is the PEM private key of the certificate
+	KeyFile string `json:"key-file" mapstructure:"key-file"`
+	// MinVersion is the minimum TLS version, 1.2 or 1.3
+	MinVersion string `json:"min-version" mapstructure:"min-version"`
+	// CipherSuites restricts the TLS 1.2 cipher suites by their IANA name, the secure Go defaults are used if empty
+	// TLS 1.3 cipher suites are not configurable
+	CipherSuites []string `json:"cipher-suites" mapstructure:"cipher-suites"`
+	// ClientCAFile is the PEM bundle verifying client certificates, client certificates are not requested if empty
+	ClientCAFile string `json:"client-ca-file" mapstructure:"client-ca-file"`
+	// ClientAuth is the client certificate policy when ClientCAFile is set, require or verify-if-given
+	ClientAuth string `json:"client-auth" mapstructure:"client-auth"`
+	// RedirectAddr is the address of a plain HTTP listener redirecting every request to HTTPS, disabled if empty
+	RedirectAddr string `json:"redirect-addr" mapstructure:"redirect-addr"`
+}
+
+// NewTLSOptions creates a TLSOptions instance with default values
+func NewTLSOptions() *TLSOptions {
+	return &TLSOptions{
+		MinVersion: "1.2",
+		ClientAuth: ClientAuthRequire,
+	}
+}
+
+// Enabled reports whether a server certificate is configured
+func (o *TLSOptions) Enabled() bool {
+	return o != nil && o.CertFile != ""
+}
+
+// Validate checks the configuration options for validity
+func (o *TLSOptions) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+
+	if o.KeyFile == "" {
+		return fmt.Errorf("tls key-file is required with cert-file")
+	}
+	if _, err := o.Version(); err != nil {
+		return err
+	}
+	if _, err := o.Ciphers(); err != nil {
+		return err
+	}
+	if _, err := o.ClientAuthType(); err != nil {
+		return err
+	}
+	if o.RedirectAddr != "" {
+		if _, _, err := net.SplitHostPort(o.RedirectAddr); err != nil {
+			return fmt.Errorf("invalid tls redirect-addr %s: %v", o.RedirectAddr, err)
+		}
+	}
+
+	return nil
+}
+
+// Version returns the minimum TLS version
+func (o *TLSOptions) Version() (uint16, error) {
+	switch o.MinVersion {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls min-version %q, must be 1.2 or 1.3", o.MinVersion)
+	}
+}
+
+// Ciphers returns the IDs of the configured cipher suites, nil selects the Go defaults
+// Only the suites Go considers secure are accepted
+func (o *TLSOptions) Ciphers() ([]uint16, error) {
+	if len(o.CipherSuites) == 0 {
+		return nil, nil
+	}
+
+	secure := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(o.CipherSuites))
+	for _, name := range o.CipherSuites {
+		id, ok := secure[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure tls cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ClientAuthType returns the client certificate policy
+func (o *TLSOptions) ClientAuthType() (tls.ClientAuthType, error) {
+	if o.ClientCAFile == "" {
+		return tls.NoClientCert, nil
+	}
+
+	switch o.ClientAuth {
+	case "", ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls client-auth %q, must be %s or %s", o.ClientAuth, ClientAuthRequire, ClientAuthVerifyIfGiven)
+	}
+}
@@ -0,0 +1,107 @@
+package options
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Span exporters of TracingOptions.Exporter
+const (
+	// ExporterNone disables tracing
+	ExporterNone = "none"
+	// ExporterStdout writes the spans to stdout, for development
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends the spans to an OpenTelemetry collector over OTLP/gRPC
+	ExporterOTLP = "otlp"
+)
+
+// TracingOptions configures the OpenTelemetry tracing of the requests
+type TracingOptions struct {
+	// Exporter is where the spans are exported to: none, stdout or otlp
+	Exporter string `json:"exporter" mapstructure:"exporter"`
+	// Endpoint is the host:port of the OTLP collector
+	Endpoint string `json:"endpoint" mapstructure:"endpoint"`
+	// Insecure connects to the OTLP collector without TLS
+	Insecure bool `json:"insecure" mapstructure:"insecure"`
+	// SampleRatio is the fraction of the traces started by fastgo which are sampled,
+	// traces started upstream follow the sampling decision of the caller
+	SampleRatio float64 `json:"sample-ratio" mapstructure:"sample-ratio"`
+	// ServiceName is the service.name resource attribute of the spans
+	ServiceName string `json:"service-name" mapstructure:"service-name"`
+}
+
+// NewTracingOptions creates a TracingOptions instance with default values
+func NewTracingOptions() *TracingOptions {
+	return &TracingOptions{
+		Exporter:    ExporterNone,
+		Endpoint:    "127.0.0.1:4317",
+		SampleRatio: 1,
+		ServiceName: "fg-apiserver",
+	}
+}
+
+// Enabled reports whether the spans are exported
+func (o *TracingOptions) Enabled() bool {
+	return o != nil && o.Exporter != "" && o.Exporter != ExporterNone
+}
+
+// Validate checks the configuration options for validity
+func (o *TracingOptions) Validate() error {
+	if !o.Enabled() {
+		return nil
+	}
+
+	switch o.Exporter {
+	case ExporterStdout:
+	case ExporterOTLP:
+		if o.Endpoint == "" {
+			return fmt.Errorf("tracing endpoint is required by the otlp exporter")
+		}
+	default:
+		return fmt.Errorf("invalid tracing exporter '%s', must be one of none, stdout or otlp", o.Exporter)
+	}
+
+	if o.SampleRatio < 0 || o.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample-ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// NewTracerProvider creates the tracer provider exporting the spans to the configured exporter
+func (o *TracingOptions) NewTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch o.Exporter {
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(o.Endpoint)}
+		if o.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("tracing exporter '%s' exports no spans", o.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(o.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.SampleRatio))),
+	), nil
+}
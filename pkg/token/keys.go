@@ -0,0 +1,202 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Supported signing algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is a key used to sign or verify tokens, identified by its key ID (kid)
+// A key without a signing key can only verify tokens, which is how retired keys
+// are kept around until the tokens they signed expire
+type Key struct {
+	// ID is the key ID written to the `kid` header of signed tokens
+	ID string
+	// Method is the signing method of the key
+	Method jwt.SigningMethod
+
+	// signKey is the []byte secret of HMAC keys, or the private key of asymmetric keys
+	signKey any
+	// verifyKey is the []byte secret of HMAC keys, or the public key of asymmetric keys
+	verifyKey any
+}
+
+// NewHMACKey creates a HS256 key from a shared secret
+func NewHMACKey(id, secret string) *Key {
+	return &Key{
+		ID:        id,
+		Method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secret),
+		verifyKey: []byte(secret),
+	}
+}
+
+// LoadKey creates a key of the algorithm from PEM encoded key files
+// The public key is derived from the private key when publicKeyFile is empty, and a key
+// loaded from a public key file only can verify but not sign tokens
+func LoadKey(id, alg, privateKeyFile, publicKeyFile string) (*Key, error) {
+	if privateKeyFile == "" && publicKeyFile == "" {
+		return nil, fmt.Errorf("key %q: a private or a public key file is required", id)
+	}
+
+	key := &Key{ID: id}
+
+	var (
+		parsePrivate func([]byte) (crypto.Signer, error)
+		parsePublic  func([]byte) (crypto.PublicKey, error)
+	)
+	switch alg {
+	case AlgRS256:
+		key.Method = jwt.SigningMethodRS256
+		parsePrivate = func(b []byte) (crypto.Signer, error) { return jwt.ParseRSAPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (crypto.PublicKey, error) { return jwt.ParseRSAPublicKeyFromPEM(b) }
+	case AlgES256:
+		key.Method = jwt.SigningMethodES256
+		parsePrivate = func(b []byte) (crypto.Signer, error) { return jwt.ParseECPrivateKeyFromPEM(b) }
+		parsePublic = func(b []byte) (crypto.PublicKey, error) { return jwt.ParseECPublicKeyFromPEM(b) }
+	case AlgEdDSA:
+		key.Method = jwt.SigningMethodEdDSA
+		parsePrivate = func(b []byte) (crypto.Signer, error) {
+			k, err := jwt.ParseEdPrivateKeyFromPEM(b)
+			if err != nil {
+				return nil, err
+			}
+			return k.(crypto.Signer), nil
+		}
+		parsePublic = jwt.ParseEdPublicKeyFromPEM
+	default:
+		return nil, fmt.Errorf("key %q: unsupported algorithm %q, must be one of: %s, %s, %s", id, alg, AlgRS256, AlgES256, AlgEdDSA)
+	}
+
+	if privateKeyFile != "" {
+		data, err := os.ReadFile(privateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		signer, err := parsePrivate(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: parse private key: %w", id, err)
+		}
+		key.signKey, key.verifyKey = signer, signer.Public()
+	}
+
+	if publicKeyFile != "" {
+		data, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		pub, err := parsePublic(data)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: parse public key: %w", id, err)
+		}
+		key.verifyKey = pub
+	}
+
+	// ES256 requires a P-256 key
+	if pub, ok := key.verifyKey.(*ecdsa.PublicKey); ok && pub.Curve.Params().Name != "P-256" {
+		return nil, fmt.Errorf("key %q: %s requires a P-256 key, got %s", id, alg, pub.Curve.Params().Name)
+	}
+
+	return key, nil
+}
+
+// NewKey creates a key from an in-memory private key, which is an *rsa.PrivateKey, a P-256
+// *ecdsa.PrivateKey or an ed25519.PrivateKey
+func NewKey(id string, privateKey crypto.Signer) (*Key, error) {
+	key := &Key{ID: id, signKey: privateKey, verifyKey: privateKey.Public()}
+
+	switch k := privateKey.(type) {
+	case *rsa.PrivateKey:
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		if k.Curve.Params().Name != "P-256" {
+			return nil, fmt.Errorf("key %q: %s requires a P-256 key", id, AlgES256)
+		}
+		key.Method = jwt.SigningMethodES256
+	case ed25519.PrivateKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("key %q: unsupported private key type %T", id, privateKey)
+	}
+
+	return key, nil
+}
+
+// CanSign reports whether the key holds a signing key
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// Symmetric reports whether the key is a shared secret, which must never be published
+func (k *Key) Symmetric() bool {
+	_, ok := k.verifyKey.([]byte)
+	return ok
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	// RSA public key
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP public key
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK returns the public key in the JSON Web Key format
+// It reports false for symmetric keys
+func (k *Key) JWK() (JWK, bool) {
+	jwk := JWK{Use: "sig", Alg: k.Method.Alg(), Kid: k.ID}
+	enc := base64.RawURLEncoding
+
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(pub.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return JWK{}, false
+		}
+		// The uncompressed point is 0x04 || X || Y
+		point := ecdhKey.Bytes()[1:]
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = enc.EncodeToString(point[:len(point)/2])
+		jwk.Y = enc.EncodeToString(point[len(point)/2:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(pub)
+	default:
+		return JWK{}, false
+	}
+
+	return jwk, true
+}
@@ -0,0 +1,130 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyFiles writes the PEM encoded private and public keys of private into dir
+func writeKeyFiles(t *testing.T, dir, name string, private crypto.Signer) (string, string) {
+	t.Helper()
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	require.NoError(t, err)
+
+	privateFile, publicFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+".pub.pem")
+	require.NoError(t, os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600))
+	require.NoError(t, os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600))
+	return privateFile, publicFile
+}
+
+func TestLoadKey(t *testing.T) {
+	restoreConfig(t)
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for alg, private := range map[string]crypto.Signer{AlgRS256: rsaKey, AlgES256: ecKey, AlgEdDSA: edKey} {
+		t.Run(alg, func(t *testing.T) {
+			privateFile, publicFile := writeKeyFiles(t, dir, alg, private)
+
+			key, err := LoadKey("signer", alg, privateFile, "")
+			require.NoError(t, err)
+			assert.True(t, key.CanSign())
+			assert.False(t, key.Symmetric())
+			assert.Equal(t, alg, key.Method.Alg())
+
+			// A public key only verifies the tokens
+			verifier, err := LoadKey("verifier", alg, "", publicFile)
+			require.NoError(t, err)
+			assert.False(t, verifier.CanSign())
+
+			require.NoError(t, InitWithKeys("signer", []*Key{key}, time.Minute))
+			token, _, err := Sign("user-1")
+			require.NoError(t, err)
+
+			verifier.ID = "signer"
+			require.NoError(t, InitWithKeys("other", []*Key{NewHMACKey("other", "secret"), verifier}, time.Minute))
+			_, err = Parse(token)
+			assert.NoError(t, err)
+		})
+	}
+
+	// The key files must hold a key of the algorithm
+	rsaFile, _ := writeKeyFiles(t, dir, "rsa", rsaKey)
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	p384File, _ := writeKeyFiles(t, dir, "p384", p384)
+	for name, load := range map[string]func() (*Key, error){
+		"no file":            func() (*Key, error) { return LoadKey("k", AlgRS256, "", "") },
+		"missing file":       func() (*Key, error) { return LoadKey("k", AlgRS256, filepath.Join(dir, "missing.pem"), "") },
+		"other algorithm":    func() (*Key, error) { return LoadKey("k", AlgES256, rsaFile, "") },
+		"unsupported alg":    func() (*Key, error) { return LoadKey("k", "HS512", rsaFile, "") },
+		"other curve":        func() (*Key, error) { return LoadKey("k", AlgES256, p384File, "") },
+		"in-memory P-384":    func() (*Key, error) { return NewKey("k", p384) },
+		"unsupported crypto": func() (*Key, error) { return NewKey("k", unsupportedSigner{ecKey}) },
+	} {
+		_, err := load()
+		assert.Error(t, err, name)
+	}
+}
+
+// unsupportedSigner is a crypto.Signer of no supported key type
+type unsupportedSigner struct {
+	crypto.Signer
+}
+
+func TestJWKSet(t *testing.T) {
+	restoreConfig(t)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := []*Key{newECKey(t, "b-ec"), NewHMACKey("shared", "secret")}
+	for id, private := range map[string]crypto.Signer{"a-rsa": rsaKey, "c-ed": edKey} {
+		key, err := NewKey(id, private)
+		require.NoError(t, err)
+		keys = append(keys, key)
+	}
+	require.NoError(t, InitWithKeys("b-ec", keys, time.Minute))
+
+	// Shared secrets are never published, the keys are sorted by kid
+	set := JWKSet()
+	require.Len(t, set.Keys, 3)
+	assert.Equal(t, []string{"a-rsa", "b-ec", "c-ed"}, []string{set.Keys[0].Kid, set.Keys[1].Kid, set.Keys[2].Kid})
+
+	assert.Equal(t, "RSA", set.Keys[0].Kty)
+	assert.Equal(t, AlgRS256, set.Keys[0].Alg)
+	assert.Equal(t, "AQAB", set.Keys[0].E)
+	assert.NotEmpty(t, set.Keys[0].N)
+
+	assert.Equal(t, "EC", set.Keys[1].Kty)
+	assert.Equal(t, "P-256", set.Keys[1].Crv)
+	assert.Len(t, set.Keys[1].X, 43)
+	assert.Len(t, set.Keys[1].Y, 43)
+
+	assert.Equal(t, JWK{Kty: "OKP", Use: "sig", Alg: AlgEdDSA, Kid: "c-ed", Crv: "Ed25519", X: set.Keys[2].X}, set.Keys[2])
+	assert.Len(t, set.Keys[2].X, 43)
+}
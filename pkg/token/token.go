@@ -3,6 +3,8 @@ package token
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,67 +13,206 @@ import (
 )
 
 type Config struct {
-	key         string
-	identityKey string
-	expiration  time.Duration
+	// signingKey signs the issued tokens
+	signingKey *Key
+	// keys verify tokens by the key ID of their `kid` header, including retired keys
+	keys       map[string]*Key
+	expiration time.Duration
+
+	// issuer is written to the `iss` claim and required from parsed tokens when set
+	issuer string
+	// audience is written to the `aud` claim, parsed tokens must name one of its entries when set
+	audience []string
+	// leeway is the clock skew tolerated when checking the time based claims
+	leeway time.Duration
 }
 
+// Option customizes the validation of the issued and parsed tokens
+type Option func(*Config)
+
+// WithIssuer sets the issuer (iss) of the tokens
+func WithIssuer(issuer string) Option {
+	return func(c *Config) { c.issuer = issuer }
+}
+
+// WithAudience sets the audience (aud) of the tokens
+func WithAudience(audience ...string) Option {
+	return func(c *Config) { c.audience = audience }
+}
+
+// WithLeeway sets the clock skew tolerated when checking exp, nbf and iat
+func WithLeeway(leeway time.Duration) Option {
+	return func(c *Config) { c.leeway = leeway }
+}
+
+// Claims are the claims carried by the tokens of this package
+// The subject (sub) holds the identity, and the token ID (jti) the session the token belongs to
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// Username is the username of the subject
+	Username string `json:"username,omitempty"`
+	// Roles are the roles of the subject
+	Roles []string `json:"roles,omitempty"`
+}
+
+// defaultKey is the shared HS256 key used when none is configured
+const defaultKey = "Rtg8BPKNEf2mB4mgvKONGPZZQSaJWNLijxR42qRgq0iBb5"
+
 var (
-	config = Config{
-		key:         "Rtg8BPKNEf2mB4mgvKONGPZZQSaJWNLijxR42qRgq0iBb5",
-		identityKey: "identityKey",
-		expiration:  2 * time.Hour,
-	}
+	config = newConfig(NewHMACKey("", defaultKey), 2*time.Hour, nil, nil)
 
-	once sync.Once
+	mu sync.RWMutex
 )
 
+func newConfig(signingKey *Key, expiration time.Duration, keys []*Key, opts []Option) Config {
+	cfg := Config{
+		signingKey: signingKey,
+		keys:       map[string]*Key{signingKey.ID: signingKey},
+		expiration: expiration,
+	}
+	for _, key := range keys {
+		cfg.keys[key.ID] = key
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
 // Init sets the packag-level configuration config, which is used for token issuance and parsing in the subsequent parts of this package.
-func Init(key string, identityKey string, expiration time.Duration) {
-	once.Do(func() {
-		if key != "" {
-			config.key = key
+// Tokens are signed with HS256 using the shared key.
+func Init(key string, expiration time.Duration, opts ...Option) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if key == "" {
+		key = defaultKey
+	}
+	if expiration == 0 {
+		expiration = config.expiration
+	}
+	config = newConfig(NewHMACKey("", key), expiration, nil, opts)
+}
+
+// InitWithKeys configures the package to sign tokens with the key identified by activeKeyID and to
+// verify tokens with any of the keys. Keeping retired keys in keys lets the tokens they signed stay
+// valid until they expire, so signing keys can be rotated without logging everyone out.
+func InitWithKeys(activeKeyID string, keys []*Key, expiration time.Duration, opts ...Option) error {
+	var signingKey *Key
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key.ID] {
+			return fmt.Errorf("duplicate key id %q", key.ID)
 		}
-		if identityKey != "" {
-			config.identityKey = identityKey
+		seen[key.ID] = true
+
+		if key.ID == activeKeyID {
+			signingKey = key
 		}
-		if expiration != 0 {
-			config.expiration = expiration
+	}
+	if signingKey == nil {
+		return fmt.Errorf("active key %q is not configured", activeKeyID)
+	}
+	if !signingKey.CanSign() {
+		return fmt.Errorf("active key %q has no private key", activeKeyID)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if expiration == 0 {
+		expiration = config.expiration
+	}
+	config = newConfig(signingKey, expiration, keys, opts)
+
+	return nil
+}
+
+// JWKSet returns the public keys which verify tokens, shared secrets are never included
+func JWKSet() JWKS {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range config.keys {
+		if jwk, ok := key.JWK(); ok {
+			set.Keys = append(set.Keys, jwk)
 		}
-	})
+	}
+	slices.SortFunc(set.Keys, func(a, b JWK) int { return strings.Compare(a.Kid, b.Kid) })
+
+	return set
 }
 
-// Parse uses the specified key key to parse the token. If the parsing is successful, it returns the token context; otherwise, it reports an error.
-func Parse(tokenString string, key string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+// Parse uses the configured keys to parse the token. If the parsing is successful, it returns the claims of the token; otherwise, it reports an error.
+// The key is selected by the `kid` header of the token and must match the signing method of the token.
+// The time based claims are checked with the configured leeway, tokens without an expiration are
+// rejected, and the issuer and the audience are checked when they are configured.
+func Parse(tokenString string) (*Claims, error) {
+	mu.RLock()
+	cfg := config
+	mu.RUnlock()
+
+	keys := cfg.keys
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, jwt.ErrSignatureInvalid
 		}
-		return []byte(key), nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	var identityKey string
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if key, exists := claims[config.identityKey]; exists {
-			if identity, valid := key.(string); valid {
-				identityKey = identity
-			}
-		}
+	if !token.Valid || claims.Subject == "" {
+		return nil, jwt.ErrSignatureInvalid
 	}
 
-	if identityKey == "" {
-		return "", jwt.ErrSignatureInvalid
+	if err := cfg.validate(claims, time.Now()); err != nil {
+		return nil, err
 	}
 
-	return identityKey, nil
+	return claims, nil
 }
 
-// ParseRequest retrieves the token from the request header and passes it to the Parse function to parse the token.
-func ParseRequest(c *gin.Context) (string, error) {
+// validate checks the registered claims of a token whose signature is valid
+func (c *Config) validate(claims *Claims, now time.Time) error {
+	// Tokens without an expiration would never expire
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: no expiration", jwt.ErrTokenExpired)
+	}
+	if !claims.VerifyExpiresAt(now.Add(-c.leeway), true) {
+		return fmt.Errorf("%w: expired at %s", jwt.ErrTokenExpired, claims.ExpiresAt.Time)
+	}
+	if !claims.VerifyNotBefore(now.Add(c.leeway), false) {
+		return fmt.Errorf("%w: not valid before %s", jwt.ErrTokenNotValidYet, claims.NotBefore.Time)
+	}
+	if !claims.VerifyIssuedAt(now.Add(c.leeway), false) {
+		return fmt.Errorf("%w: issued at %s", jwt.ErrTokenUsedBeforeIssued, claims.IssuedAt.Time)
+	}
+	if c.issuer != "" && !claims.VerifyIssuer(c.issuer, true) {
+		return fmt.Errorf("%w: unexpected issuer %q", jwt.ErrTokenInvalidIssuer, claims.Issuer)
+	}
+	if len(c.audience) > 0 && !slices.ContainsFunc(c.audience, func(aud string) bool {
+		return claims.VerifyAudience(aud, true)
+	}) {
+		return fmt.Errorf("%w: unexpected audience %v", jwt.ErrTokenInvalidAudience, claims.Audience)
+	}
+
+	return nil
+}
+
+// FromRequest retrieves the bearer token from the `Authorization` header of the request.
+func FromRequest(c *gin.Context) (string, error) {
 	header := c.Request.Header.Get("Authorization")
 
 	if len(header) == 0 {
@@ -81,28 +222,65 @@ func ParseRequest(c *gin.Context) (string, error) {
 	var token string
 	fmt.Sscanf(header, "Bearer %s", &token)
 
-	return Parse(token, config.key)
+	return token, nil
 }
 
-// Sign uses jwtSecret to issue tokens, and the claims of the token will store the incoming subject.
-func Sign(identityKey string) (string, time.Time, error) {
-	expireAt := time.Now().Add(config.expiration)
+// ParseRequest retrieves the token from the request header and passes it to the Parse function to parse the token.
+func ParseRequest(c *gin.Context) (*Claims, error) {
+	token, err := FromRequest(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(token)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		config.identityKey: identityKey,
-		"exp":              expireAt.Unix(),
-		"iat":              time.Now().Unix(),
-		"nbf":              time.Now().Unix(),
+// Sign uses the active signing key to issue tokens, and the claims of the token will store the incoming subject and its roles.
+func Sign(identityKey string, roles ...string) (string, time.Time, error) {
+	return SignClaims(&Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: identityKey},
+		Roles:            roles,
 	})
+}
+
+// SignClaims issues a token with the given claims using the active signing key.
+// The issue time, the not-before time, the expiration time, the issuer and the audience are filled in when they are not set.
+func SignClaims(claims *Claims) (string, time.Time, error) {
+	mu.RLock()
+	cfg := config
+	mu.RUnlock()
 
-	if config.key == "" {
+	signingKey := cfg.signingKey
+
+	now := time.Now()
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+	if claims.NotBefore == nil {
+		claims.NotBefore = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(cfg.expiration))
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = cfg.issuer
+	}
+	if len(claims.Audience) == 0 {
+		claims.Audience = cfg.audience
+	}
+
+	if secret, ok := signingKey.signKey.([]byte); ok && len(secret) == 0 {
 		return "", time.Time{}, jwt.ErrInvalidKey
 	}
 
-	tokenString, err := token.SignedString([]byte(config.key))
+	token := jwt.NewWithClaims(signingKey.Method, claims)
+	if signingKey.ID != "" {
+		token.Header["kid"] = signingKey.ID
+	}
+	tokenString, err := token.SignedString(signingKey.signKey)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 
-	return tokenString, expireAt, nil
+	return tokenString, claims.ExpiresAt.Time, nil
 }
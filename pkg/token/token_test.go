@@ -0,0 +1,214 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreConfig restores the package configuration changed by a test
+func restoreConfig(t *testing.T) {
+	t.Helper()
+
+	mu.RLock()
+	saved := config
+	mu.RUnlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		config = saved
+		mu.Unlock()
+	})
+}
+
+// signWith signs claims with key, bypassing the configuration of the package
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, signKey any, claims *Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(signKey)
+	require.NoError(t, err)
+	return s
+}
+
+func newECKey(t *testing.T, id string) *Key {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	key, err := NewKey(id, private)
+	require.NoError(t, err)
+	return key
+}
+
+func TestSignAndParse(t *testing.T) {
+	restoreConfig(t)
+	Init("test-key", time.Minute)
+
+	token, expireAt, err := Sign("user-1", "admin")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expireAt, 2*time.Second)
+
+	claims, err := Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, []string{"admin"}, claims.Roles)
+
+	// Tokens of another key are rejected
+	Init("other-key", time.Minute)
+	_, err = Parse(token)
+	assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+
+	// Tokens without subject are rejected
+	token, _, err = SignClaims(&Claims{})
+	require.NoError(t, err)
+	_, err = Parse(token)
+	assert.ErrorIs(t, err, jwt.ErrSignatureInvalid)
+}
+
+func TestParseTimes(t *testing.T) {
+	restoreConfig(t)
+	Init("test-key", time.Minute, WithLeeway(30*time.Second))
+	secret := []byte("test-key")
+	now := time.Now()
+	expiresAt := jwt.NewNumericDate(now.Add(time.Minute))
+
+	for _, tc := range []struct {
+		name   string
+		claims jwt.RegisteredClaims
+		err    error
+	}{
+		{"expired within the leeway", jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-10 * time.Second))}, nil},
+		{"expired", jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(-time.Minute))}, jwt.ErrTokenExpired},
+		{"no expiration", jwt.RegisteredClaims{}, jwt.ErrTokenExpired},
+		{"valid soon", jwt.RegisteredClaims{ExpiresAt: expiresAt, NotBefore: jwt.NewNumericDate(now.Add(10 * time.Second))}, nil},
+		{"not valid yet", jwt.RegisteredClaims{ExpiresAt: expiresAt, NotBefore: jwt.NewNumericDate(now.Add(time.Minute))}, jwt.ErrTokenNotValidYet},
+		{"issued in the future", jwt.RegisteredClaims{ExpiresAt: expiresAt, IssuedAt: jwt.NewNumericDate(now.Add(time.Minute))}, jwt.ErrTokenUsedBeforeIssued},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.claims.Subject = "user-1"
+			_, err := Parse(signWith(t, jwt.SigningMethodHS256, "", secret, &Claims{RegisteredClaims: tc.claims}))
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestIssuerAndAudience(t *testing.T) {
+	restoreConfig(t)
+	Init("test-key", time.Minute, WithIssuer("fastgo"), WithAudience("fg-apiserver", "fgctl"))
+	secret := []byte("test-key")
+
+	// The issued tokens carry the issuer and the audience
+	token, _, err := Sign("user-1")
+	require.NoError(t, err)
+	claims, err := Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, "fastgo", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"fg-apiserver", "fgctl"}, claims.Audience)
+
+	for _, tc := range []struct {
+		name   string
+		claims jwt.RegisteredClaims
+		err    error
+	}{
+		{"one of the audiences", jwt.RegisteredClaims{Issuer: "fastgo", Audience: jwt.ClaimStrings{"other", "fgctl"}}, nil},
+		{"other issuer", jwt.RegisteredClaims{Issuer: "other", Audience: jwt.ClaimStrings{"fgctl"}}, jwt.ErrTokenInvalidIssuer},
+		{"no issuer", jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"fgctl"}}, jwt.ErrTokenInvalidIssuer},
+		{"other audience", jwt.RegisteredClaims{Issuer: "fastgo", Audience: jwt.ClaimStrings{"other"}}, jwt.ErrTokenInvalidAudience},
+		{"no audience", jwt.RegisteredClaims{Issuer: "fastgo"}, jwt.ErrTokenInvalidAudience},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.claims.Subject = "user-1"
+			tc.claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+			_, err := Parse(signWith(t, jwt.SigningMethodHS256, "", secret, &Claims{RegisteredClaims: tc.claims}))
+			if tc.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	restoreConfig(t)
+	oldKey, newKey := newECKey(t, "old"), newECKey(t, "new")
+
+	require.NoError(t, InitWithKeys("old", []*Key{oldKey}, time.Minute))
+	oldToken, _, err := Sign("user-1")
+	require.NoError(t, err)
+
+	// The retired key keeps verifying the tokens it signed, the new tokens are signed by the active key
+	retired := &Key{ID: oldKey.ID, Method: oldKey.Method, verifyKey: oldKey.verifyKey}
+	require.NoError(t, InitWithKeys("new", []*Key{newKey, retired}, time.Minute))
+	_, err = Parse(oldToken)
+	require.NoError(t, err)
+
+	newToken, _, err := Sign("user-1")
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, AlgES256, parsed.Method.Alg())
+
+	// Tokens of unknown keys are rejected
+	require.NoError(t, InitWithKeys("new", []*Key{newKey}, time.Minute))
+	_, err = Parse(oldToken)
+	assert.ErrorContains(t, err, `unknown key id "old"`)
+
+	// Invalid key sets are refused
+	assert.ErrorContains(t, InitWithKeys("new", []*Key{newKey, newKey}, time.Minute), "duplicate key id")
+	assert.ErrorContains(t, InitWithKeys("other", []*Key{newKey}, time.Minute), "is not configured")
+	assert.ErrorContains(t, InitWithKeys("old", []*Key{retired}, time.Minute), "has no private key")
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	restoreConfig(t)
+	key := newECKey(t, "es")
+	require.NoError(t, InitWithKeys("es", []*Key{key, NewHMACKey("hs", "secret")}, time.Minute))
+	claims := &Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "user-1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}}
+
+	// A token must be signed with the algorithm of the key named by its kid
+	_, err := Parse(signWith(t, jwt.SigningMethodHS256, "es", []byte("secret"), claims))
+	assert.True(t, errors.Is(err, jwt.ErrSignatureInvalid), "%v", err)
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, err = Parse(signWith(t, jwt.SigningMethodES256, "hs", private, claims))
+	assert.True(t, errors.Is(err, jwt.ErrSignatureInvalid), "%v", err)
+
+	// Unsigned tokens are rejected
+	_, err = Parse(signWith(t, jwt.SigningMethodNone, "es", jwt.UnsafeAllowNoneSignatureType, claims))
+	assert.Error(t, err)
+
+	_, err = Parse(signWith(t, jwt.SigningMethodHS256, "hs", []byte("secret"), claims))
+	assert.NoError(t, err)
+}
+
+func TestFromRequest(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	_, err := FromRequest(c)
+	assert.Error(t, err)
+
+	c.Request.Header.Set("Authorization", "Bearer abc.def.ghi")
+	token, err := FromRequest(c)
+	require.NoError(t, err)
+	assert.Equal(t, "abc.def.ghi", token)
+}
@@ -0,0 +1,31 @@
+#!/usr/bin/env bash
+
+# Generates the Go code of the protobuf APIs in pkg/api.
+# The generators are pinned so that the checked-in code is reproducible: protoc must be
+# PROTOC_VERSION (https://github.com/protocolbuffers/protobuf/releases), the plugins are
+# installed at their pinned versions into _output/bin.
+set -o errexit
+set -o nounset
+set -o pipefail
+
+PROTOC_VERSION=29.3
+PROTOC_GEN_GO_VERSION=v1.36.6
+PROTOC_GEN_GO_GRPC_VERSION=v1.5.1
+
+PROJ_ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+API_ROOT=${PROJ_ROOT}/pkg/api
+TOOLS_BIN=${PROJ_ROOT}/_output/bin
+
+if [[ "$(protoc --version)" != "libprotoc ${PROTOC_VERSION}" ]]; then
+  echo "protoc ${PROTOC_VERSION} is required, found: $(protoc --version)" >&2
+  exit 1
+fi
+
+GOBIN=${TOOLS_BIN} go install google.golang.org/protobuf/cmd/protoc-gen-go@${PROTOC_GEN_GO_VERSION}
+GOBIN=${TOOLS_BIN} go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@${PROTOC_GEN_GO_GRPC_VERSION}
+
+cd "${API_ROOT}"
+PATH=${TOOLS_BIN}:${PATH} protoc --proto_path=. \
+  --go_out=paths=source_relative:. \
+  --go-grpc_out=paths=source_relative:. \
+  apiserver/rpc/v1/*.proto
@@ -88,7 +88,7 @@ fg::test::post()
   echo -e "\033[32m5. 成功更新博客 ${postID} 信息\033[0m"
 
   # 7. 删除所创建的博客
-  ${DCURL} "${token}" http://${INSECURE_SERVER}/api/v1/post -d'{"postIDs":["'${postID}'"]}'; echo
+  ${DCURL} "${token}" http://${INSECURE_SERVER}/api/v1/post/${postID}; echo
   echo -e "\033[32m6. 成功删除博客 ${postID}\033[0m"
 
   ${DCURL} "${token}" http://${INSECURE_SERVER}/api/v1/user/${username}; echo